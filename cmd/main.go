@@ -1,15 +1,23 @@
 package main
 
 import (
+	"context"
 	_ "ecommerce-api/docs"
+	"ecommerce-api/internal/blob"
 	"ecommerce-api/internal/config"
 	"ecommerce-api/internal/controllers"
 	"ecommerce-api/internal/database"
+	"ecommerce-api/internal/jobs"
 	"ecommerce-api/internal/logger"
 	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/notify"
+	"ecommerce-api/internal/password"
+	"ecommerce-api/internal/payment"
 	"ecommerce-api/internal/repository"
 	"ecommerce-api/internal/routes"
 	"ecommerce-api/internal/services"
+	"ecommerce-api/internal/tax"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -44,6 +52,35 @@ func main() {
 		&models.User{},
 		&models.Order{},
 		&models.Product{},
+		&models.Address{},
+		&models.AuditLog{},
+		&models.ErasureRequest{},
+		&models.Session{},
+		&models.PasswordResetToken{},
+		&models.IdempotencyKey{},
+		&models.Promotion{},
+		&models.PromotionRedemption{},
+		&models.OrderTaxLine{},
+		&models.ShippingZone{},
+		&models.ShippingMethod{},
+		&models.Shipment{},
+		&models.ShipmentItem{},
+		&models.ReturnRequest{},
+		&models.ReturnItem{},
+		&models.ReturnEvent{},
+		&models.Refund{},
+		&models.Invoice{},
+		&models.InvoiceSequence{},
+		&models.OrderEvent{},
+		&models.StockMovement{},
+		&models.Warehouse{},
+		&models.WarehouseStock{},
+		&models.ProductImage{},
+		&models.Review{},
+		&models.ReviewVote{},
+		&models.ProductRating{},
+		&models.WishlistItem{},
+		&models.WishlistNotification{},
 	)
 	if err != nil {
 		logger.Fatal("Error running migrations: " + err.Error())
@@ -53,22 +90,161 @@ func main() {
 	userRepo := repository.NewUserRepository(db)
 	orderRepo := repository.NewOrderRepository(db)
 	productRepo := repository.NewProductRepository(db)
+	addressRepo := repository.NewAddressRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	promotionRepo := repository.NewPromotionRepository(db)
+	shippingRepo := repository.NewShippingRepository(db)
+	shipmentRepo := repository.NewShipmentRepository(db)
+	returnRepo := repository.NewReturnRepository(db)
+	invoiceRepo := repository.NewInvoiceRepository(db)
+	inventoryRepo := repository.NewInventoryRepository(db)
+	warehouseRepo := repository.NewWarehouseRepository(db)
+	productImageRepo := repository.NewProductImageRepository(db)
+	reviewRepo := repository.NewReviewRepository(db)
+	wishlistRepo := repository.NewWishlistRepository(db)
+	locker := repository.NewAdvisoryLocker(db)
+
+	// Password policy, hashing and notification delivery
+	passwordPolicy := &password.Policy{
+		MinLength:      cfg.PasswordMinLength,
+		MaxLength:      cfg.PasswordMaxLength,
+		RequireUpper:   cfg.PasswordRequireUpper,
+		RequireLower:   cfg.PasswordRequireLower,
+		RequireDigit:   cfg.PasswordRequireDigit,
+		RequireSymbol:  cfg.PasswordRequireSymbol,
+		RejectBreached: cfg.PasswordRejectBreached,
+	}
+	hasher, err := password.NewHasher(
+		cfg.PasswordHasher,
+		cfg.BcryptCost,
+		uint32(cfg.Argon2Time),
+		uint32(cfg.Argon2MemoryKiB),
+		uint8(cfg.Argon2Parallelism),
+	)
+	if err != nil {
+		logger.Fatal("Error configuring password hashing: " + err.Error())
+	}
+	var notifier notify.Notifier = notify.NewLogNotifier()
+	if cfg.SMTPHost != "" {
+		notifier = notify.NewSMTPNotifier(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	}
+	payments := payment.NewManualProvider()
+
+	// Uploaded files go to a local directory unless an S3-compatible bucket is configured
+	var blobStore blob.BlobStore
+	if cfg.BlobStore == "s3" {
+		blobStore = blob.NewS3Store(blob.S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+		})
+	} else if blobStore, err = blob.NewLocalStore(cfg.BlobLocalDir); err != nil {
+		logger.Fatal("Error preparing blob storage: " + err.Error())
+	}
+
+	// Low-stock alerts are always logged, and also emailed or posted to a webhook when configured
+	alertNotifiers := []notify.Notifier{notify.NewLogNotifier()}
+	if cfg.LowStockAlertEmail != "" && cfg.SMTPHost != "" {
+		alertNotifiers = append(alertNotifiers, notifier)
+	}
+	if cfg.LowStockWebhookURL != "" {
+		alertNotifiers = append(alertNotifiers, notify.NewWebhookNotifier(cfg.LowStockWebhookURL))
+	}
+	inventorySettings := services.InventorySettings{
+		AllocationStrategy: cfg.InventoryAllocationStrategy,
+		LowStockNotifier:   notify.NewMultiNotifier(alertNotifiers...),
+		LowStockRecipient:  cfg.LowStockAlertEmail,
+	}
+
+	// Tax rates are optional; without a rate table no tax is charged
+	taxSettings := services.TaxSettings{
+		PricesIncludeTax: cfg.TaxPricesIncludeTax,
+		OriginCountry:    cfg.TaxOriginCountry,
+		OriginRegion:     cfg.TaxOriginRegion,
+	}
+	if cfg.TaxRatesFile != "" {
+		rates, err := tax.LoadRatesFile(cfg.TaxRatesFile)
+		if err != nil {
+			logger.Fatal("Error loading tax rates: " + err.Error())
+		}
+		calculator, err := tax.NewRuleBasedCalculator(rates)
+		if err != nil {
+			logger.Fatal("Error loading tax rates: " + err.Error())
+		}
+		taxSettings.Calculator = calculator
+	}
 
 	// Initialize services
-	userService := services.NewUserService(userRepo)
-	orderService := services.NewOrderService(orderRepo)
-	productService := services.NewProductService(productRepo)
+	userService := services.NewUserService(userRepo, addressRepo, sessionRepo, passwordPolicy, hasher, notifier)
+	promotionService := services.NewPromotionService(promotionRepo)
+	shippingService := services.NewShippingService(shippingRepo, productRepo, addressRepo)
+	shipmentService := services.NewShipmentService(shipmentRepo, orderRepo)
+	invoiceService := services.NewInvoiceService(invoiceRepo, orderRepo, productRepo, userRepo, services.SellerDetails{
+		Name:    cfg.InvoiceSellerName,
+		Address: cfg.InvoiceSellerAddress,
+		TaxID:   cfg.InvoiceSellerTaxID,
+	})
+	returnService := services.NewReturnService(returnRepo, orderRepo, payments, invoiceService)
+	inventoryService := services.NewInventoryService(inventoryRepo, productRepo, warehouseRepo, inventorySettings)
+	orderService := services.NewOrderService(orderRepo, productRepo, userRepo, addressRepo, promotionService, shippingService, inventoryService, taxSettings)
+	wishlistService := services.NewWishlistService(wishlistRepo, productRepo, userRepo, services.WishlistSettings{
+		Notifier:       notifier,
+		DigestInterval: time.Duration(cfg.WishlistDigestIntervalHours) * time.Hour,
+		UnsubscribeURL: cfg.PublicURL + "/api/wishlist/unsubscribe/",
+	})
+	productService := services.NewProductService(productRepo, inventoryService, wishlistService)
+	productImageService := services.NewProductImageService(productImageRepo, productRepo, blobStore, int64(cfg.ProductImageMaxBytes))
+	reviewService := services.NewReviewService(reviewRepo, productRepo)
+	privacyService := services.NewPrivacyService(userRepo, orderRepo, addressRepo, auditRepo)
+	impersonationService := services.NewImpersonationService(userRepo, sessionRepo, auditRepo)
+	purgeService := services.NewPurgeService(orderRepo, userRepo, productRepo, blobStore)
 
 	// Initialize controllers
 	userController := controllers.NewUserController(userService)
 	orderController := controllers.NewOrderController(orderService)
 	productController := controllers.NewProductController(productService)
+	privacyController := controllers.NewPrivacyController(privacyService)
+	impersonationController := controllers.NewImpersonationController(impersonationService)
+	promotionController := controllers.NewPromotionController(promotionService)
+	shippingController := controllers.NewShippingController(shippingService)
+	shipmentController := controllers.NewShipmentController(shipmentService)
+	returnController := controllers.NewReturnController(returnService)
+	invoiceController := controllers.NewInvoiceController(invoiceService)
+	inventoryController := controllers.NewInventoryController(inventoryService)
+	productImageController := controllers.NewProductImageController(productImageService)
+	reviewController := controllers.NewReviewController(reviewService)
+	wishlistController := controllers.NewWishlistController(wishlistService)
+
+	// Start background jobs
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheduler := jobs.NewScheduler()
+	// Only one replica may process erasure requests at a time
+	scheduler.Every("erasure", time.Minute, jobs.Singleton(locker, "erasure", privacyService.ProcessErasureRequests))
+	scheduler.Every("idempotency-purge", time.Hour,
+		jobs.PurgeIdempotencyKeys(idempotencyRepo, time.Duration(cfg.IdempotencyKeyTTLHours)*time.Hour))
+	if cfg.OrderExpiryMinutes > 0 {
+		// Only one replica may expire orders at a time
+		scheduler.Every("order-expiry", time.Minute, jobs.Singleton(locker, "order-expiry",
+			jobs.ExpirePendingOrders(orderService, time.Duration(cfg.OrderExpiryMinutes)*time.Minute)))
+	}
+	// Only one replica may purge soft-deleted rows at a time
+	scheduler.Every("soft-delete-purge", time.Hour, jobs.Singleton(locker, "soft-delete-purge",
+		jobs.PurgeSoftDeleted(purgeService, time.Duration(cfg.SoftDeleteRetentionDays)*24*time.Hour)))
+	// Only one replica may send wishlist digests at a time
+	scheduler.Every("wishlist-digest", 5*time.Minute, jobs.Singleton(locker, "wishlist-digest",
+		jobs.SendWishlistDigests(wishlistService)))
+	scheduler.Start(ctx)
 
 	// Initialize Gin router
 	router := gin.Default()
 
 	// Set up routes with the controllers
-	routes.SetupRoutes(router, userController, productController, orderController)
+	routes.SetupRoutes(router, userController, productController, orderController, privacyController, impersonationController, promotionController, shippingController, shipmentController, returnController, invoiceController, inventoryController, productImageController, reviewController, wishlistController, sessionRepo, auditRepo, idempotencyRepo)
 
 	// Start the server
 	if err := router.Run(cfg.ServerAddress); err != nil {
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"ecommerce-api/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditWriter stores audit log entries.
+type AuditWriter interface {
+	CreateEntry(entry *models.AuditLog) error
+}
+
+// IsImpersonating reports whether the request is made by an admin acting as another user.
+func IsImpersonating(c *gin.Context) bool {
+	return c.GetString("actorID") != ""
+}
+
+// DenyImpersonation blocks sensitive actions, such as changing a password or
+// paying, while an admin is impersonating a user.
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsImpersonating(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This action is not allowed while impersonating a user"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ImpersonationAudit writes an audit entry for every request made with an
+// impersonation token, including the response status.
+func ImpersonationAudit(audit AuditWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if !IsImpersonating(c) {
+			return
+		}
+
+		actorID, _ := strconv.ParseUint(c.GetString("actorID"), 10, 32)
+		userID, _ := strconv.ParseUint(c.GetString("userID"), 10, 32)
+		entry := &models.AuditLog{
+			ActorID:    uint(actorID),
+			Action:     models.AuditActionImpersonatedRequest,
+			TargetType: "user",
+			TargetID:   uint(userID),
+			Details:    fmt.Sprintf("%s %s -> %d", c.Request.Method, c.Request.URL.RequestURI(), c.Writer.Status()),
+		}
+		if err := audit.CreateEntry(entry); err != nil {
+			log.Printf("Error writing impersonation audit entry: %v", err)
+		}
+	}
+}
@@ -10,18 +10,49 @@ import (
 
 var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
 
+// TokenTTL is how long an access token stays valid after it is issued.
+const TokenTTL = time.Hour * 1
+
+// ImpersonationTokenTTL is how long a token issued to an impersonating admin stays valid.
+const ImpersonationTokenTTL = time.Minute * 15
+
+// Claims holds the user information carried by an access token.
+type Claims struct {
+	UserID    string
+	Role      string
+	SessionID string
+	// ActorID is the admin acting on behalf of UserID, taken from the RFC 8693
+	// "act" claim. It is empty unless the token was issued for impersonation.
+	ActorID string
+}
+
 // GenerateToken generates a JWT token with user information and expiration time
-func GenerateToken(userID string, userRole string) (string, error) {
-	// Define the expiration time (e.g., 1 hour)
-	expirationTime := time.Now().Add(time.Hour * 1)
+func GenerateToken(userID string, userRole string, sessionID string) (string, error) {
+	// Create JWT claims with userID, role, session and expiration time
+	claims := jwt.MapClaims{
+		"sub":  userID,
+		"role": userRole,
+		"sid":  sessionID,
+		"exp":  time.Now().Add(TokenTTL).Unix(),
+	}
+	return signToken(claims)
+}
 
-	// Create JWT claims with userID, role, and expiration time
-	claims := &jwt.MapClaims{
+// GenerateImpersonationToken generates a short-lived JWT token that lets the
+// admin actorID act as userID. The admin is recorded in the "act" claim.
+func GenerateImpersonationToken(userID string, userRole string, sessionID string, actorID string) (string, error) {
+	claims := jwt.MapClaims{
 		"sub":  userID,
 		"role": userRole,
-		"exp":  expirationTime.Unix(),
+		"sid":  sessionID,
+		"act":  map[string]interface{}{"sub": actorID},
+		"exp":  time.Now().Add(ImpersonationTokenTTL).Unix(),
 	}
+	return signToken(claims)
+}
 
+// signToken signs the claims with the server secret.
+func signToken(claims jwt.MapClaims) (string, error) {
 	// Create a new JWT token with the claims
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
@@ -34,8 +65,8 @@ func GenerateToken(userID string, userRole string) (string, error) {
 	return signedToken, nil
 }
 
-// ValidateToken checks the validity of the provided JWT token.
-func ValidateToken(tokenString string) (string, error) {
+// ParseToken checks the validity of the provided JWT token and returns its claims.
+func ParseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
@@ -44,14 +75,40 @@ func ValidateToken(tokenString string) (string, error) {
 	})
 
 	if err != nil || !token.Valid {
-		return "", errors.New("invalid token")
+		return nil, errors.New("invalid token")
+	}
+
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+
+	userID, ok := mapClaims["sub"].(string)
+	if !ok {
+		return nil, errors.New("invalid token claims")
 	}
+	userRole, ok := mapClaims["role"].(string)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	// Tokens issued before sessions were introduced carry no session ID.
+	sessionID, _ := mapClaims["sid"].(string)
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return "", errors.New("invalid token claims")
+	var actorID string
+	if act, ok := mapClaims["act"].(map[string]interface{}); ok {
+		if actorID, ok = act["sub"].(string); !ok || actorID == "" {
+			return nil, errors.New("invalid token claims")
+		}
 	}
 
-	userID := claims["sub"].(string)
-	return userID, nil
+	return &Claims{UserID: userID, Role: userRole, SessionID: sessionID, ActorID: actorID}, nil
+}
+
+// ValidateToken checks the validity of the provided JWT token.
+func ValidateToken(tokenString string) (string, error) {
+	claims, err := ParseToken(tokenString)
+	if err != nil {
+		return "", err
+	}
+	return claims.UserID, nil
 }
@@ -2,53 +2,71 @@ package auth
 
 import (
 	"net/http"
+	"strings"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-// JWTMiddleware is a middleware function that checks for a valid JWT in the request cookie.
-func JWTMiddleware() gin.HandlerFunc {
+// SessionChecker reports whether the login session behind a token is still active.
+type SessionChecker interface {
+	TouchSession(sessionID string) (bool, error)
+}
+
+// JWTMiddleware is a middleware function that checks for a valid JWT in a Bearer
+// Authorization header, or otherwise in the request cookie. The header wins so
+// that an impersonation token can be used without replacing the admin's own
+// cookie. Tokens whose session has been revoked or has expired are rejected.
+func JWTMiddleware(sessions SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get the token from the cookie
-		tokenString, err := c.Cookie("access_token")
+		// Get the token from the Authorization header, falling back to the cookie
+		var tokenString string
+		if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+			tokenString = strings.TrimPrefix(header, "Bearer ")
+		} else {
+			cookie, err := c.Cookie("access_token")
+			if err != nil {
+				// If neither is present, respond with unauthorized
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization cookie is missing"})
+				c.Abort()
+				return
+			}
+			tokenString = cookie
+		}
+
+		// Validate the token
+		claims, err := ParseToken(tokenString)
 		if err != nil {
-			// If the cookie is not found, respond with unauthorized
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization cookie is missing"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 
-		// Validate the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Ensure the token's signing method is HMAC
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.NewValidationError("unexpected signing method", jwt.ValidationErrorMalformed)
-			}
-			// Return the secret key for validation
-			return []byte(jwtSecret), nil
-		})
-
-		if err != nil || !token.Valid {
-			// Token is invalid
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		// Make sure the session has not been revoked
+		if claims.SessionID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session is missing"})
 			c.Abort()
 			return
 		}
-
-		// Extract user information from the token claims
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			userID := claims["sub"].(string)
-			userRole := claims["role"].(string)
-
-			// Store user info in context for further use
-			c.Set("userID", userID)
-			c.Set("userRole", userRole)
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		active, err := sessions.TouchSession(claims.SessionID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not verify session"})
 			c.Abort()
 			return
 		}
+		if !active {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+			c.Abort()
+			return
+		}
+
+		// Store user info in context for further use
+		c.Set("userID", claims.UserID)
+		c.Set("userRole", claims.Role)
+		c.Set("sessionID", claims.SessionID)
+		if claims.ActorID != "" {
+			// The real user behind an impersonation token
+			c.Set("actorID", claims.ActorID)
+		}
 
 		// Token is valid, proceed to the next handler
 		c.Next()
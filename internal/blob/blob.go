@@ -0,0 +1,21 @@
+// Package blob stores files such as product images in object storage.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when no object is stored under a key.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores objects under slash-separated keys.
+type BlobStore interface {
+	// Put stores data under key, replacing any object already there.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get opens the object stored under key. The caller must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,182 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Config configures an S3Store. Endpoint is the service URL, such as
+// https://s3.eu-west-1.amazonaws.com or http://localhost:9000 for a local
+// S3-compatible server like MinIO.
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3Store keeps objects in a bucket of an S3-compatible service. Requests
+// use path-style addressing and are signed with AWS Signature Version 4.
+type S3Store struct {
+	config S3Config
+	client *http.Client
+	// now is replaceable so signatures can be checked against fixed times.
+	now func() time.Time
+}
+
+// NewS3Store creates a new S3Store instance.
+func NewS3Store(config S3Config) *S3Store {
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+	return &S3Store{config: config, client: &http.Client{Timeout: time.Minute}, now: time.Now}
+}
+
+// Put uploads the object with a PUT request.
+func (s *S3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, data)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkS3Response(resp)
+}
+
+// Get downloads the object with a GET request.
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, nil)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if err := checkS3Response(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the object with a DELETE request. S3 reports success for missing objects.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, nil)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return checkS3Response(resp)
+}
+
+// newRequest builds a request for an object in the bucket.
+func (s *S3Store) newRequest(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	objectURL := s.config.Endpoint + "/" + s.config.Bucket + "/" + escapeKey(key)
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	return http.NewRequestWithContext(ctx, method, objectURL, reader)
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to the request.
+func (s *S3Store) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Every header set so far is signed, in sorted order
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("Content-Type") != "" {
+		signedHeaders = []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaders {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyID, scope, strings.Join(signedHeaders, ";"), signature))
+}
+
+// checkS3Response turns an error response into an error.
+func checkS3Response(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("object storage responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// escapeKey escapes each segment of a key for use in a URL path.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,72 @@
+// Package catalog reads and writes products in bulk as CSV or JSON Lines.
+package catalog
+
+import (
+	"ecommerce-api/internal/models"
+	"fmt"
+	"io"
+)
+
+// Supported file formats.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// Columns are the product fields read and written, in export order. Imports
+// may give them in any order; sku, name and price are required.
+var Columns = []string{
+	"sku", "name", "description", "category", "tax_class", "price",
+	"stock", "reorder_threshold", "weight", "length", "width", "height",
+}
+
+// Row is one product read from an import file.
+type Row struct {
+	// Line is the row's line number in the file, for error reports.
+	Line    int
+	Product models.Product
+	// Stock is nil when the row leaves stock unchanged.
+	Stock *int
+	// Columns lists the columns the row gives. Updating an existing product
+	// leaves the others unchanged.
+	Columns []string
+	// Err is set when the row could not be parsed. Reading carries on with
+	// the next row.
+	Err error
+}
+
+// Reader reads products one row at a time. Read returns io.EOF after the
+// last row; any other error means the file as a whole cannot be read.
+type Reader interface {
+	Read() (Row, error)
+}
+
+// Writer writes products one at a time. Flush must be called at the end.
+type Writer interface {
+	Write(product *models.Product) error
+	Flush() error
+}
+
+// NewReader creates a Reader for the given format.
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatJSONL:
+		return newJSONLReader(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// NewWriter creates a Writer for the given format.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatJSONL:
+		return newJSONLWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
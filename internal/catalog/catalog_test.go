@@ -0,0 +1,59 @@
+package catalog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadersReportGivenColumns(t *testing.T) {
+	tests := map[string]struct {
+		format string
+		input  string
+		want   []string
+	}{
+		"csv": {
+			format: FormatCSV,
+			input:  "price,sku,name,stock\n9.5,LAMP-1,Lamp,3\n",
+			want:   []string{"sku", "name", "price", "stock"},
+		},
+		"jsonl": {
+			format: FormatJSONL,
+			input:  `{"sku":"LAMP-1","name":"Lamp","price":9.5,"weight":0}` + "\n",
+			want:   []string{"sku", "name", "price", "weight"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			reader, err := NewReader(tc.format, strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			row, err := reader.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if row.Err != nil {
+				t.Fatalf("row error: %v", row.Err)
+			}
+			if !reflect.DeepEqual(row.Columns, tc.want) {
+				t.Errorf("got columns %v, want %v", row.Columns, tc.want)
+			}
+			if row.Product.TaxClass != "" {
+				t.Errorf("got tax class %q for a row without one", row.Product.TaxClass)
+			}
+		})
+	}
+}
+
+func TestJSONLRowRequiresNameAndPrice(t *testing.T) {
+	reader := newJSONLReader(strings.NewReader(`{"sku":"LAMP-1","name":"Lamp"}` + "\n"))
+	row, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Err == nil || row.Err.Error() != "missing price" {
+		t.Errorf("got error %v, want missing price", row.Err)
+	}
+}
@@ -0,0 +1,159 @@
+package catalog
+
+import (
+	"ecommerce-api/internal/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvReader reads products from CSV with a header row naming the columns.
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+	// given lists the header's columns in the order of Columns.
+	given []string
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheets often save CSV with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !isColumn(name) {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"sku", "name", "price"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+	var given []string
+	for _, name := range Columns {
+		if _, ok := columns[name]; ok {
+			given = append(given, name)
+		}
+	}
+	// Rows must have as many fields as the header
+	reader.FieldsPerRecord = len(header)
+	return &csvReader{reader: reader, columns: columns, given: given}, nil
+}
+
+func (r *csvReader) Read() (Row, error) {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return Row{}, io.EOF
+	}
+	line, _ := r.reader.FieldPos(0)
+	row := Row{Line: line, Columns: r.given}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+		if i := r.columns["sku"]; i < len(record) {
+			row.Product.SKU = strings.TrimSpace(record[i])
+		}
+		row.Err = errors.New("wrong number of fields")
+		return row, nil
+	}
+	if err != nil {
+		return Row{}, fmt.Errorf("could not read CSV: %w", err)
+	}
+
+	field := func(name string) string {
+		if i, ok := r.columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	p := &row.Product
+	p.SKU = field("sku")
+	p.Name = field("name")
+	p.Description = field("description")
+	p.Category = field("category")
+	p.TaxClass = field("tax_class")
+
+	floats := map[string]*float64{"price": &p.Price, "weight": &p.Weight, "length": &p.Length, "width": &p.Width, "height": &p.Height}
+	for _, name := range Columns {
+		target, ok := floats[name]
+		if !ok || field(name) == "" {
+			continue
+		}
+		if *target, err = strconv.ParseFloat(field(name), 64); err != nil {
+			row.Err = fmt.Errorf("invalid %s %q", name, field(name))
+			return row, nil
+		}
+	}
+
+	if value := field("reorder_threshold"); value != "" {
+		if p.ReorderThreshold, err = strconv.Atoi(value); err != nil {
+			row.Err = fmt.Errorf("invalid reorder_threshold %q", value)
+			return row, nil
+		}
+	}
+	// An empty stock cell leaves stock unchanged
+	if value := field("stock"); value != "" {
+		stock, err := strconv.Atoi(value)
+		if err != nil {
+			row.Err = fmt.Errorf("invalid stock %q", value)
+			return row, nil
+		}
+		row.Stock = &stock
+	}
+	return row, nil
+}
+
+// csvWriter writes products as CSV with a header row.
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(Columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer}, nil
+}
+
+func (w *csvWriter) Write(p *models.Product) error {
+	formatFloat := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return w.writer.Write([]string{
+		p.SKU, p.Name, p.Description, p.Category, p.TaxClass, formatFloat(p.Price),
+		strconv.Itoa(p.Stock), strconv.Itoa(p.ReorderThreshold),
+		formatFloat(p.Weight), formatFloat(p.Length), formatFloat(p.Width), formatFloat(p.Height),
+	})
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// isColumn reports whether name is one of the known columns.
+func isColumn(name string) bool {
+	for _, column := range Columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"ecommerce-api/internal/models"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// maxJSONLLine is the longest line accepted in a JSON Lines file.
+const maxJSONLLine = 1 << 20
+
+// jsonlRow is one line of a JSON Lines file. Stock is a pointer so that a
+// missing stock can be told apart from zero.
+type jsonlRow struct {
+	SKU              string  `json:"sku"`
+	Name             string  `json:"name"`
+	Description      string  `json:"description"`
+	Category         string  `json:"category"`
+	TaxClass         string  `json:"tax_class"`
+	Price            float64 `json:"price"`
+	Stock            *int    `json:"stock"`
+	ReorderThreshold int     `json:"reorder_threshold"`
+	Weight           float64 `json:"weight"`
+	Length           float64 `json:"length"`
+	Width            float64 `json:"width"`
+	Height           float64 `json:"height"`
+}
+
+// jsonlReader reads products from JSON Lines, one object per line.
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxJSONLLine)
+	return &jsonlReader{scanner: scanner}
+}
+
+func (r *jsonlReader) Read() (Row, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		row := Row{Line: r.line}
+		var in jsonlRow
+		if err := json.Unmarshal(data, &in); err != nil {
+			row.Err = fmt.Errorf("invalid JSON: %s", err.Error())
+			return row, nil
+		}
+		// The keys tell which columns the row gives
+		var keys map[string]json.RawMessage
+		if err := json.Unmarshal(data, &keys); err != nil {
+			row.Err = fmt.Errorf("invalid JSON: %s", err.Error())
+			return row, nil
+		}
+		for _, name := range Columns {
+			if _, ok := keys[name]; ok {
+				row.Columns = append(row.Columns, name)
+			}
+		}
+		row.Product = models.Product{
+			SKU:              in.SKU,
+			Name:             in.Name,
+			Description:      in.Description,
+			Category:         in.Category,
+			TaxClass:         in.TaxClass,
+			Price:            in.Price,
+			ReorderThreshold: in.ReorderThreshold,
+			Weight:           in.Weight,
+			Length:           in.Length,
+			Width:            in.Width,
+			Height:           in.Height,
+		}
+		row.Stock = in.Stock
+		for _, name := range []string{"name", "price"} {
+			if _, ok := keys[name]; !ok {
+				row.Err = fmt.Errorf("missing %s", name)
+				return row, nil
+			}
+		}
+		return row, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return Row{}, fmt.Errorf("could not read JSON Lines: %w", err)
+	}
+	return Row{}, io.EOF
+}
+
+// jsonlWriter writes products as JSON Lines.
+type jsonlWriter struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	writer := bufio.NewWriter(w)
+	return &jsonlWriter{writer: writer, encoder: json.NewEncoder(writer)}
+}
+
+func (w *jsonlWriter) Write(p *models.Product) error {
+	stock := p.Stock
+	return w.encoder.Encode(jsonlRow{
+		SKU:              p.SKU,
+		Name:             p.Name,
+		Description:      p.Description,
+		Category:         p.Category,
+		TaxClass:         p.TaxClass,
+		Price:            p.Price,
+		Stock:            &stock,
+		ReorderThreshold: p.ReorderThreshold,
+		Weight:           p.Weight,
+		Length:           p.Length,
+		Width:            p.Width,
+		Height:           p.Height,
+	})
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.writer.Flush()
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -16,6 +18,73 @@ type Config struct {
 	DBName        string
 	JWTSecret     string
 	ServerAddress string
+
+	// Password policy
+	PasswordMinLength      int
+	PasswordMaxLength      int
+	PasswordRequireUpper   bool
+	PasswordRequireLower   bool
+	PasswordRequireDigit   bool
+	PasswordRequireSymbol  bool
+	PasswordRejectBreached bool
+
+	// Password hashing
+	PasswordHasher    string
+	BcryptCost        int
+	Argon2Time        int
+	Argon2MemoryKiB   int
+	Argon2Parallelism int
+
+	// Idempotency keys are kept this many hours before being purged
+	IdempotencyKeyTTLHours int
+
+	// Pending orders are cancelled after this many minutes without payment; 0, the default, disables it
+	OrderExpiryMinutes int
+
+	// Soft-deleted products, users and orders are purged after this many days
+	SoftDeleteRetentionDays int
+
+	// Wishlist notifications: each user gets at most one digest per interval,
+	// with unsubscribe links pointing at PublicURL
+	WishlistDigestIntervalHours int
+	PublicURL                   string
+
+	// Tax
+	TaxRatesFile        string
+	TaxPricesIncludeTax bool
+	TaxOriginCountry    string
+	TaxOriginRegion     string
+
+	// How the warehouse an order ships from is picked: nearest or most_stock
+	InventoryAllocationStrategy string
+
+	// Low-stock alerts go to the log and, when set, to this email address and webhook
+	LowStockAlertEmail string
+	LowStockWebhookURL string
+
+	// SMTP server for outgoing email; without a host, email is only logged
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// Where uploaded files are stored: local (a directory) or s3 (an S3-compatible bucket)
+	BlobStore         string
+	BlobLocalDir      string
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+
+	// Largest product image accepted, in bytes
+	ProductImageMaxBytes int
+
+	// Seller details printed on invoices
+	InvoiceSellerName    string
+	InvoiceSellerAddress string
+	InvoiceSellerTaxID   string
 }
 
 func LoadConfig() (Config, error) {
@@ -35,6 +104,105 @@ func LoadConfig() (Config, error) {
 	cfg.JWTSecret = os.Getenv("JWT_SECRET")
 	cfg.ServerAddress = os.Getenv("SERVER_ADDRESS")
 
+	// Optional settings fall back to sensible defaults
+	var err error
+	if cfg.PasswordMinLength, err = getEnvInt("PASSWORD_MIN_LENGTH", 8); err != nil {
+		return cfg, err
+	}
+	if cfg.PasswordMaxLength, err = getEnvInt("PASSWORD_MAX_LENGTH", 72); err != nil {
+		return cfg, err
+	}
+	if cfg.PasswordRequireUpper, err = getEnvBool("PASSWORD_REQUIRE_UPPER", false); err != nil {
+		return cfg, err
+	}
+	if cfg.PasswordRequireLower, err = getEnvBool("PASSWORD_REQUIRE_LOWER", true); err != nil {
+		return cfg, err
+	}
+	if cfg.PasswordRequireDigit, err = getEnvBool("PASSWORD_REQUIRE_DIGIT", true); err != nil {
+		return cfg, err
+	}
+	if cfg.PasswordRequireSymbol, err = getEnvBool("PASSWORD_REQUIRE_SYMBOL", false); err != nil {
+		return cfg, err
+	}
+	if cfg.PasswordRejectBreached, err = getEnvBool("PASSWORD_REJECT_BREACHED", true); err != nil {
+		return cfg, err
+	}
+	cfg.PasswordHasher = os.Getenv("PASSWORD_HASHER")
+	if cfg.PasswordHasher == "" {
+		cfg.PasswordHasher = "bcrypt"
+	}
+	if cfg.BcryptCost, err = getEnvInt("BCRYPT_COST", 12); err != nil {
+		return cfg, err
+	}
+	if cfg.Argon2Time, err = getEnvInt("ARGON2_TIME", 3); err != nil {
+		return cfg, err
+	}
+	if cfg.Argon2MemoryKiB, err = getEnvInt("ARGON2_MEMORY_KIB", 64*1024); err != nil {
+		return cfg, err
+	}
+	if cfg.Argon2Parallelism, err = getEnvInt("ARGON2_PARALLELISM", 4); err != nil {
+		return cfg, err
+	}
+	if cfg.IdempotencyKeyTTLHours, err = getEnvInt("IDEMPOTENCY_KEY_TTL_HOURS", 24); err != nil {
+		return cfg, err
+	}
+	if cfg.OrderExpiryMinutes, err = getEnvInt("ORDER_EXPIRY_MINUTES", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.SoftDeleteRetentionDays, err = getEnvInt("SOFT_DELETE_RETENTION_DAYS", 30); err != nil {
+		return cfg, err
+	}
+	if cfg.WishlistDigestIntervalHours, err = getEnvInt("WISHLIST_DIGEST_INTERVAL_HOURS", 12); err != nil {
+		return cfg, err
+	}
+	cfg.PublicURL = strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/")
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = "http://localhost:8080"
+	}
+	cfg.TaxRatesFile = os.Getenv("TAX_RATES_FILE")
+	if cfg.TaxPricesIncludeTax, err = getEnvBool("TAX_PRICES_INCLUDE_TAX", false); err != nil {
+		return cfg, err
+	}
+	cfg.TaxOriginCountry = os.Getenv("TAX_ORIGIN_COUNTRY")
+	cfg.TaxOriginRegion = os.Getenv("TAX_ORIGIN_REGION")
+	cfg.InventoryAllocationStrategy = os.Getenv("INVENTORY_ALLOCATION_STRATEGY")
+	if cfg.InventoryAllocationStrategy == "" {
+		cfg.InventoryAllocationStrategy = "nearest"
+	}
+	cfg.LowStockAlertEmail = os.Getenv("LOW_STOCK_ALERT_EMAIL")
+	cfg.LowStockWebhookURL = os.Getenv("LOW_STOCK_WEBHOOK_URL")
+	cfg.SMTPHost = os.Getenv("SMTP_HOST")
+	cfg.SMTPPort = os.Getenv("SMTP_PORT")
+	if cfg.SMTPPort == "" {
+		cfg.SMTPPort = "587"
+	}
+	cfg.SMTPUsername = os.Getenv("SMTP_USERNAME")
+	cfg.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	cfg.SMTPFrom = os.Getenv("SMTP_FROM")
+	cfg.BlobStore = os.Getenv("BLOB_STORE")
+	if cfg.BlobStore == "" {
+		cfg.BlobStore = "local"
+	}
+	cfg.BlobLocalDir = os.Getenv("BLOB_LOCAL_DIR")
+	if cfg.BlobLocalDir == "" {
+		cfg.BlobLocalDir = "uploads"
+	}
+	cfg.S3Endpoint = os.Getenv("S3_ENDPOINT")
+	cfg.S3Region = os.Getenv("S3_REGION")
+	if cfg.S3Region == "" {
+		cfg.S3Region = "us-east-1"
+	}
+	cfg.S3Bucket = os.Getenv("S3_BUCKET")
+	cfg.S3AccessKeyID = os.Getenv("S3_ACCESS_KEY_ID")
+	cfg.S3SecretAccessKey = os.Getenv("S3_SECRET_ACCESS_KEY")
+	if cfg.ProductImageMaxBytes, err = getEnvInt("PRODUCT_IMAGE_MAX_BYTES", 5<<20); err != nil {
+		return cfg, err
+	}
+	cfg.InvoiceSellerName = os.Getenv("INVOICE_SELLER_NAME")
+	// Address lines are separated by a literal \n in the environment
+	cfg.InvoiceSellerAddress = strings.ReplaceAll(os.Getenv("INVOICE_SELLER_ADDRESS"), `\n`, "\n")
+	cfg.InvoiceSellerTaxID = os.Getenv("INVOICE_SELLER_TAX_ID")
+
 	// Validate required configuration values
 	if cfg.ServerAddress == "" {
 		return cfg, fmt.Errorf("SERVER_ADDRESS is not set")
@@ -57,6 +225,74 @@ func LoadConfig() (Config, error) {
 	if cfg.JWTSecret == "" {
 		return cfg, fmt.Errorf("JWT_SECRET is not set")
 	}
+	if cfg.PasswordMaxLength > 72 {
+		return cfg, fmt.Errorf("PASSWORD_MAX_LENGTH cannot exceed 72, the bcrypt limit")
+	}
+	if cfg.PasswordMinLength > cfg.PasswordMaxLength {
+		return cfg, fmt.Errorf("PASSWORD_MIN_LENGTH cannot exceed PASSWORD_MAX_LENGTH")
+	}
+	if cfg.PasswordHasher != "bcrypt" && cfg.PasswordHasher != "argon2id" {
+		return cfg, fmt.Errorf("PASSWORD_HASHER must be bcrypt or argon2id")
+	}
+	if cfg.BcryptCost < 4 || cfg.BcryptCost > 31 {
+		return cfg, fmt.Errorf("BCRYPT_COST must be between 4 and 31")
+	}
+	if cfg.Argon2Time < 1 || cfg.Argon2MemoryKiB < 8*1024 || cfg.Argon2Parallelism < 1 || cfg.Argon2Parallelism > 255 {
+		return cfg, fmt.Errorf("ARGON2_TIME, ARGON2_MEMORY_KIB and ARGON2_PARALLELISM are out of range")
+	}
+	if cfg.IdempotencyKeyTTLHours < 1 {
+		return cfg, fmt.Errorf("IDEMPOTENCY_KEY_TTL_HOURS must be at least 1")
+	}
+	if cfg.OrderExpiryMinutes < 0 {
+		return cfg, fmt.Errorf("ORDER_EXPIRY_MINUTES cannot be negative")
+	}
+	if cfg.SoftDeleteRetentionDays < 1 {
+		return cfg, fmt.Errorf("SOFT_DELETE_RETENTION_DAYS must be at least 1")
+	}
+	if cfg.WishlistDigestIntervalHours < 1 {
+		return cfg, fmt.Errorf("WISHLIST_DIGEST_INTERVAL_HOURS must be at least 1")
+	}
+	if cfg.SMTPHost != "" && cfg.SMTPFrom == "" {
+		return cfg, fmt.Errorf("SMTP_FROM is required when SMTP_HOST is set")
+	}
+	if cfg.BlobStore != "local" && cfg.BlobStore != "s3" {
+		return cfg, fmt.Errorf("BLOB_STORE must be local or s3")
+	}
+	if cfg.BlobStore == "s3" && (cfg.S3Endpoint == "" || cfg.S3Bucket == "" || cfg.S3AccessKeyID == "" || cfg.S3SecretAccessKey == "") {
+		return cfg, fmt.Errorf("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required when BLOB_STORE is s3")
+	}
+	if cfg.ProductImageMaxBytes < 1 {
+		return cfg, fmt.Errorf("PRODUCT_IMAGE_MAX_BYTES must be at least 1")
+	}
+	if cfg.InventoryAllocationStrategy != "nearest" && cfg.InventoryAllocationStrategy != "most_stock" {
+		return cfg, fmt.Errorf("INVENTORY_ALLOCATION_STRATEGY must be nearest or most_stock")
+	}
 
 	return cfg, nil
 }
+
+// getEnvInt reads an integer environment variable, returning def when it is unset.
+func getEnvInt(key string, def int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", key)
+	}
+	return n, nil
+}
+
+// getEnvBool reads a boolean environment variable, returning def when it is unset.
+func getEnvBool(key string, def bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", key)
+	}
+	return b, nil
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"ecommerce-api/internal/repository"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserID returns the authenticated user's ID set by the JWT middleware.
+func currentUserID(c *gin.Context) (uint, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		return 0, false
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		return 0, false
+	}
+
+	uid, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(uid), true
+}
+
+// currentSessionID returns the ID of the login session behind the request's token.
+func currentSessionID(c *gin.Context) (uint, bool) {
+	sessionID := c.GetString("sessionID")
+	sid, err := strconv.ParseUint(sessionID, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(sid), true
+}
+
+// parseIDParam parses a numeric path parameter such as :id.
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseBoolQuery parses an optional boolean query parameter, which is false when absent.
+func parseBoolQuery(c *gin.Context, name string) (bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// isAdmin reports whether the authenticated user has the admin role.
+func isAdmin(c *gin.Context) bool {
+	return c.GetString("userRole") == "admin"
+}
+
+// parseTimeParam parses a query parameter given either as RFC 3339 or as a plain date.
+func parseTimeParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// versionETag formats a row version as the ETag of its resource.
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch reads the version from an If-Match header holding a single
+// ETag made by versionETag. It returns 0 when the header is absent or "*".
+func parseIfMatch(c *gin.Context) (int, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	if len(value) < 3 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return 0, errors.New("invalid If-Match header")
+	}
+	version, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil || version < 1 {
+		return 0, errors.New("invalid If-Match header")
+	}
+	return version, nil
+}
+
+// respondVersionConflict answers 409 with the current version when err is a
+// lost update, and reports whether it did.
+func respondVersionConflict(c *gin.Context, err error) bool {
+	var conflict *repository.VersionConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	c.Header("ETag", versionETag(conflict.CurrentVersion))
+	c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "current_version": conflict.CurrentVersion})
+	return true
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"ecommerce-api/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonationController handles admin impersonation requests.
+type ImpersonationController struct {
+	ImpersonationService *services.ImpersonationService
+}
+
+// NewImpersonationController creates a new ImpersonationController instance.
+func NewImpersonationController(impersonationService *services.ImpersonationService) *ImpersonationController {
+	return &ImpersonationController{ImpersonationService: impersonationService}
+}
+
+// ImpersonateUser issues a short-lived token to act as a customer
+// @Summary Impersonate a user
+// @Description Issues a short-lived token that lets an admin act as the given user. Send it as a Bearer token. Sensitive actions are blocked and every request is audited
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param body body object{reason=string} true "Why the user is being impersonated"
+// @Success 200 {object} services.ImpersonationGrant
+// @Failure 400 {object} gin.H{"error": "Invalid user ID"}
+// @Failure 403 {object} gin.H{"error": "admins cannot be impersonated"}
+// @Failure 404 {object} gin.H{"error": "User not found"}
+// @Router /admin/users/{id}/impersonate [post]
+func (ic *ImpersonationController) ImpersonateUser(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var body struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required"})
+		return
+	}
+
+	grant, err := ic.ImpersonationService.Impersonate(adminID, userID, body.Reason, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case "admins cannot be impersonated", "admins cannot impersonate themselves":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not impersonate user"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, grant)
+}
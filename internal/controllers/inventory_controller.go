@@ -0,0 +1,350 @@
+package controllers
+
+import (
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InventoryController handles HTTP requests related to warehouses and product stock.
+type InventoryController struct {
+	InventoryService *services.InventoryService
+}
+
+// NewInventoryController creates a new InventoryController instance.
+func NewInventoryController(inventoryService *services.InventoryService) *InventoryController {
+	return &InventoryController{InventoryService: inventoryService}
+}
+
+// adjustStockRequest is the body accepted when adjusting stock by hand.
+type adjustStockRequest struct {
+	ProductID   uint   `json:"product_id" binding:"required"`
+	WarehouseID *uint  `json:"warehouse_id"`
+	Change      *int   `json:"change"`
+	SetTo       *int   `json:"set_to"`
+	Reason      string `json:"reason"`
+	Note        string `json:"note"`
+}
+
+// transferStockRequest is the body accepted when moving stock between warehouses.
+type transferStockRequest struct {
+	ProductID       uint   `json:"product_id" binding:"required"`
+	FromWarehouseID *uint  `json:"from_warehouse_id"`
+	ToWarehouseID   uint   `json:"to_warehouse_id" binding:"required"`
+	Quantity        int    `json:"quantity" binding:"required"`
+	Note            string `json:"note"`
+}
+
+// AdjustStock changes a product's stock and records it in the inventory ledger.
+// @Summary Adjust stock
+// @Description Changes a product's stock by a relative amount (change) or sets it to an absolute level (set_to), for example after a stock count. With warehouse_id the change applies to that warehouse, otherwise to stock not assigned to a warehouse. The reason is adjustment (default) or restock (admin only)
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param adjustment body adjustStockRequest true "Stock Adjustment"
+// @Success 201 {object} models.StockMovement
+// @Success 200 {object} models.StockMovement
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 404 {object} gin.H{"error": "Product not found"}
+// @Failure 409 {object} gin.H{"error": "insufficient stock"}
+// @Router /admin/inventory/adjust [post]
+func (ic *InventoryController) AdjustStock(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request adjustStockRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	movement, err := ic.InventoryService.AdjustStock(services.StockAdjustment{
+		ProductID:   request.ProductID,
+		WarehouseID: request.WarehouseID,
+		Change:      request.Change,
+		SetTo:       request.SetTo,
+		Reason:      request.Reason,
+		Note:        request.Note,
+		ActorID:     adminID,
+	})
+	if err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+
+	// Setting stock to its current level records nothing
+	if movement.ID == 0 {
+		c.JSON(http.StatusOK, movement)
+		return
+	}
+	c.JSON(http.StatusCreated, movement)
+}
+
+// GetMovements lists the stock movements of a product.
+// @Summary List stock movements of a product
+// @Description Returns the product's inventory ledger, newest first, with the reason, related order, actor and stock level after each change (admin only)
+// @Tags Inventory
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Success 200 {object} gin.H{"movements": []models.StockMovement, "total": 0, "page": 1, "page_size": 20}
+// @Failure 400 {object} gin.H{"error": "Invalid product ID"}
+// @Failure 404 {object} gin.H{"error": "Product not found"}
+// @Router /admin/inventory/products/{id}/movements [get]
+func (ic *InventoryController) GetMovements(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	page, pageSize := 1, 20
+	for name, target := range map[string]*int{"page": &page, "page_size": &pageSize} {
+		if value := c.Query(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+				return
+			}
+			*target = n
+		}
+	}
+
+	movements, total, err := ic.InventoryService.GetMovements(productID, &page, &pageSize)
+	if err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"movements": movements,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// TransferStock moves stock of a product between warehouses.
+// @Summary Transfer stock between warehouses
+// @Description Moves stock of a product from one warehouse to another. Without from_warehouse_id, stock not yet assigned to a warehouse is moved. The total stock is unchanged and both sides are recorded in the ledger (admin only)
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param transfer body transferStockRequest true "Stock Transfer"
+// @Success 201 {array} models.StockMovement
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 404 {object} gin.H{"error": "Warehouse not found"}
+// @Failure 409 {object} gin.H{"error": "insufficient stock"}
+// @Router /admin/inventory/transfers [post]
+func (ic *InventoryController) TransferStock(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request transferStockRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	movements, err := ic.InventoryService.TransferStock(request.ProductID, request.FromWarehouseID, request.ToWarehouseID, request.Quantity, adminID, request.Note)
+	if err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, movements)
+}
+
+// GetProductStock shows where a product's stock is held.
+// @Summary Get stock by location
+// @Description Breaks a product's total stock down by warehouse, including stock not assigned to a warehouse (admin only)
+// @Tags Inventory
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} models.ProductStock
+// @Failure 400 {object} gin.H{"error": "Invalid product ID"}
+// @Failure 404 {object} gin.H{"error": "Product not found"}
+// @Router /admin/inventory/products/{id}/stock [get]
+func (ic *InventoryController) GetProductStock(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	stock, err := ic.InventoryService.GetProductStock(productID)
+	if err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stock)
+}
+
+// GetReorderReport suggests how much of each product to reorder.
+// @Summary Reorder report
+// @Description Lists products that need reordering with a suggested quantity. Average daily sales over the last days (from orders that were not cancelled) are projected over cover_days, and stock is topped up to that demand plus the product's reorder threshold (admin only)
+// @Tags Inventory
+// @Produce json
+// @Param days query int false "Days of sales history to average" default(30)
+// @Param cover_days query int false "Days of sales the suggested stock should cover" default(30)
+// @Success 200 {array} services.ReorderSuggestion
+// @Failure 400 {object} gin.H{"error": "invalid days"}
+// @Failure 500 {object} gin.H{"error": "Could not build reorder report"}
+// @Router /admin/inventory/reorder-report [get]
+func (ic *InventoryController) GetReorderReport(c *gin.Context) {
+	days, coverDays := 30, 30
+	for name, target := range map[string]*int{"days": &days, "cover_days": &coverDays} {
+		if value := c.Query(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 365 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+				return
+			}
+			*target = n
+		}
+	}
+
+	report, err := ic.InventoryService.ReorderReport(days, coverDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not build reorder report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// CreateWarehouse handles the creation of a warehouse.
+// @Summary Create a warehouse
+// @Description Creates a warehouse. Its country and region are used to find the warehouse nearest to a customer. It is active unless active is false (admin only)
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param warehouse body models.Warehouse true "Warehouse Data"
+// @Success 201 {object} models.Warehouse
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Router /admin/warehouses [post]
+func (ic *InventoryController) CreateWarehouse(c *gin.Context) {
+	// A warehouse is active unless the request turns it off
+	warehouse := models.Warehouse{Active: true}
+	if err := c.ShouldBindJSON(&warehouse); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	warehouse.ID = 0
+
+	if err := ic.InventoryService.CreateWarehouse(&warehouse); err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, warehouse)
+}
+
+// GetWarehouses lists all warehouses.
+// @Summary List warehouses
+// @Description Retrieves all warehouses, active or not (admin only)
+// @Tags Inventory
+// @Produce json
+// @Success 200 {array} models.Warehouse
+// @Failure 500 {object} gin.H{"error": "Could not retrieve warehouses"}
+// @Router /admin/warehouses [get]
+func (ic *InventoryController) GetWarehouses(c *gin.Context) {
+	warehouses, err := ic.InventoryService.GetWarehouses()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve warehouses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouses)
+}
+
+// GetWarehouseByID retrieves a warehouse by its ID.
+// @Summary Get a warehouse
+// @Description Retrieves a warehouse by its ID (admin only)
+// @Tags Inventory
+// @Produce json
+// @Param id path int true "Warehouse ID"
+// @Success 200 {object} models.Warehouse
+// @Failure 400 {object} gin.H{"error": "Invalid warehouse ID"}
+// @Failure 404 {object} gin.H{"error": "Warehouse not found"}
+// @Router /admin/warehouses/{id} [get]
+func (ic *InventoryController) GetWarehouseByID(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid warehouse ID"})
+		return
+	}
+
+	warehouse, err := ic.InventoryService.GetWarehouseByID(id)
+	if err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouse)
+}
+
+// UpdateWarehouse handles the update of a warehouse.
+// @Summary Update a warehouse
+// @Description Replaces a warehouse's details. Setting active to false keeps its stock but stops it shipping new orders (admin only)
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param id path int true "Warehouse ID"
+// @Param warehouse body models.Warehouse true "Updated Warehouse Data"
+// @Success 200 {object} models.Warehouse
+// @Failure 400 {object} gin.H{"error": "Invalid warehouse ID"}
+// @Failure 404 {object} gin.H{"error": "Warehouse not found"}
+// @Router /admin/warehouses/{id} [put]
+func (ic *InventoryController) UpdateWarehouse(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid warehouse ID"})
+		return
+	}
+
+	// A warehouse is active unless the request turns it off
+	warehouse := models.Warehouse{Active: true}
+	if err := c.ShouldBindJSON(&warehouse); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	warehouse.ID = id
+
+	if err := ic.InventoryService.UpdateWarehouse(&warehouse); err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+
+	updated, err := ic.InventoryService.GetWarehouseByID(id)
+	if err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// respondInventoryError maps inventory service errors to HTTP responses.
+func respondInventoryError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "product not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case "warehouse not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+	case "insufficient stock", "warehouse code already in use":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvoiceController handles HTTP requests for invoices and credit notes.
+type InvoiceController struct {
+	InvoiceService *services.InvoiceService
+}
+
+// NewInvoiceController creates a new InvoiceController instance.
+func NewInvoiceController(invoiceService *services.InvoiceService) *InvoiceController {
+	return &InvoiceController{InvoiceService: invoiceService}
+}
+
+// GetInvoicePDF returns the invoice of an order as a PDF.
+// @Summary Download the invoice of an order
+// @Description Returns the invoice of a shipped order as a PDF. The invoice is issued with the next number of the year on first request and never changes afterwards
+// @Tags Invoices
+// @Produce application/pdf
+// @Param id path int true "Order ID"
+// @Success 200 {file} file
+// @Failure 404 {object} gin.H{"error": "Order not found"}
+// @Failure 409 {object} gin.H{"error": "Invoice is not available until the order has shipped"}
+// @Security ApiKeyAuth
+// @Router /orders/{id}/invoice.pdf [get]
+func (ic *InvoiceController) GetInvoicePDF(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	inv, err := ic.InvoiceService.GetInvoice(orderID, uid, isAdmin(c))
+	if err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	writeInvoicePDF(c, inv)
+}
+
+// ListCreditNotes lists the credit notes of an order.
+// @Summary List credit notes of an order
+// @Description Retrieves the credit notes issued for refunds of an order
+// @Tags Invoices
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {array} models.Invoice
+// @Failure 404 {object} gin.H{"error": "Order not found"}
+// @Security ApiKeyAuth
+// @Router /orders/{id}/credit-notes [get]
+func (ic *InvoiceController) ListCreditNotes(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	notes, err := ic.InvoiceService.GetCreditNotes(orderID, uid, isAdmin(c))
+	if err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notes)
+}
+
+// GetCreditNotePDF returns a credit note of an order as a PDF.
+// @Summary Download a credit note
+// @Description Returns a credit note issued for a refund of the order as a PDF
+// @Tags Invoices
+// @Produce application/pdf
+// @Param id path int true "Order ID"
+// @Param noteId path int true "Credit note ID"
+// @Success 200 {file} file
+// @Failure 404 {object} gin.H{"error": "Invoice not found"}
+// @Security ApiKeyAuth
+// @Router /orders/{id}/credit-notes/{noteId}/pdf [get]
+func (ic *InvoiceController) GetCreditNotePDF(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+	noteID, ok := parseIDParam(c, "noteId")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credit note ID"})
+		return
+	}
+
+	note, err := ic.InvoiceService.GetCreditNote(orderID, noteID, uid, isAdmin(c))
+	if err != nil {
+		respondInvoiceError(c, err)
+		return
+	}
+
+	writeInvoicePDF(c, note)
+}
+
+// writeInvoicePDF sends a stored invoice PDF. Issued invoices never change,
+// so the browser may keep it.
+func writeInvoicePDF(c *gin.Context, inv *models.Invoice) {
+	c.Header("Content-Disposition", `inline; filename="`+inv.Number+`.pdf"`)
+	c.Header("Cache-Control", "private, max-age=86400, immutable")
+	c.Data(http.StatusOK, "application/pdf", inv.PDF)
+}
+
+// respondInvoiceError maps invoice service errors to HTTP responses.
+func respondInvoiceError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "order not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+	case "invoice not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+	case "invoice is not available until the order has shipped":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve invoice"})
+	}
+}
@@ -2,11 +2,15 @@ package controllers
 
 import (
 	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/repository"
 	"ecommerce-api/internal/services"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,58 +25,87 @@ func NewOrderController(orderService *services.OrderService) *OrderController {
 	return &OrderController{OrderService: orderService}
 }
 
+// placeOrderRequest is the body accepted when placing an order. Prices and
+// totals are always calculated by the server.
+type placeOrderRequest struct {
+	ProductID   uint     `json:"product_id" binding:"required"`
+	Quantity    int      `json:"quantity" binding:"required"`
+	CouponCode  string   `json:"coupon_code"`
+	CouponCodes []string `json:"coupon_codes"`
+	AddressID   uint     `json:"address_id"`
+	// ShippingMethodID is one of the methods quoted by /checkout/shipping-rates.
+	ShippingMethodID uint `json:"shipping_method_id"`
+}
+
 // PlaceOrder handles the request to place a new order
 // @Summary Place a new order
-// @Description Create a new order with the provided details for the authenticated user
+// @Description Create a new order for the authenticated user. Unit price and totals are calculated by the server from the current catalog and stored on the order. Promotion codes can be sent in coupon_code or coupon_codes, and address_id selects a saved address to ship to, which also determines the tax. shipping_method_id picks a shipping method for that address; without it the cheapest method available for the address is charged
 // @Tags Orders
 // @Accept json
 // @Produce json
-// @Param order body models.Order true "Order object containing items and shipping details"
+// @Param order body placeOrderRequest true "Product and quantity to order"
 // @Success 201 {object} models.Order "Successfully created order"
 // @Failure 400 {object} gin.H "Invalid input or malformed request body"
 // @Failure 401 {object} gin.H "User not authenticated or invalid authentication token"
+// @Failure 409 {object} gin.H "Not enough stock to reserve for the order"
+// @Failure 422 {object} gin.H "A promotion code was rejected; rejected_codes explains why"
 // @Failure 500 {object} gin.H "Internal server error while processing the order"
 // @Security ApiKeyAuth
 // @Router /orders [post]
 func (oc *OrderController) PlaceOrder(c *gin.Context) {
-	var order models.Order
-
 	// Retrieve the user ID from the context (set by the authentication middleware)
-	userID, exists := c.Get("userID")
-	if !exists {
+	uid, ok := currentUserID(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	// Convert userID to uint (assuming userID is a string in the token)
-	uid, err := strconv.Atoi(userID.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+	// Bind the request body; any amounts sent by the client are ignored
+	var request placeOrderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
 
-	// Convert int to uint before assigning to order.UserID
-	uidUint := uint(uid)
-	order.UserID = uidUint
-
-	// Set the status to "Pending"
-	order.Status = models.OrderStatusPending
-
-	// Bind the request body to the order struct
-	if err := c.ShouldBindJSON(&order); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
-		return
+	order := models.Order{
+		UserID:    uid,
+		ProductID: request.ProductID,
+		Quantity:  request.Quantity,
 	}
 
-	// Log the order to ensure UserID is not overwritten
-	log.Printf("Order details: %+v", order)
+	options := services.PlaceOrderOptions{
+		PromotionCodes:   request.CouponCodes,
+		AddressID:        request.AddressID,
+		ShippingMethodID: request.ShippingMethodID,
+	}
+	if request.CouponCode != "" {
+		options.PromotionCodes = append(options.PromotionCodes, request.CouponCode)
+	}
 
 	// Call the service to place the order
-	if err := oc.OrderService.PlaceOrder(&order); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := oc.OrderService.PlaceOrder(&order, options); err != nil {
+		var promotionErr *services.PromotionError
+		if errors.As(err, &promotionErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Promotion code rejected", "rejected_codes": promotionErr.Rejections})
+			return
+		}
+		switch err.Error() {
+		case "product not found":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Product not found"})
+		case "address not found":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Address not found"})
+		case "insufficient stock":
+			c.JSON(http.StatusConflict, gin.H{"error": "Insufficient stock"})
+		case "quantity must be greater than zero", "shipping address is required", "shipping method not available":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
+	log.Printf("Order placed: %+v", order)
+
 	// Respond with the created order
 	c.JSON(http.StatusCreated, order)
 }
@@ -109,43 +142,234 @@ func (oc *OrderController) ListOrders(c *gin.Context) {
 	c.JSON(http.StatusOK, orders)
 }
 
+// GetOrder handles the request to retrieve a single order
+// @Summary Get an order
+// @Description Retrieve an order by ID. Users can only see their own orders; admins can see any order
+// @Tags Orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} models.Order
+// @Header 200 {string} ETag "Order version, for If-Match on status updates"
+// @Failure 400 {object} gin.H{"error": "Invalid order ID"}
+// @Failure 404 {object} gin.H{"error": "Order not found"}
+// @Router /orders/{id} [get]
+func (oc *OrderController) GetOrder(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	oid, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	order, err := oc.OrderService.GetOrder(oid, uid, isAdmin(c))
+	if err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	c.Header("ETag", versionETag(order.Version))
+	c.JSON(http.StatusOK, order)
+}
+
+// AdminListOrders handles the request to list all orders
+// @Summary List all orders
+// @Description Retrieve a filtered, sorted and paginated list of all orders (admin only)
+// @Tags Admin
+// @Produce json
+// @Param status query string false "Order status (Pending, PartiallyShipped, Shipped, Completed, Cancelled)"
+// @Param user_id query int false "Only orders placed by this user"
+// @Param product_id query int false "Only orders for this product"
+// @Param from query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Created before (RFC 3339 or YYYY-MM-DD)"
+// @Param sort query string false "Sort key, prefix with - for descending (id, created_at, updated_at, status, quantity, user_id)" default(-created_at)
+// @Param include_deleted query bool false "Also list soft-deleted orders"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Success 200 {object} gin.H{"orders": []models.Order, "total": 0, "page": 1, "page_size": 20}
+// @Failure 400 {object} gin.H{"error": "Invalid filter"}
+// @Failure 500 {object} gin.H{"error": "Internal server error"}
+// @Router /admin/orders [get]
+func (oc *OrderController) AdminListOrders(c *gin.Context) {
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orders, total, err := oc.OrderService.ListOrders(&filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orders":    orders,
+		"total":     total,
+		"page":      filter.Page,
+		"page_size": filter.PageSize,
+	})
+}
+
 // CancelOrder handles the request to cancel an order
 // @Summary Cancel an order
-// @Description Cancel a specific order by ID
+// @Description Cancel one of the authenticated user's pending orders by ID
 // @Tags Orders
 // @Produce json
 // @Param id path int true "Order ID"
 // @Success 200 {object} gin.H{"message": "Order canceled successfully"}
 // @Failure 400 {object} gin.H{"error": "Invalid order ID"}
+// @Failure 404 {object} gin.H{"error": "Order not found"}
+// @Failure 409 {object} gin.H{"error": "Order cannot be canceled"}
 // @Failure 500 {object} gin.H{"error": "Internal server error"}
-// @Router /orders/{id} [delete]
+// @Router /orders/{id}/cancel [put]
 func (oc *OrderController) CancelOrder(c *gin.Context) {
-	orderID := c.Param("id")
-	oid, err := strconv.ParseUint(orderID, 10, 32)
-	if err != nil {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	oid, ok := parseIDParam(c, "id")
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
 		return
 	}
 
-	oidUint := uint(oid)
-	if err := oc.OrderService.CancelOrder(oidUint); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := oc.OrderService.CancelOrder(oid, uid); err != nil {
+		respondOrderError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Order canceled successfully"})
 }
 
+// AdminCancelOrder handles the request to cancel any user's order
+// @Summary Cancel any order
+// @Description Cancel a pending order regardless of who placed it (admin only)
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} gin.H{"message": "Order canceled successfully"}
+// @Failure 400 {object} gin.H{"error": "Invalid order ID"}
+// @Failure 404 {object} gin.H{"error": "Order not found"}
+// @Failure 409 {object} gin.H{"error": "Order cannot be canceled"}
+// @Router /admin/orders/{id}/cancel [put]
+func (oc *OrderController) AdminCancelOrder(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	oid, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	if err := oc.OrderService.AdminCancelOrder(oid, adminID); err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order canceled successfully"})
+}
+
+// AdminMarkOrderPaid handles the request to record an order's payment
+// @Summary Mark an order as paid
+// @Description Records that payment for an order was received. Paid orders are not expired (admin only)
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} gin.H{"message": "Order marked as paid"}
+// @Failure 400 {object} gin.H{"error": "Invalid order ID"}
+// @Failure 404 {object} gin.H{"error": "Order not found"}
+// @Failure 409 {object} gin.H{"error": "order is already paid"}
+// @Router /admin/orders/{id}/paid [put]
+func (oc *OrderController) AdminMarkOrderPaid(c *gin.Context) {
+	oid, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	if err := oc.OrderService.MarkOrderPaid(oid); err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order marked as paid"})
+}
+
+// AdminDeleteOrder handles the request to delete an order
+// @Summary Delete an order
+// @Description Soft-deletes a completed or cancelled order. Open orders cannot be deleted (admin only)
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} gin.H{"message": "Order deleted successfully"}
+// @Failure 400 {object} gin.H{"error": "Invalid order ID"}
+// @Failure 404 {object} gin.H{"error": "Order not found"}
+// @Failure 409 {object} gin.H{"error": "order cannot be deleted while it is open"}
+// @Router /admin/orders/{id} [delete]
+func (oc *OrderController) AdminDeleteOrder(c *gin.Context) {
+	oid, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	if err := oc.OrderService.DeleteOrder(oid); err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order deleted successfully"})
+}
+
+// AdminRestoreOrder handles the request to bring back a deleted order
+// @Summary Restore an order
+// @Description Restores a soft-deleted order (admin only)
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} gin.H{"message": "Order restored successfully"}
+// @Failure 400 {object} gin.H{"error": "Invalid order ID"}
+// @Failure 404 {object} gin.H{"error": "Order not found"}
+// @Router /admin/orders/{id}/restore [post]
+func (oc *OrderController) AdminRestoreOrder(c *gin.Context) {
+	oid, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	if err := oc.OrderService.RestoreOrder(oid); err != nil {
+		respondOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order restored successfully"})
+}
+
 // UpdateOrderStatus handles the request to update the status of an order
 // @Summary Update order status
-// @Description Update the status of a specific order
+// @Description Update the status of a specific order. Cancelled and completed orders cannot change, and no order goes back to Pending. With an If-Match ETag the update only applies if the order has not changed since
 // @Tags Orders
 // @Accept json
 // @Produce json
 // @Param id path int true "Order ID"
-// @Param status body string true "Order status (Pending, Completed, Cancelled)"
+// @Param If-Match header string false "ETag from GET /orders/{id}"
+// @Param status body string true "Order status (Pending, PartiallyShipped, Shipped, Completed, Cancelled)"
 // @Success 200 {object} gin.H{"message": "Order status updated"}
 // @Failure 400 {object} gin.H{"error": "Invalid input or status"}
+// @Failure 404 {object} gin.H{"error": "Order not found"}
+// @Failure 409 {object} gin.H{"error": "order was modified by another request", "current_version": int}
+// @Failure 409 {object} gin.H{"error": "order cannot move from Cancelled to Pending"}
 // @Failure 500 {object} gin.H{"error": "Internal server error"}
 // @Router /orders/{id}/status [put]
 func (oc *OrderController) UpdateOrderStatus(c *gin.Context) {
@@ -162,7 +386,7 @@ func (oc *OrderController) UpdateOrderStatus(c *gin.Context) {
 	}
 
 	if !isValidStatus(statusUpdate.Status) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Valid values are: Pending, Completed, Cancelled"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Valid values are: Pending, PartiallyShipped, Shipped, Completed, Cancelled"})
 		return
 	}
 
@@ -172,9 +396,18 @@ func (oc *OrderController) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
+	ifVersion, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	oidUint := uint(oid)
-	if err := oc.OrderService.UpdateOrderStatus(oidUint, statusUpdate.Status); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := oc.OrderService.UpdateOrderStatus(oidUint, statusUpdate.Status, ifVersion); err != nil {
+		if respondVersionConflict(c, err) {
+			return
+		}
+		respondOrderError(c, err)
 		return
 	}
 
@@ -183,7 +416,7 @@ func (oc *OrderController) UpdateOrderStatus(c *gin.Context) {
 
 // Helper function to validate status
 func isValidStatus(status string) bool {
-	validStatuses := []string{"Pending", "Completed", "Cancelled"}
+	validStatuses := []string{"Pending", "PartiallyShipped", "Shipped", "Completed", "Cancelled"}
 	for _, s := range validStatuses {
 		if status == s {
 			return true
@@ -191,3 +424,83 @@ func isValidStatus(status string) bool {
 	}
 	return false
 }
+
+// respondOrderError maps order service errors to HTTP responses.
+func respondOrderError(c *gin.Context, err error) {
+	var transitionErr *repository.OrderTransitionError
+	if errors.As(err, &transitionErr) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	switch err.Error() {
+	case "order not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+	case "order cannot be canceled as it is not in Pending status",
+		"order cannot be deleted while it is open",
+		"order is already paid", "cancelled orders cannot be paid":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// parseOrderFilter reads the admin order listing filters from the query string.
+func parseOrderFilter(c *gin.Context) (repository.OrderFilter, error) {
+	filter := repository.OrderFilter{
+		Status:   c.Query("status"),
+		Page:     1,
+		PageSize: 20,
+		SortBy:   "created_at",
+		SortDesc: true,
+	}
+
+	if filter.Status != "" && !isValidStatus(filter.Status) {
+		return filter, errors.New("invalid status. Valid values are: Pending, PartiallyShipped, Shipped, Completed, Cancelled")
+	}
+
+	for name, target := range map[string]*uint{"user_id": &filter.UserID, "product_id": &filter.ProductID} {
+		if value := c.Query(name); value != "" {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s", name)
+			}
+			*target = uint(id)
+		}
+	}
+
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := c.Query(name); value != "" {
+			t, err := parseTimeParam(value)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s date", name)
+			}
+			*target = &t
+		}
+	}
+
+	includeDeleted, err := parseBoolQuery(c, "include_deleted")
+	if err != nil {
+		return filter, errors.New("invalid include_deleted")
+	}
+	filter.IncludeDeleted = includeDeleted
+
+	if sort := c.Query("sort"); sort != "" {
+		filter.SortDesc = strings.HasPrefix(sort, "-")
+		filter.SortBy = strings.TrimPrefix(sort, "-")
+		if !repository.IsValidOrderSort(filter.SortBy) {
+			return filter, errors.New("invalid sort key")
+		}
+	}
+
+	for name, target := range map[string]*int{"page": &filter.Page, "page_size": &filter.PageSize} {
+		if value := c.Query(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return filter, fmt.Errorf("invalid %s", name)
+			}
+			*target = n
+		}
+	}
+
+	return filter, nil
+}
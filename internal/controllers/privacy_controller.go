@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"ecommerce-api/internal/services"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrivacyController handles data export and erasure requests.
+type PrivacyController struct {
+	PrivacyService *services.PrivacyService
+}
+
+// NewPrivacyController creates a new PrivacyController instance.
+func NewPrivacyController(privacyService *services.PrivacyService) *PrivacyController {
+	return &PrivacyController{PrivacyService: privacyService}
+}
+
+// ExportUserData returns everything stored about the authenticated user
+// @Summary Export personal data
+// @Description Downloads the profile, addresses and orders of the authenticated user as a ZIP archive, or as a single JSON document with format=json
+// @Tags Users
+// @Produce  application/zip
+// @Produce  json
+// @Param format query string false "Export format (zip or json)" default(zip)
+// @Success 200 {object} services.UserDataExport
+// @Failure 400 {object} gin.H{"error": "Invalid format"}
+// @Failure 401 {object} gin.H{"error": "User not authenticated"}
+// @Failure 500 {object} gin.H{"error": "Could not export user data"}
+// @Router /users/me/export [get]
+func (pc *PrivacyController) ExportUserData(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	switch c.DefaultQuery("format", "zip") {
+	case "json":
+		export, err := pc.PrivacyService.ExportUserData(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not export user data"})
+			return
+		}
+		c.JSON(http.StatusOK, export)
+	case "zip":
+		archive, err := pc.PrivacyService.ExportUserDataZip(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not export user data"})
+			return
+		}
+		filename := fmt.Sprintf("user-%d-export-%s.zip", userID, time.Now().UTC().Format("20060102"))
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Data(http.StatusOK, "application/zip", archive)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Valid values are: zip, json"})
+	}
+}
+
+// RequestErasure queues the anonymisation of the authenticated user's personal data
+// @Summary Request account erasure
+// @Description Queues a right-to-be-forgotten request. Email, name and addresses are anonymised in the background; orders are kept for accounting
+// @Tags Users
+// @Produce  json
+// @Success 202 {object} models.ErasureRequest
+// @Failure 401 {object} gin.H{"error": "User not authenticated"}
+// @Failure 500 {object} gin.H{"error": "Could not request erasure"}
+// @Router /users/me/erasure [post]
+func (pc *PrivacyController) RequestErasure(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	request, err := pc.PrivacyService.RequestErasure(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not request erasure"})
+		return
+	}
+
+	// The session ends here; the account becomes unusable once the job runs.
+	c.SetCookie("access_token", "", -1, "/", "", false, true)
+	c.JSON(http.StatusAccepted, request)
+}
@@ -1,15 +1,28 @@
 package controllers
 
 import (
+	"ecommerce-api/internal/catalog"
+	"ecommerce-api/internal/logger"
 	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/patch"
 	"ecommerce-api/internal/services"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxImportBytes is the largest product import accepted.
+const maxImportBytes = 100 << 20
+
+// maxPatchBytes is the largest product patch accepted.
+const maxPatchBytes = 1 << 20
+
 // ProductController handles HTTP requests related to products.
 type ProductController struct {
 	ProductService *services.ProductService
@@ -48,12 +61,14 @@ func (pc *ProductController) CreateProduct(c *gin.Context) {
 
 // GetProductByID retrieves a product by its ID.
 // @Summary Get a product by ID
-// @Description Retrieves a product by its unique ID
+// @Description Retrieves a product by its unique ID. Soft-deleted products are found with include_deleted=true
 // @Tags Product
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param include_deleted query bool false "Also find a soft-deleted product"
 // @Success 200 {object} models.Product
+// @Header 200 {string} ETag "Product version, for If-Match on updates"
 // @Failure 400 {object} gin.H{"error": "Invalid product ID"}
 // @Failure 500 {object} gin.H{"error": "Could not retrieve product"}
 // @Router /products/{id} [get]
@@ -68,27 +83,42 @@ func (pc *ProductController) GetProductByID(c *gin.Context) {
 	// Convert the id to uint
 	productID := uint(id)
 
+	includeDeleted, err := parseBoolQuery(c, "include_deleted")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid include_deleted"})
+		return
+	}
+
 	// Now call the service with the correct id type
-	product, err := pc.ProductService.GetProductByID(productID)
+	product, err := pc.ProductService.GetProductByID(productID, includeDeleted)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve product"})
 		return
 	}
 
+	c.Header("ETag", versionETag(product.Version))
 	c.JSON(http.StatusOK, product)
 }
 
 // GetProducts retrieves all products.
 // @Summary Get all products
-// @Description Retrieves all available products in the system
+// @Description Retrieves all available products in the system. Soft-deleted products are listed too with include_deleted=true
 // @Tags Product
 // @Accept json
 // @Produce json
+// @Param include_deleted query bool false "Also list soft-deleted products"
 // @Success 200 {array} models.Product
+// @Failure 400 {object} gin.H{"error": "invalid include_deleted"}
 // @Failure 500 {object} gin.H{"error": "Could not retrieve products"}
 // @Router /products [get]
 func (pc *ProductController) GetProducts(c *gin.Context) {
-	products, err := pc.ProductService.GetProducts()
+	includeDeleted, err := parseBoolQuery(c, "include_deleted")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid include_deleted"})
+		return
+	}
+
+	products, err := pc.ProductService.GetProducts(includeDeleted)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve products"})
 		return
@@ -97,16 +127,28 @@ func (pc *ProductController) GetProducts(c *gin.Context) {
 	c.JSON(http.StatusOK, products)
 }
 
+// updateProductRequest is the body accepted when updating a product. Stock
+// and the reorder threshold are pointers so that setting them to zero can be
+// told apart from leaving them out.
+type updateProductRequest struct {
+	models.Product
+	Stock            *int `json:"stock"`
+	ReorderThreshold *int `json:"reorder_threshold"`
+}
+
 // UpdateProduct handles the update of an existing product.
 // @Summary Update a product
-// @Description Updates an existing product in the system
+// @Description Updates an existing product in the system. A stock value, zero included, is applied as an inventory adjustment. With an If-Match ETag, or a version in the body, the update only applies if the product has not changed since
 // @Tags Product
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag from GET /products/{id}"
 // @Param product body models.Product true "Updated Product Data"
 // @Success 200 {object} gin.H{"message": "Product updated successfully", "product": models.Product}
+// @Header 200 {string} ETag "New product version"
 // @Failure 400 {object} gin.H{"error": "Invalid product ID"}
+// @Failure 409 {object} gin.H{"error": "product was modified by another request", "current_version": int}
 // @Failure 500 {object} gin.H{"error": "Could not update product"}
 // @Router /products/{id} [put]
 func (pc *ProductController) UpdateProduct(c *gin.Context) {
@@ -118,22 +160,48 @@ func (pc *ProductController) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	var product models.Product
-	if err := c.ShouldBindJSON(&product); err != nil {
+	var request updateProductRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
 		fmt.Println("Error binding JSON: ", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
 
 	// Set the product ID from the URL params
+	product := request.Product
 	product.ID = uint(id)
 
+	// If-Match takes precedence over a version sent in the body
+	ifVersion, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if ifVersion == 0 {
+		ifVersion = product.Version
+	}
+
+	// The admin making the change is recorded against any stock adjustment
+	actorID, _ := currentUserID(c)
+
 	// Call the service to update the product and handle returned error
-	updatedProduct, err := pc.ProductService.UpdateProduct(&product)
+	updatedProduct, err := pc.ProductService.UpdateProduct(&product, services.ProductUpdateOptions{
+		Stock:            request.Stock,
+		ReorderThreshold: request.ReorderThreshold,
+		ActorID:          actorID,
+		IfVersion:        ifVersion,
+	})
 	if err != nil {
 		fmt.Println("Error updating product: ", err)
+		if respondVersionConflict(c, err) {
+			return
+		}
 		if err.Error() == "product not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		} else if err.Error() == "product stock cannot be negative" || err.Error() == "reorder threshold cannot be negative" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else if err.Error() == "stock held at warehouses must be adjusted per warehouse" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update product"})
 		}
@@ -141,12 +209,83 @@ func (pc *ProductController) UpdateProduct(c *gin.Context) {
 	}
 
 	// Return the updated product with a success message
+	c.Header("ETag", versionETag(updatedProduct.Version))
 	c.JSON(http.StatusOK, gin.H{"message": "Product updated successfully", "product": updatedProduct})
 }
 
+// PatchProduct handles a partial update of a product.
+// @Summary Patch a product
+// @Description Partially updates a product with a JSON Merge Patch (application/merge-patch+json or application/json, RFC 7396) or a JSON Patch (application/json-patch+json, RFC 6902). Fields left out keep their value and fields set to null are cleared, so stock, price and other fields can be set to zero. The patched product is validated and only changed columns are written; a stock change is applied as an inventory adjustment. With an If-Match ETag, a version in the patch or a JSON Patch test on /version, the patch only applies if the product has not changed since
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag from GET /products/{id}"
+// @Param patch body object true "Merge patch object or JSON Patch operations"
+// @Success 200 {object} gin.H{"message": "Product updated successfully", "product": models.Product}
+// @Header 200 {string} ETag "New product version"
+// @Failure 400 {object} gin.H{"error": "invalid patch: product name is required"}
+// @Failure 404 {object} gin.H{"error": "Product not found"}
+// @Failure 409 {object} gin.H{"error": "product was modified by another request", "current_version": int}
+// @Failure 415 {object} gin.H{"error": "Content-Type must be application/merge-patch+json or application/json-patch+json"}
+// @Failure 500 {object} gin.H{"error": "Could not update product"}
+// @Router /products/{id} [patch]
+func (pc *ProductController) PatchProduct(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	format := patch.FormatFromContentType(c.ContentType())
+	if format == "" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/merge-patch+json or application/json-patch+json"})
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPatchBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	ifVersion, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	actorID, _ := currentUserID(c)
+
+	product, err := pc.ProductService.PatchProduct(productID, format, body, services.ProductUpdateOptions{
+		ActorID:   actorID,
+		IfVersion: ifVersion,
+	})
+	if err != nil {
+		if respondVersionConflict(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, patch.ErrTestFailed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidPatch):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "product not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		case err.Error() == "stock held at warehouses must be adjusted per warehouse":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			logger.Error("could not patch product: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update product"})
+		}
+		return
+	}
+
+	c.Header("ETag", versionETag(product.Version))
+	c.JSON(http.StatusOK, gin.H{"message": "Product updated successfully", "product": product})
+}
+
 // DeleteProduct handles the deletion of a product.
 // @Summary Delete a product
-// @Description Deletes a product by its ID
+// @Description Soft-deletes a product by its ID. Products with open orders cannot be deleted
 // @Tags Product
 // @Accept json
 // @Produce json
@@ -154,6 +293,7 @@ func (pc *ProductController) UpdateProduct(c *gin.Context) {
 // @Success 200 {object} gin.H{"message": "Product deleted successfully"}
 // @Failure 400 {object} gin.H{"error": "Invalid product ID"}
 // @Failure 404 {object} gin.H{"error": "Product not found"}
+// @Failure 409 {object} gin.H{"error": "product has open orders"}
 // @Failure 500 {object} gin.H{"error": "Could not delete product"}
 // @Router /products/{id} [delete]
 func (pc *ProductController) DeleteProduct(c *gin.Context) {
@@ -168,9 +308,12 @@ func (pc *ProductController) DeleteProduct(c *gin.Context) {
 
 	// Call the service to delete the product
 	if err := pc.ProductService.DeleteProduct(productID); err != nil {
-		if err.Error() == "product not found" {
+		switch err.Error() {
+		case "product not found":
 			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
-		} else {
+		case "product has open orders":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete product"})
 		}
 		return
@@ -179,3 +322,164 @@ func (pc *ProductController) DeleteProduct(c *gin.Context) {
 	// Use HTTP StatusOK for responses with a message
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
+
+// RestoreProduct handles bringing back a soft-deleted product.
+// @Summary Restore a product
+// @Description Restores a soft-deleted product to the catalog (admin only)
+// @Tags Product
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} gin.H{"message": "Product restored successfully"}
+// @Failure 400 {object} gin.H{"error": "Invalid product ID"}
+// @Failure 404 {object} gin.H{"error": "Product not found"}
+// @Failure 500 {object} gin.H{"error": "Could not restore product"}
+// @Router /admin/products/{id}/restore [post]
+func (pc *ProductController) RestoreProduct(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	if err := pc.ProductService.RestoreProduct(productID); err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not restore product"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Product restored successfully"})
+}
+
+// ImportProducts handles a bulk product import.
+// @Summary Import products
+// @Description Creates or updates products by SKU from a CSV file (header row naming the columns) or JSON Lines file, sent as the request body or as the "file" field of a multipart form. Columns: sku, name, description, category, tax_class, price, stock, reorder_threshold, weight, length, width, height; an empty stock leaves stock unchanged. Every row is validated and valid rows are saved in batches. With dry_run=true nothing is saved. The report lists the rows that failed (admin only)
+// @Tags Product
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Accept multipart/form-data
+// @Produce json
+// @Param format query string false "File format (csv, jsonl); inferred from the content type or file name when left out"
+// @Param dry_run query bool false "Validate without saving"
+// @Param file formData file false "Import file"
+// @Success 200 {object} services.ImportReport
+// @Failure 400 {object} gin.H{"error": "Invalid import file"}
+// @Router /admin/products/import [post]
+func (pc *ProductController) ImportProducts(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dry_run"})
+			return
+		}
+		dryRun = parsed
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	format := c.Query("format")
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import file"})
+			return
+		}
+		defer file.Close()
+		body = file
+		if format == "" {
+			format = formatFromFileName(header.Filename)
+		}
+	} else if format == "" {
+		format = formatFromContentType(c.ContentType())
+	}
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or jsonl"})
+		return
+	}
+
+	reader, err := catalog.NewReader(format, body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := pc.ProductService.ImportProducts(reader, dryRun, adminID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportProducts streams the catalog as a file.
+// @Summary Export products
+// @Description Downloads every product as CSV or JSON Lines, in the same columns the import accepts (admin only)
+// @Tags Product
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "File format (csv, jsonl)" default(csv)
+// @Success 200 {file} file
+// @Failure 400 {object} gin.H{"error": "format must be csv or jsonl"}
+// @Router /admin/products/export [get]
+func (pc *ProductController) ExportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", catalog.FormatCSV)
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case catalog.FormatCSV:
+	case catalog.FormatJSONL:
+		contentType = "application/x-ndjson"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or jsonl"})
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="products.`+format+`"`)
+	c.Status(http.StatusOK)
+
+	writer, err := catalog.NewWriter(format, c.Writer)
+	if err == nil {
+		err = pc.ProductService.ExportProducts(writer)
+	}
+	if err != nil {
+		// The response has already started, so the download is cut short
+		logger.Error("product export failed: " + err.Error())
+	}
+}
+
+// formatFromContentType picks the import format from a request's content type.
+func formatFromContentType(contentType string) string {
+	switch contentType {
+	case "text/csv", "application/csv":
+		return catalog.FormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return catalog.FormatJSONL
+	}
+	return ""
+}
+
+// formatFromFileName picks the import format from an uploaded file's extension.
+func formatFromFileName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return catalog.FormatCSV
+	case ".jsonl", ".ndjson":
+		return catalog.FormatJSONL
+	}
+	return ""
+}
@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"ecommerce-api/internal/services"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// imageCacheControl lets clients and CDNs keep image files for a year.
+// Stored files never change: a new upload gets a new key.
+const imageCacheControl = "public, max-age=31536000, immutable"
+
+// ProductImageController handles HTTP requests related to product images.
+type ProductImageController struct {
+	ProductImageService *services.ProductImageService
+}
+
+// NewProductImageController creates a new ProductImageController instance.
+func NewProductImageController(productImageService *services.ProductImageService) *ProductImageController {
+	return &ProductImageController{ProductImageService: productImageService}
+}
+
+// updateImageRequest is the body accepted when editing an image.
+type updateImageRequest struct {
+	AltText   *string `json:"alt_text"`
+	SortOrder *int    `json:"sort_order"`
+}
+
+// UploadImage handles an image upload for a product.
+// @Summary Upload a product image
+// @Description Uploads a JPEG, PNG or GIF image as the "file" field of a multipart form. The type is checked from the file content and the size against PRODUCT_IMAGE_MAX_BYTES. Small (200px) and medium (600px) thumbnails are made. Without sort_order the image goes last (admin only)
+// @Tags Product
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param file formData file true "Image file"
+// @Param alt_text formData string false "Alternative text"
+// @Param sort_order formData int false "Position among the product's images"
+// @Success 201 {object} models.ProductImage
+// @Failure 400 {object} gin.H{"error": "image must be a JPEG, PNG or GIF"}
+// @Failure 404 {object} gin.H{"error": "Product not found"}
+// @Failure 413 {object} gin.H{"error": "image exceeds the maximum size"}
+// @Router /admin/products/{id}/images [post]
+func (ic *ProductImageController) UploadImage(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// Leave room for the multipart framing and the other form fields
+	maxBytes := ic.ProductImageService.MaxBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+64*1024)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "image exceeds the maximum size"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if header.Size > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "image exceeds the maximum size"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image file"})
+		return
+	}
+
+	var sortOrder *int
+	if value := c.PostForm("sort_order"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort_order"})
+			return
+		}
+		sortOrder = &n
+	}
+
+	image, err := ic.ProductImageService.UploadImage(c.Request.Context(), productID, data, c.PostForm("alt_text"), sortOrder)
+	if err != nil {
+		respondProductImageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, image)
+}
+
+// GetImages lists the images of a product.
+// @Summary List product images
+// @Description Lists a product's images in display order with their thumbnails. Files are served from /api/images/{imageId}/{variant} (admin only)
+// @Tags Product
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.ProductImage
+// @Failure 400 {object} gin.H{"error": "Invalid product ID"}
+// @Failure 404 {object} gin.H{"error": "Product not found"}
+// @Router /products/{id}/images [get]
+func (ic *ProductImageController) GetImages(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	images, err := ic.ProductImageService.GetImages(productID)
+	if err != nil {
+		respondProductImageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, images)
+}
+
+// UpdateImage edits the alt text or position of a product image.
+// @Summary Update a product image
+// @Description Changes the alt text and sort order of a product image; fields left out are not changed (admin only)
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param imageId path int true "Image ID"
+// @Param image body updateImageRequest true "Image Changes"
+// @Success 200 {object} models.ProductImage
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 404 {object} gin.H{"error": "Image not found"}
+// @Router /admin/products/{id}/images/{imageId} [put]
+func (ic *ProductImageController) UpdateImage(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	imageID, ok := parseIDParam(c, "imageId")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		return
+	}
+
+	var request updateImageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	image, err := ic.ProductImageService.UpdateImage(productID, imageID, request.AltText, request.SortOrder)
+	if err != nil {
+		respondProductImageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, image)
+}
+
+// DeleteImage removes a product image.
+// @Summary Delete a product image
+// @Description Deletes a product image and its stored files (admin only)
+// @Tags Product
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param imageId path int true "Image ID"
+// @Success 200 {object} gin.H{"message": "Image deleted successfully"}
+// @Failure 400 {object} gin.H{"error": "Invalid image ID"}
+// @Failure 404 {object} gin.H{"error": "Image not found"}
+// @Router /admin/products/{id}/images/{imageId} [delete]
+func (ic *ProductImageController) DeleteImage(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	imageID, ok := parseIDParam(c, "imageId")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		return
+	}
+
+	if err := ic.ProductImageService.DeleteImage(productID, imageID); err != nil {
+		respondProductImageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
+}
+
+// ServeImage serves an image file.
+// @Summary Get an image file
+// @Description Serves the original image or a thumbnail (small, medium). Files never change, so responses are cacheable for a year and conditional requests with If-None-Match get 304
+// @Tags Product
+// @Produce image/jpeg
+// @Produce image/png
+// @Produce image/gif
+// @Param imageId path int true "Image ID"
+// @Param variant path string true "original, small or medium"
+// @Success 200 {file} file
+// @Success 304 "Not Modified"
+// @Failure 404 {object} gin.H{"error": "Image not found"}
+// @Router /images/{imageId}/{variant} [get]
+func (ic *ProductImageController) ServeImage(c *gin.Context) {
+	imageID, ok := parseIDParam(c, "imageId")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		return
+	}
+
+	file, contentType, key, err := ic.ProductImageService.OpenImage(c.Request.Context(), imageID, c.Param("variant"))
+	if err != nil {
+		respondProductImageError(c, err)
+		return
+	}
+	defer file.Close()
+
+	etag := `"` + key + `"`
+	c.Header("Cache-Control", imageCacheControl)
+	c.Header("ETag", etag)
+	if match := c.GetHeader("If-None-Match"); match != "" && strings.Contains(match, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, -1, contentType, file, nil)
+}
+
+// respondProductImageError maps product image service errors to HTTP responses.
+func respondProductImageError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "product not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case err.Error() == "image not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+	case strings.HasPrefix(err.Error(), "image exceeds the maximum size"):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "image "):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not process image"})
+	}
+}
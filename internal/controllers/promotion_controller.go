@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromotionController handles HTTP requests related to promotions.
+type PromotionController struct {
+	PromotionService *services.PromotionService
+}
+
+// NewPromotionController creates a new PromotionController instance.
+func NewPromotionController(promotionService *services.PromotionService) *PromotionController {
+	return &PromotionController{PromotionService: promotionService}
+}
+
+// CreatePromotion handles the creation of a new promotion.
+// @Summary Create a promotion
+// @Description Creates a new discount code. It is active unless active is false (admin only)
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Param promotion body models.Promotion true "Promotion Data"
+// @Success 201 {object} models.Promotion
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 500 {object} gin.H{"error": "Could not create promotion"}
+// @Router /admin/promotions [post]
+func (pc *PromotionController) CreatePromotion(c *gin.Context) {
+	// A promotion is active unless the request turns it off
+	promotion := models.Promotion{Active: true}
+	if err := c.ShouldBindJSON(&promotion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	promotion.ID = 0
+
+	if err := pc.PromotionService.CreatePromotion(&promotion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotion)
+}
+
+// GetPromotions retrieves all promotions.
+// @Summary Get all promotions
+// @Description Retrieves every promotion including usage counts (admin only)
+// @Tags Promotions
+// @Produce json
+// @Success 200 {array} models.Promotion
+// @Failure 500 {object} gin.H{"error": "Could not retrieve promotions"}
+// @Router /admin/promotions [get]
+func (pc *PromotionController) GetPromotions(c *gin.Context) {
+	promotions, err := pc.PromotionService.GetPromotions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve promotions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promotions)
+}
+
+// GetPromotionByID retrieves a promotion by its ID.
+// @Summary Get a promotion by ID
+// @Description Retrieves a promotion by its unique ID (admin only)
+// @Tags Promotions
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} models.Promotion
+// @Failure 400 {object} gin.H{"error": "Invalid promotion ID"}
+// @Failure 404 {object} gin.H{"error": "Promotion not found"}
+// @Router /admin/promotions/{id} [get]
+func (pc *PromotionController) GetPromotionByID(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion ID"})
+		return
+	}
+
+	promotion, err := pc.PromotionService.GetPromotionByID(id)
+	if err != nil {
+		respondPromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// UpdatePromotion handles the update of an existing promotion.
+// @Summary Update a promotion
+// @Description Replaces an existing promotion. The usage count is not changed (admin only)
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Param promotion body models.Promotion true "Updated Promotion Data"
+// @Success 200 {object} models.Promotion
+// @Failure 400 {object} gin.H{"error": "Invalid promotion ID"}
+// @Failure 404 {object} gin.H{"error": "Promotion not found"}
+// @Router /admin/promotions/{id} [put]
+func (pc *PromotionController) UpdatePromotion(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion ID"})
+		return
+	}
+
+	var promotion models.Promotion
+	if err := c.ShouldBindJSON(&promotion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	promotion.ID = id
+
+	if err := pc.PromotionService.UpdatePromotion(&promotion); err != nil {
+		respondPromotionError(c, err)
+		return
+	}
+
+	updated, err := pc.PromotionService.GetPromotionByID(id)
+	if err != nil {
+		respondPromotionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeletePromotion handles the deletion of a promotion.
+// @Summary Delete a promotion
+// @Description Deletes a promotion by its ID (admin only)
+// @Tags Promotions
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} gin.H{"message": "Promotion deleted successfully"}
+// @Failure 400 {object} gin.H{"error": "Invalid promotion ID"}
+// @Failure 404 {object} gin.H{"error": "Promotion not found"}
+// @Router /admin/promotions/{id} [delete]
+func (pc *PromotionController) DeletePromotion(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion ID"})
+		return
+	}
+
+	if err := pc.PromotionService.DeletePromotion(id); err != nil {
+		respondPromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion deleted successfully"})
+}
+
+// respondPromotionError maps promotion service errors to HTTP responses.
+func respondPromotionError(c *gin.Context, err error) {
+	if err.Error() == "promotion not found" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/repository"
+	"ecommerce-api/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReturnController handles HTTP requests related to returns and refunds.
+type ReturnController struct {
+	ReturnService *services.ReturnService
+}
+
+// NewReturnController creates a new ReturnController instance.
+func NewReturnController(returnService *services.ReturnService) *ReturnController {
+	return &ReturnController{ReturnService: returnService}
+}
+
+// requestReturnRequest is the body accepted when asking to return items.
+type requestReturnRequest struct {
+	Items  []models.ReturnItem `json:"items" binding:"required"`
+	Reason string              `json:"reason" binding:"required"`
+}
+
+// returnDecisionRequest is the optional body accepted by the admin return actions.
+type returnDecisionRequest struct {
+	Note string `json:"note"`
+	// Restock puts received items back into stock.
+	Restock bool `json:"restock"`
+	// Amount is the refund amount. Without it the returned items are refunded in full.
+	Amount *float64 `json:"amount"`
+}
+
+// RequestReturn opens a return request for items of an order.
+// @Summary Request a return
+// @Description Asks to return selected items of a completed order with a reason
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param request body requestReturnRequest true "Items to return and the reason"
+// @Success 201 {object} models.ReturnRequest
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 404 {object} gin.H{"error": "Order not found"}
+// @Failure 409 {object} gin.H{"error": "Return exceeds the returnable quantity"}
+// @Security ApiKeyAuth
+// @Router /orders/{id}/returns [post]
+func (rc *ReturnController) RequestReturn(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var request requestReturnRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	rma := models.ReturnRequest{
+		OrderID: orderID,
+		UserID:  uid,
+		Reason:  request.Reason,
+		Items:   request.Items,
+	}
+	if err := rc.ReturnService.RequestReturn(&rma); err != nil {
+		respondReturnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rma)
+}
+
+// GetOrderReturns lists the return requests of an order.
+// @Summary List returns of an order
+// @Description Retrieves the return requests of one of the user's orders with their history
+// @Tags Returns
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {array} models.ReturnRequest
+// @Failure 404 {object} gin.H{"error": "Order not found"}
+// @Security ApiKeyAuth
+// @Router /orders/{id}/returns [get]
+func (rc *ReturnController) GetOrderReturns(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	returns, err := rc.ReturnService.GetReturnsByOrder(orderID, uid, isAdmin(c))
+	if err != nil {
+		respondReturnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, returns)
+}
+
+// AdminListReturns lists all return requests.
+// @Summary List return requests
+// @Description Retrieves every return request, optionally filtered by status (admin only)
+// @Tags Returns
+// @Produce json
+// @Param status query string false "Return status (requested, approved, rejected, received, refunded)"
+// @Success 200 {array} models.ReturnRequest
+// @Failure 400 {object} gin.H{"error": "Invalid status"}
+// @Router /admin/returns [get]
+func (rc *ReturnController) AdminListReturns(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.ReturnStatusRequested, models.ReturnStatusApproved, models.ReturnStatusRejected,
+		models.ReturnStatusReceived, models.ReturnStatusRefunded:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Valid values are: requested, approved, rejected, received, refunded"})
+		return
+	}
+
+	returns, err := rc.ReturnService.ListReturns(status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve returns"})
+		return
+	}
+
+	c.JSON(http.StatusOK, returns)
+}
+
+// AdminGetReturn retrieves a return request by its ID.
+// @Summary Get a return request
+// @Description Retrieves a return request with its items and history (admin only)
+// @Tags Returns
+// @Produce json
+// @Param id path int true "Return ID"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 404 {object} gin.H{"error": "Return not found"}
+// @Router /admin/returns/{id} [get]
+func (rc *ReturnController) AdminGetReturn(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid return ID"})
+		return
+	}
+
+	rma, err := rc.ReturnService.GetReturn(id)
+	if err != nil {
+		respondReturnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rma)
+}
+
+// ApproveReturn accepts a requested return.
+// @Summary Approve a return
+// @Description Accepts a requested return so the customer can send the goods back (admin only)
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Return ID"
+// @Param decision body returnDecisionRequest false "Optional note"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 404 {object} gin.H{"error": "Return not found"}
+// @Failure 409 {object} gin.H{"error": "Return cannot move to approved"}
+// @Router /admin/returns/{id}/approve [put]
+func (rc *ReturnController) ApproveReturn(c *gin.Context) {
+	rc.decide(c, func(id, actorID uint, request returnDecisionRequest) (*models.ReturnRequest, error) {
+		return rc.ReturnService.ApproveReturn(id, actorID, request.Note)
+	})
+}
+
+// RejectReturn declines a requested return.
+// @Summary Reject a return
+// @Description Declines a requested return. A note explaining why is required (admin only)
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Return ID"
+// @Param decision body returnDecisionRequest true "Note explaining the rejection"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 404 {object} gin.H{"error": "Return not found"}
+// @Failure 409 {object} gin.H{"error": "Return cannot move to rejected"}
+// @Router /admin/returns/{id}/reject [put]
+func (rc *ReturnController) RejectReturn(c *gin.Context) {
+	rc.decide(c, func(id, actorID uint, request returnDecisionRequest) (*models.ReturnRequest, error) {
+		return rc.ReturnService.RejectReturn(id, actorID, request.Note)
+	})
+}
+
+// ReceiveReturn records that the returned goods arrived.
+// @Summary Receive a return
+// @Description Records that the returned goods arrived. With restock the items are put back into stock (admin only)
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Return ID"
+// @Param decision body returnDecisionRequest false "Restock flag and optional note"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 404 {object} gin.H{"error": "Return not found"}
+// @Failure 409 {object} gin.H{"error": "Return cannot move to received"}
+// @Router /admin/returns/{id}/receive [put]
+func (rc *ReturnController) ReceiveReturn(c *gin.Context) {
+	rc.decide(c, func(id, actorID uint, request returnDecisionRequest) (*models.ReturnRequest, error) {
+		return rc.ReturnService.ReceiveReturn(id, actorID, request.Restock, request.Note)
+	})
+}
+
+// RefundReturn refunds a received return.
+// @Summary Refund a return
+// @Description Refunds a received or already refunded return through the payment provider, in full or for the given amount. The refund is recorded as pending before the payout and completed after it; a payout the provider rejects is recorded as failed and can be retried (admin only)
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Return ID"
+// @Param decision body returnDecisionRequest false "Optional amount and note"
+// @Success 200 {object} models.ReturnRequest
+// @Failure 400 {object} gin.H{"error": "Refund exceeds the refundable amount"}
+// @Failure 404 {object} gin.H{"error": "Return not found"}
+// @Failure 409 {object} gin.H{"error": "Return cannot move to refunded"}
+// @Failure 409 {object} gin.H{"error": "Nothing left to refund"}
+// @Router /admin/returns/{id}/refund [post]
+func (rc *ReturnController) RefundReturn(c *gin.Context) {
+	rc.decide(c, func(id, actorID uint, request returnDecisionRequest) (*models.ReturnRequest, error) {
+		return rc.ReturnService.RefundReturn(c.Request.Context(), id, actorID, request.Amount, request.Note)
+	})
+}
+
+// decide parses the return ID and optional body shared by the admin return
+// actions, runs the action and writes the updated return request.
+func (rc *ReturnController) decide(c *gin.Context, action func(id, actorID uint, request returnDecisionRequest) (*models.ReturnRequest, error)) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid return ID"})
+		return
+	}
+
+	var request returnDecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+	}
+
+	rma, err := action(id, actorID, request)
+	if err != nil {
+		respondReturnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rma)
+}
+
+// respondReturnError maps return service errors to HTTP responses.
+func respondReturnError(c *gin.Context, err error) {
+	var transitionErr *repository.ReturnTransitionError
+	if errors.As(err, &transitionErr) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	switch err.Error() {
+	case "order not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+	case "return not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Return not found"})
+	case "return exceeds the returnable quantity", "only completed orders can be returned", "refund is not pending",
+		"nothing left to refund":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
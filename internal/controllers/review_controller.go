@@ -0,0 +1,436 @@
+package controllers
+
+import (
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/repository"
+	"ecommerce-api/internal/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewController handles HTTP requests related to product reviews.
+type ReviewController struct {
+	ReviewService *services.ReviewService
+}
+
+// NewReviewController creates a new ReviewController instance.
+func NewReviewController(reviewService *services.ReviewService) *ReviewController {
+	return &ReviewController{ReviewService: reviewService}
+}
+
+// reviewRequest is the body accepted when posting or editing a review.
+type reviewRequest struct {
+	Rating int    `json:"rating" binding:"required"`
+	Title  string `json:"title" binding:"required"`
+	Body   string `json:"body"`
+}
+
+// reviewModerationRequest is the body accepted by the admin moderation actions.
+type reviewModerationRequest struct {
+	Note string `json:"note"`
+}
+
+// GetProductReviews lists the approved reviews of a product.
+// @Summary List product reviews
+// @Description Retrieves a page of a product's approved reviews with its average rating and the number of reviews per star level
+// @Tags Reviews
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param sort query string false "Sort key (newest, oldest, helpful, rating_high, rating_low)" default(newest)
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Success 200 {object} gin.H{"reviews": []models.Review, "rating": models.ProductRating, "total": 0, "page": 1, "page_size": 20}
+// @Failure 400 {object} gin.H{"error": "Invalid filter"}
+// @Failure 404 {object} gin.H{"error": "Product not found"}
+// @Router /products/{id}/reviews [get]
+func (rc *ReviewController) GetProductReviews(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	filter, err := parseReviewFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.ProductID = productID
+
+	reviews, total, rating, err := rc.ReviewService.GetProductReviews(&filter)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews":   reviews,
+		"rating":    rating,
+		"total":     total,
+		"page":      filter.Page,
+		"page_size": filter.PageSize,
+	})
+}
+
+// CreateReview posts a review for a product.
+// @Summary Review a product
+// @Description Posts a 1–5 star review for a product the user received in a completed order. Each product can be reviewed once and the review is shown after moderation
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param review body reviewRequest true "Rating, title and body"
+// @Success 201 {object} models.Review
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 403 {object} gin.H{"error": "Only customers who bought the product can review it"}
+// @Failure 404 {object} gin.H{"error": "Product not found"}
+// @Failure 409 {object} gin.H{"error": "Product already reviewed"}
+// @Security ApiKeyAuth
+// @Router /products/{id}/reviews [post]
+func (rc *ReviewController) CreateReview(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var request reviewRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	review := models.Review{
+		ProductID: productID,
+		UserID:    uid,
+		Rating:    request.Rating,
+		Title:     request.Title,
+		Body:      request.Body,
+	}
+	if err := rc.ReviewService.CreateReview(&review); err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, review)
+}
+
+// UpdateReview edits one of the user's reviews.
+// @Summary Edit a review
+// @Description Changes the rating, title and body of one of the user's reviews. The edited review goes back to moderation
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param review body reviewRequest true "Rating, title and body"
+// @Success 200 {object} models.Review
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 404 {object} gin.H{"error": "Review not found"}
+// @Security ApiKeyAuth
+// @Router /reviews/{id} [put]
+func (rc *ReviewController) UpdateReview(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var request reviewRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	review, err := rc.ReviewService.UpdateReview(id, uid, models.Review{
+		Rating: request.Rating,
+		Title:  request.Title,
+		Body:   request.Body,
+	})
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// DeleteReview removes one of the user's reviews.
+// @Summary Delete a review
+// @Description Deletes one of the user's reviews and removes it from the product's rating
+// @Tags Reviews
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} gin.H{"message": "Review deleted successfully"}
+// @Failure 404 {object} gin.H{"error": "Review not found"}
+// @Security ApiKeyAuth
+// @Router /reviews/{id} [delete]
+func (rc *ReviewController) DeleteReview(c *gin.Context) {
+	rc.deleteReview(c, false)
+}
+
+// VoteHelpful marks a review as helpful.
+// @Summary Vote a review helpful
+// @Description Records that the user found an approved review helpful. Each user can vote once per review and not for their own reviews
+// @Tags Reviews
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} gin.H{"message": "Vote recorded"}
+// @Failure 403 {object} gin.H{"error": "Cannot vote for your own review"}
+// @Failure 404 {object} gin.H{"error": "Review not found"}
+// @Failure 409 {object} gin.H{"error": "Review already voted helpful"}
+// @Security ApiKeyAuth
+// @Router /reviews/{id}/helpful [post]
+func (rc *ReviewController) VoteHelpful(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	if err := rc.ReviewService.VoteHelpful(id, uid); err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Vote recorded"})
+}
+
+// RemoveHelpfulVote takes back a helpful vote.
+// @Summary Remove a helpful vote
+// @Description Takes back the user's helpful vote for a review
+// @Tags Reviews
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} gin.H{"message": "Vote removed"}
+// @Failure 404 {object} gin.H{"error": "Vote not found"}
+// @Security ApiKeyAuth
+// @Router /reviews/{id}/helpful [delete]
+func (rc *ReviewController) RemoveHelpfulVote(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	if err := rc.ReviewService.RemoveHelpfulVote(id, uid); err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Vote removed"})
+}
+
+// AdminListReviews lists reviews for moderation.
+// @Summary List reviews
+// @Description Retrieves a filtered, sorted and paginated list of reviews in any moderation state (admin only)
+// @Tags Reviews
+// @Produce json
+// @Param status query string false "Review status (pending, approved, rejected)"
+// @Param product_id query int false "Only reviews of this product"
+// @Param user_id query int false "Only reviews by this user"
+// @Param sort query string false "Sort key (newest, oldest, helpful, rating_high, rating_low)" default(newest)
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size (max 100)" default(20)
+// @Success 200 {object} gin.H{"reviews": []models.Review, "total": 0, "page": 1, "page_size": 20}
+// @Failure 400 {object} gin.H{"error": "Invalid filter"}
+// @Router /admin/reviews [get]
+func (rc *ReviewController) AdminListReviews(c *gin.Context) {
+	filter, err := parseReviewFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch filter.Status {
+	case "", models.ReviewStatusPending, models.ReviewStatusApproved, models.ReviewStatusRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Valid values are: pending, approved, rejected"})
+		return
+	}
+
+	reviews, total, err := rc.ReviewService.ListReviews(&filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve reviews"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews":   reviews,
+		"total":     total,
+		"page":      filter.Page,
+		"page_size": filter.PageSize,
+	})
+}
+
+// ApproveReview publishes a review.
+// @Summary Approve a review
+// @Description Publishes a review and counts it in the product's rating (admin only)
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param decision body reviewModerationRequest false "Optional note"
+// @Success 200 {object} models.Review
+// @Failure 404 {object} gin.H{"error": "Review not found"}
+// @Router /admin/reviews/{id}/approve [put]
+func (rc *ReviewController) ApproveReview(c *gin.Context) {
+	rc.moderate(c, rc.ReviewService.ApproveReview)
+}
+
+// RejectReview hides a review.
+// @Summary Reject a review
+// @Description Hides a review and removes it from the product's rating. A note explaining why is required (admin only)
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param decision body reviewModerationRequest true "Note explaining the rejection"
+// @Success 200 {object} models.Review
+// @Failure 400 {object} gin.H{"error": "A note is required to reject a review"}
+// @Failure 404 {object} gin.H{"error": "Review not found"}
+// @Router /admin/reviews/{id}/reject [put]
+func (rc *ReviewController) RejectReview(c *gin.Context) {
+	rc.moderate(c, rc.ReviewService.RejectReview)
+}
+
+// AdminDeleteReview removes any review.
+// @Summary Delete any review
+// @Description Deletes a review regardless of who wrote it and removes it from the product's rating (admin only)
+// @Tags Reviews
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} gin.H{"message": "Review deleted successfully"}
+// @Failure 404 {object} gin.H{"error": "Review not found"}
+// @Router /admin/reviews/{id} [delete]
+func (rc *ReviewController) AdminDeleteReview(c *gin.Context) {
+	rc.deleteReview(c, true)
+}
+
+// deleteReview deletes the review named in the path, on behalf of its
+// author or, with asAdmin, of an admin.
+func (rc *ReviewController) deleteReview(c *gin.Context, asAdmin bool) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	if err := rc.ReviewService.DeleteReview(id, uid, asAdmin); err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review deleted successfully"})
+}
+
+// moderate parses the review ID and optional note shared by the moderation
+// actions, runs the action and writes the updated review.
+func (rc *ReviewController) moderate(c *gin.Context, action func(id, moderatorID uint, note string) (*models.Review, error)) {
+	moderatorID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var request reviewModerationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+	}
+
+	review, err := action(id, moderatorID, request.Note)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// parseReviewFilter reads the review filter, sort and pagination query parameters.
+func parseReviewFilter(c *gin.Context) (repository.ReviewFilter, error) {
+	filter := repository.ReviewFilter{
+		Status:   c.Query("status"),
+		SortBy:   c.DefaultQuery("sort", "newest"),
+		Page:     1,
+		PageSize: 20,
+	}
+	if !repository.IsValidReviewSort(filter.SortBy) {
+		return filter, errors.New("invalid sort key. Valid values are: newest, oldest, helpful, rating_high, rating_low")
+	}
+
+	for name, target := range map[string]*uint{"user_id": &filter.UserID, "product_id": &filter.ProductID} {
+		if value := c.Query(name); value != "" {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return filter, errors.New("invalid " + name)
+			}
+			*target = uint(id)
+		}
+	}
+
+	for name, target := range map[string]*int{"page": &filter.Page, "page_size": &filter.PageSize} {
+		if value := c.Query(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return filter, errors.New("invalid " + name)
+			}
+			*target = n
+		}
+	}
+	if filter.PageSize > 100 {
+		filter.PageSize = 100
+	}
+	return filter, nil
+}
+
+// respondReviewError maps review service errors to HTTP responses.
+func respondReviewError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "product not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case "review not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+	case "vote not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vote not found"})
+	case "only customers who bought the product can review it", "cannot vote for your own review":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "product already reviewed", "review already voted helpful":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
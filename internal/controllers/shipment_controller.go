@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShipmentController handles HTTP requests related to order shipments.
+type ShipmentController struct {
+	ShipmentService *services.ShipmentService
+}
+
+// NewShipmentController creates a new ShipmentController instance.
+func NewShipmentController(shipmentService *services.ShipmentService) *ShipmentController {
+	return &ShipmentController{ShipmentService: shipmentService}
+}
+
+// createShipmentRequest is the body accepted when recording a shipment.
+type createShipmentRequest struct {
+	Carrier        string                `json:"carrier" binding:"required"`
+	TrackingNumber string                `json:"tracking_number"`
+	Items          []models.ShipmentItem `json:"items"`
+	ShippedAt      *time.Time            `json:"shipped_at"`
+}
+
+// markDeliveredRequest is the optional body accepted when marking a shipment delivered.
+type markDeliveredRequest struct {
+	DeliveredAt *time.Time `json:"delivered_at"`
+}
+
+// CreateShipment records a shipment for an order.
+// @Summary Create a shipment
+// @Description Records a parcel sent for an order with its carrier, tracking number and items. Without items everything not yet shipped is included. The order moves to PartiallyShipped or Shipped (admin only)
+// @Tags Shipments
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param shipment body createShipmentRequest true "Shipment Data"
+// @Success 201 {object} models.Shipment
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 404 {object} gin.H{"error": "Order not found"}
+// @Failure 409 {object} gin.H{"error": "Shipment exceeds the unshipped quantity"}
+// @Router /admin/orders/{id}/shipments [post]
+func (sc *ShipmentController) CreateShipment(c *gin.Context) {
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var request createShipmentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	shipment := models.Shipment{
+		OrderID:        orderID,
+		Carrier:        request.Carrier,
+		TrackingNumber: request.TrackingNumber,
+		Items:          request.Items,
+	}
+	if request.ShippedAt != nil {
+		shipment.ShippedAt = *request.ShippedAt
+	}
+
+	if err := sc.ShipmentService.CreateShipment(&shipment); err != nil {
+		respondShipmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, shipment)
+}
+
+// GetShipments lists the shipments of an order.
+// @Summary List shipments of an order
+// @Description Retrieves the shipments recorded for an order (admin only)
+// @Tags Shipments
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {array} models.Shipment
+// @Failure 400 {object} gin.H{"error": "Invalid order ID"}
+// @Failure 404 {object} gin.H{"error": "Order not found"}
+// @Router /admin/orders/{id}/shipments [get]
+func (sc *ShipmentController) GetShipments(c *gin.Context) {
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	shipments, err := sc.ShipmentService.GetShipmentsByOrder(orderID)
+	if err != nil {
+		respondShipmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shipments)
+}
+
+// MarkDelivered records a shipment as delivered.
+// @Summary Mark a shipment delivered
+// @Description Records the delivery of a shipment, now or at delivered_at. Once every item is delivered the order moves to Completed (admin only)
+// @Tags Shipments
+// @Accept json
+// @Produce json
+// @Param id path int true "Shipment ID"
+// @Param delivery body markDeliveredRequest false "Delivery time"
+// @Success 200 {object} models.Shipment
+// @Failure 400 {object} gin.H{"error": "Invalid shipment ID"}
+// @Failure 404 {object} gin.H{"error": "Shipment not found"}
+// @Failure 409 {object} gin.H{"error": "Shipment has already been delivered"}
+// @Router /admin/shipments/{id}/delivered [put]
+func (sc *ShipmentController) MarkDelivered(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipment ID"})
+		return
+	}
+
+	var request markDeliveredRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+	}
+
+	shipment, err := sc.ShipmentService.MarkDelivered(id, request.DeliveredAt)
+	if err != nil {
+		respondShipmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+// respondShipmentError maps shipment service errors to HTTP responses.
+func respondShipmentError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "order not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+	case "shipment not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shipment not found"})
+	case "shipment exceeds the unshipped quantity", "order has already been shipped in full",
+		"shipment has already been delivered", "cancelled orders cannot be shipped":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
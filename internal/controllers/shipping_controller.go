@@ -0,0 +1,318 @@
+package controllers
+
+import (
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShippingController handles HTTP requests related to shipping zones, methods and rates.
+type ShippingController struct {
+	ShippingService *services.ShippingService
+}
+
+// NewShippingController creates a new ShippingController instance.
+func NewShippingController(shippingService *services.ShippingService) *ShippingController {
+	return &ShippingController{ShippingService: shippingService}
+}
+
+// shippingRatesRequest is the body accepted when quoting shipping rates. The
+// destination is either a saved address or a country and region.
+type shippingRatesRequest struct {
+	Items     []services.CartItem `json:"items" binding:"required"`
+	AddressID uint                `json:"address_id"`
+	Country   string              `json:"country"`
+	Region    string              `json:"region"`
+}
+
+// GetShippingRates quotes the shipping methods available for a cart.
+// @Summary Quote shipping rates
+// @Description Prices the items with every shipping method available for the address, cheapest first. The destination is address_id or a country and region
+// @Tags Checkout
+// @Accept json
+// @Produce json
+// @Param request body shippingRatesRequest true "Items and destination"
+// @Success 200 {object} gin.H{"rates": []shipping.Quote}
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 401 {object} gin.H{"error": "User not authenticated"}
+// @Security ApiKeyAuth
+// @Router /checkout/shipping-rates [post]
+func (sc *ShippingController) GetShippingRates(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var request shippingRatesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	destination := services.Destination{
+		AddressID: request.AddressID,
+		Country:   request.Country,
+		Region:    request.Region,
+	}
+	rates, err := sc.ShippingService.QuoteRates(uid, request.Items, destination)
+	if err != nil {
+		switch err.Error() {
+		case "product not found", "address not found", "at least one item is required",
+			"quantity must be greater than zero", "country or address is required":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not quote shipping rates"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rates": rates})
+}
+
+// CreateZone handles the creation of a new shipping zone.
+// @Summary Create a shipping zone
+// @Description Creates a new shipping zone. Methods are added separately (admin only)
+// @Tags Shipping
+// @Accept json
+// @Produce json
+// @Param zone body models.ShippingZone true "Shipping Zone Data"
+// @Success 201 {object} models.ShippingZone
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Router /admin/shipping/zones [post]
+func (sc *ShippingController) CreateZone(c *gin.Context) {
+	var zone models.ShippingZone
+	if err := c.ShouldBindJSON(&zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	zone.ID = 0
+	zone.Methods = nil
+
+	if err := sc.ShippingService.CreateZone(&zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, zone)
+}
+
+// GetZones retrieves all shipping zones.
+// @Summary Get all shipping zones
+// @Description Retrieves every shipping zone with its methods (admin only)
+// @Tags Shipping
+// @Produce json
+// @Success 200 {array} models.ShippingZone
+// @Failure 500 {object} gin.H{"error": "Could not retrieve shipping zones"}
+// @Router /admin/shipping/zones [get]
+func (sc *ShippingController) GetZones(c *gin.Context) {
+	zones, err := sc.ShippingService.GetZones()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve shipping zones"})
+		return
+	}
+
+	c.JSON(http.StatusOK, zones)
+}
+
+// GetZoneByID retrieves a shipping zone by its ID.
+// @Summary Get a shipping zone by ID
+// @Description Retrieves a shipping zone with its methods (admin only)
+// @Tags Shipping
+// @Produce json
+// @Param id path int true "Zone ID"
+// @Success 200 {object} models.ShippingZone
+// @Failure 400 {object} gin.H{"error": "Invalid zone ID"}
+// @Failure 404 {object} gin.H{"error": "Shipping zone not found"}
+// @Router /admin/shipping/zones/{id} [get]
+func (sc *ShippingController) GetZoneByID(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid zone ID"})
+		return
+	}
+
+	zone, err := sc.ShippingService.GetZoneByID(id)
+	if err != nil {
+		respondShippingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, zone)
+}
+
+// UpdateZone handles the update of a shipping zone.
+// @Summary Update a shipping zone
+// @Description Replaces the name and destinations of a shipping zone. Its methods are not changed (admin only)
+// @Tags Shipping
+// @Accept json
+// @Produce json
+// @Param id path int true "Zone ID"
+// @Param zone body models.ShippingZone true "Updated Shipping Zone Data"
+// @Success 200 {object} models.ShippingZone
+// @Failure 400 {object} gin.H{"error": "Invalid zone ID"}
+// @Failure 404 {object} gin.H{"error": "Shipping zone not found"}
+// @Router /admin/shipping/zones/{id} [put]
+func (sc *ShippingController) UpdateZone(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid zone ID"})
+		return
+	}
+
+	var zone models.ShippingZone
+	if err := c.ShouldBindJSON(&zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	zone.ID = id
+
+	if err := sc.ShippingService.UpdateZone(&zone); err != nil {
+		respondShippingError(c, err)
+		return
+	}
+
+	updated, err := sc.ShippingService.GetZoneByID(id)
+	if err != nil {
+		respondShippingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteZone handles the deletion of a shipping zone.
+// @Summary Delete a shipping zone
+// @Description Deletes a shipping zone and its methods (admin only)
+// @Tags Shipping
+// @Param id path int true "Zone ID"
+// @Success 200 {object} gin.H{"message": "Shipping zone deleted successfully"}
+// @Failure 400 {object} gin.H{"error": "Invalid zone ID"}
+// @Failure 404 {object} gin.H{"error": "Shipping zone not found"}
+// @Router /admin/shipping/zones/{id} [delete]
+func (sc *ShippingController) DeleteZone(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid zone ID"})
+		return
+	}
+
+	if err := sc.ShippingService.DeleteZone(id); err != nil {
+		respondShippingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Shipping zone deleted successfully"})
+}
+
+// CreateMethod handles adding a shipping method to a zone.
+// @Summary Create a shipping method
+// @Description Adds a flat, weight_based or free_over_threshold shipping method to a zone. It is active unless active is false (admin only)
+// @Tags Shipping
+// @Accept json
+// @Produce json
+// @Param id path int true "Zone ID"
+// @Param method body models.ShippingMethod true "Shipping Method Data"
+// @Success 201 {object} models.ShippingMethod
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 404 {object} gin.H{"error": "Shipping zone not found"}
+// @Router /admin/shipping/zones/{id}/methods [post]
+func (sc *ShippingController) CreateMethod(c *gin.Context) {
+	zoneID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid zone ID"})
+		return
+	}
+
+	// A method is active unless the request turns it off
+	method := models.ShippingMethod{Active: true}
+	if err := c.ShouldBindJSON(&method); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	method.ID = 0
+	method.ZoneID = zoneID
+
+	if err := sc.ShippingService.CreateMethod(&method); err != nil {
+		respondShippingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, method)
+}
+
+// UpdateMethod handles the update of a shipping method.
+// @Summary Update a shipping method
+// @Description Replaces a shipping method. It stays in its zone (admin only)
+// @Tags Shipping
+// @Accept json
+// @Produce json
+// @Param id path int true "Method ID"
+// @Param method body models.ShippingMethod true "Updated Shipping Method Data"
+// @Success 200 {object} models.ShippingMethod
+// @Failure 400 {object} gin.H{"error": "Invalid method ID"}
+// @Failure 404 {object} gin.H{"error": "Shipping method not found"}
+// @Router /admin/shipping/methods/{id} [put]
+func (sc *ShippingController) UpdateMethod(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid method ID"})
+		return
+	}
+
+	var method models.ShippingMethod
+	if err := c.ShouldBindJSON(&method); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	method.ID = id
+
+	if err := sc.ShippingService.UpdateMethod(&method); err != nil {
+		respondShippingError(c, err)
+		return
+	}
+
+	updated, err := sc.ShippingService.GetMethodByID(id)
+	if err != nil {
+		respondShippingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteMethod handles the deletion of a shipping method.
+// @Summary Delete a shipping method
+// @Description Deletes a shipping method by its ID (admin only)
+// @Tags Shipping
+// @Param id path int true "Method ID"
+// @Success 200 {object} gin.H{"message": "Shipping method deleted successfully"}
+// @Failure 400 {object} gin.H{"error": "Invalid method ID"}
+// @Failure 404 {object} gin.H{"error": "Shipping method not found"}
+// @Router /admin/shipping/methods/{id} [delete]
+func (sc *ShippingController) DeleteMethod(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid method ID"})
+		return
+	}
+
+	if err := sc.ShippingService.DeleteMethod(id); err != nil {
+		respondShippingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Shipping method deleted successfully"})
+}
+
+// respondShippingError maps shipping service errors to HTTP responses.
+func respondShippingError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "shipping zone not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shipping zone not found"})
+	case "shipping method not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shipping method not found"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/password"
 	"ecommerce-api/internal/services"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -20,33 +22,51 @@ func NewUserController(userService *services.UserService) *UserController {
 	return &UserController{UserService: userService}
 }
 
+// registerUserRequest is the body accepted when signing up. The role and tax
+// exemption are not taken from it; new users are customers who pay tax.
+type registerUserRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// taxExemptRequest is the body accepted when changing a user's tax exemption.
+type taxExemptRequest struct {
+	TaxExempt *bool `json:"tax_exempt" binding:"required"`
+}
+
 // RegisterUser handles user registration
 // @Summary Register a new user
-// @Description Registers a new user in the system
+// @Description Registers a new customer in the system
 // @Accept  json
 // @Produce  json
-// @Param user body models.User true "User Information"
+// @Param user body registerUserRequest true "User Information"
 // @Success 201 {object} gin.H{"message": "User registered successfully"}
-// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 400 {object} gin.H{"error": "password must contain a digit", "rule": "digit"}
 // @Failure 500 {object} gin.H{"error": "Could not create user"}
 // @Router /users/register [post]
 func (uc *UserController) RegisterUser(c *gin.Context) {
-	var user models.User
+	var request registerUserRequest
 
-	// Bind incoming JSON to the user struct
-	if err := c.ShouldBindJSON(&user); err != nil {
+	// Bind incoming JSON to the registration request
+	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Printf("Binding error: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
 
-	// Set default role if not provided
-	if user.Role == "" {
-		user.Role = "user"
+	user := models.User{
+		Role:     "user",
+		Name:     request.Name,
+		Email:    request.Email,
+		Password: request.Password,
 	}
 
 	// Register the user
 	if err := uc.UserService.RegisterUser(&user); err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
 		log.Printf("Error creating user: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Could not create user",
@@ -75,7 +95,7 @@ func (uc *UserController) LoginUser(c *gin.Context) {
 		return
 	}
 
-	token, err := uc.UserService.AuthenticateUser(user.Email, user.Password)
+	token, err := uc.UserService.AuthenticateUser(user.Email, user.Password, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
@@ -95,10 +115,17 @@ func (uc *UserController) LoginUser(c *gin.Context) {
 
 // LogoutUser handles user logout
 // @Summary Log out a user
-// @Description Logs out a user and expires the authentication cookie
+// @Description Logs out a user, revokes the current session and expires the authentication cookie
 // @Success 200 {object} gin.H{"message": "Successfully logged out"}
 // @Router /users/logout [post]
 func (uc *UserController) LogoutUser(c *gin.Context) {
+	// Revoke the session behind the cookie, if there is one
+	if token, err := c.Cookie("access_token"); err == nil {
+		if err := uc.UserService.LogoutToken(token); err != nil {
+			log.Printf("Error revoking session: %v", err)
+		}
+	}
+
 	// Expire the cookie
 	c.SetCookie("access_token", "", -1, "/", "", false, true)
 
@@ -167,12 +194,15 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 
 // DeleteUser deletes a user
 // @Summary Delete a user
-// @Description Deletes a user from the system
+// @Description Soft-deletes a user and logs out all their sessions. Their orders are kept (admin only)
+// @Tags Users
+// @Produce json
 // @Param id path int true "User ID"
 // @Success 200 {object} gin.H{"message": "User deleted successfully"}
 // @Failure 400 {object} gin.H{"error": "Invalid user ID"}
+// @Failure 404 {object} gin.H{"error": "User not found"}
 // @Failure 500 {object} gin.H{"error": "Could not delete user"}
-// @Router /users/{id} [delete]
+// @Router /admin/users/{id} [delete]
 func (uc *UserController) DeleteUser(c *gin.Context) {
 	// Get userID from URL parameter
 	userIDStr := c.Param("id")
@@ -187,9 +217,411 @@ func (uc *UserController) DeleteUser(c *gin.Context) {
 
 	// Call the DeleteUser service function with the converted uint
 	if err := uc.UserService.DeleteUser(uint(userID)); err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete user"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
+
+// GetDeletedUsers lists the soft-deleted users
+// @Summary List deleted users
+// @Description Retrieves the soft-deleted users, most recently deleted first (admin only)
+// @Tags Users
+// @Produce json
+// @Success 200 {array} models.User
+// @Failure 500 {object} gin.H{"error": "Could not retrieve users"}
+// @Router /admin/users/deleted [get]
+func (uc *UserController) GetDeletedUsers(c *gin.Context) {
+	users, err := uc.UserService.GetDeletedUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// RestoreUser brings back a soft-deleted user
+// @Summary Restore a user
+// @Description Restores a soft-deleted user, who can then log in again (admin only)
+// @Tags Users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} gin.H{"message": "User restored successfully"}
+// @Failure 400 {object} gin.H{"error": "Invalid user ID"}
+// @Failure 404 {object} gin.H{"error": "User not found"}
+// @Failure 500 {object} gin.H{"error": "Could not restore user"}
+// @Router /admin/users/{id}/restore [post]
+func (uc *UserController) RestoreUser(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := uc.UserService.RestoreUser(userID); err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not restore user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User restored successfully"})
+}
+
+// SetTaxExempt turns a user's tax exemption on or off
+// @Summary Set tax exemption
+// @Description Sets whether a user is exempt from tax on new orders (admin only)
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body taxExemptRequest true "Tax exemption"
+// @Success 200 {object} gin.H{"message": "Tax exemption updated"}
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 404 {object} gin.H{"error": "User not found"}
+// @Failure 500 {object} gin.H{"error": "Could not update tax exemption"}
+// @Router /admin/users/{id}/tax-exempt [put]
+func (uc *UserController) SetTaxExempt(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var request taxExemptRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := uc.UserService.SetTaxExempt(userID, *request.TaxExempt); err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update tax exemption"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tax exemption updated"})
+}
+
+// ListAddresses lists the authenticated user's saved addresses
+// @Summary List saved addresses
+// @Description Retrieves the addresses saved by the authenticated user
+// @Tags Users
+// @Produce  json
+// @Success 200 {array} models.Address
+// @Failure 401 {object} gin.H{"error": "User not authenticated"}
+// @Failure 500 {object} gin.H{"error": "Could not retrieve addresses"}
+// @Router /users/me/addresses [get]
+func (uc *UserController) ListAddresses(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	addresses, err := uc.UserService.GetAddresses(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve addresses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, addresses)
+}
+
+// AddAddress saves a new address for the authenticated user
+// @Summary Add an address
+// @Description Saves a new postal address for the authenticated user
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param address body models.Address true "Address"
+// @Success 201 {object} models.Address
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 401 {object} gin.H{"error": "User not authenticated"}
+// @Router /users/me/addresses [post]
+func (uc *UserController) AddAddress(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var address models.Address
+	if err := c.ShouldBindJSON(&address); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	address.ID = 0
+	address.UserID = userID
+
+	if err := uc.UserService.AddAddress(&address); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, address)
+}
+
+// DeleteAddress removes one of the authenticated user's addresses
+// @Summary Delete an address
+// @Description Removes a saved address owned by the authenticated user
+// @Tags Users
+// @Param id path int true "Address ID"
+// @Success 200 {object} gin.H{"message": "Address deleted successfully"}
+// @Failure 400 {object} gin.H{"error": "Invalid address ID"}
+// @Failure 404 {object} gin.H{"error": "Address not found"}
+// @Router /users/me/addresses/{id} [delete]
+func (uc *UserController) DeleteAddress(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	addressID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address ID"})
+		return
+	}
+
+	if err := uc.UserService.DeleteAddress(userID, addressID); err != nil {
+		if err.Error() == "address not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete address"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Address deleted successfully"})
+}
+
+// sessionResponse is a login session as shown to its owner.
+type sessionResponse struct {
+	models.Session
+	Current bool `json:"current"`
+}
+
+// ListSessions lists the authenticated user's active logins
+// @Summary List active sessions
+// @Description Retrieves the devices the authenticated user is logged in on
+// @Tags Users
+// @Produce  json
+// @Success 200 {array} sessionResponse
+// @Failure 401 {object} gin.H{"error": "User not authenticated"}
+// @Failure 500 {object} gin.H{"error": "Could not retrieve sessions"}
+// @Router /users/me/sessions [get]
+func (uc *UserController) ListSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	currentSession, _ := currentSessionID(c)
+
+	sessions, err := uc.UserService.GetSessions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve sessions"})
+		return
+	}
+
+	response := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, sessionResponse{Session: session, Current: session.ID == currentSession})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeSession logs out one of the authenticated user's sessions
+// @Summary Revoke a session
+// @Description Logs out the given session of the authenticated user
+// @Tags Users
+// @Param id path int true "Session ID"
+// @Success 200 {object} gin.H{"message": "Session revoked"}
+// @Failure 400 {object} gin.H{"error": "Invalid session ID"}
+// @Failure 404 {object} gin.H{"error": "Session not found"}
+// @Router /users/me/sessions/{id} [delete]
+func (uc *UserController) RevokeSession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	if err := uc.UserService.RevokeSession(userID, sessionID); err != nil {
+		if err.Error() == "session not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not revoke session"})
+		}
+		return
+	}
+
+	if current, ok := currentSessionID(c); ok && current == sessionID {
+		c.SetCookie("access_token", "", -1, "/", "", false, true)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// RevokeOtherSessions logs the authenticated user out everywhere else
+// @Summary Log out everywhere else
+// @Description Revokes every session of the authenticated user except the current one
+// @Tags Users
+// @Produce  json
+// @Success 200 {object} gin.H{"message": "Other sessions revoked", "revoked": 2}
+// @Failure 401 {object} gin.H{"error": "User not authenticated"}
+// @Failure 500 {object} gin.H{"error": "Could not revoke sessions"}
+// @Router /users/me/sessions/revoke-others [post]
+func (uc *UserController) RevokeOtherSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	sessionID, ok := currentSessionID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session not found in context"})
+		return
+	}
+
+	revoked, err := uc.UserService.RevokeOtherSessions(userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked", "revoked": revoked})
+}
+
+// ChangePassword changes the authenticated user's password
+// @Summary Change password
+// @Description Changes the password of the authenticated user and logs out their other sessions
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param body body object{current_password=string,new_password=string} true "Current and new password"
+// @Success 200 {object} gin.H{"message": "Password changed successfully"}
+// @Failure 400 {object} gin.H{"error": "password must contain a digit", "rule": "digit"}
+// @Failure 401 {object} gin.H{"error": "Current password is incorrect"}
+// @Router /users/me/password [put]
+func (uc *UserController) ChangePassword(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	sessionID, _ := currentSessionID(c)
+
+	var body struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := uc.UserService.ChangePassword(userID, sessionID, body.CurrentPassword, body.NewPassword); err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
+		if err.Error() == "current password is incorrect" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not change password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
+
+// RequestPasswordReset sends a password reset token to the user
+// @Summary Request a password reset
+// @Description Sends a single-use password reset token to the email address if an account exists
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param body body object{email=string} true "Account email"
+// @Success 202 {object} gin.H{"message": "If the account exists, a reset token has been sent"}
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Router /users/password-reset [post]
+func (uc *UserController) RequestPasswordReset(c *gin.Context) {
+	var body struct {
+		Email string `json:"email" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := uc.UserService.RequestPasswordReset(c.Request.Context(), body.Email); err != nil {
+		log.Printf("Error requesting password reset: %v", err)
+	}
+
+	// Always answer the same way so accounts cannot be enumerated
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the account exists, a reset token has been sent"})
+}
+
+// ResetPassword sets a new password using a reset token
+// @Summary Reset password
+// @Description Sets a new password using a token from a password reset request and logs out every session
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param body body object{token=string,new_password=string} true "Reset token and new password"
+// @Success 200 {object} gin.H{"message": "Password reset successfully"}
+// @Failure 400 {object} gin.H{"error": "Invalid or expired reset token"}
+// @Router /users/password-reset/confirm [post]
+func (uc *UserController) ResetPassword(c *gin.Context) {
+	var body struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := uc.UserService.ResetPassword(body.Token, body.NewPassword); err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
+		if err.Error() == "invalid or expired reset token" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
+// respondPasswordPolicyError writes a 400 response naming the failed rule if err
+// is a password policy violation, and reports whether it did so.
+func respondPasswordPolicyError(c *gin.Context, err error) bool {
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": policyErr.Message, "rule": policyErr.Rule})
+	return true
+}
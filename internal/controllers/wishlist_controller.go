@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"ecommerce-api/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WishlistController handles HTTP requests related to wishlists.
+type WishlistController struct {
+	WishlistService *services.WishlistService
+}
+
+// NewWishlistController creates a new WishlistController instance.
+func NewWishlistController(wishlistService *services.WishlistService) *WishlistController {
+	return &WishlistController{WishlistService: wishlistService}
+}
+
+// addWishlistItemRequest is the body accepted when saving a product for later.
+type addWishlistItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	// Notify subscribes to back-in-stock and price-drop notifications. Defaults to true.
+	Notify *bool `json:"notify"`
+}
+
+// wishlistNotifyRequest is the body accepted when changing a wishlist subscription.
+type wishlistNotifyRequest struct {
+	Notify *bool `json:"notify" binding:"required"`
+}
+
+// GetWishlist lists the authenticated user's wishlist
+// @Summary List wishlist
+// @Description Retrieves the products the authenticated user saved for later, most recent first
+// @Tags Wishlist
+// @Produce json
+// @Success 200 {array} models.WishlistItem
+// @Failure 401 {object} gin.H{"error": "User not authenticated"}
+// @Failure 500 {object} gin.H{"error": "Could not retrieve wishlist"}
+// @Security ApiKeyAuth
+// @Router /users/me/wishlist [get]
+func (wc *WishlistController) GetWishlist(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	items, err := wc.WishlistService.GetWishlist(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// AddToWishlist saves a product to the authenticated user's wishlist
+// @Summary Add to wishlist
+// @Description Saves a product for later. Unless notify is false the user is notified when it comes back in stock or its price drops
+// @Tags Wishlist
+// @Accept json
+// @Produce json
+// @Param item body addWishlistItemRequest true "Product to save"
+// @Success 201 {object} models.WishlistItem
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 404 {object} gin.H{"error": "Product not found"}
+// @Failure 409 {object} gin.H{"error": "Product already in wishlist"}
+// @Security ApiKeyAuth
+// @Router /users/me/wishlist [post]
+func (wc *WishlistController) AddToWishlist(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var request addWishlistItemRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	notify := request.Notify == nil || *request.Notify
+
+	item, err := wc.WishlistService.AddToWishlist(userID, request.ProductID, notify)
+	if err != nil {
+		respondWishlistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// UpdateWishlistItem changes whether the user is notified about a wishlisted product
+// @Summary Change wishlist notifications
+// @Description Turns back-in-stock and price-drop notifications for a wishlisted product on or off
+// @Tags Wishlist
+// @Accept json
+// @Produce json
+// @Param productId path int true "Product ID"
+// @Param request body wishlistNotifyRequest true "Notification setting"
+// @Success 200 {object} models.WishlistItem
+// @Failure 400 {object} gin.H{"error": "Invalid input"}
+// @Failure 404 {object} gin.H{"error": "Wishlist item not found"}
+// @Security ApiKeyAuth
+// @Router /users/me/wishlist/{productId} [put]
+func (wc *WishlistController) UpdateWishlistItem(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	productID, ok := parseIDParam(c, "productId")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var request wishlistNotifyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	item, err := wc.WishlistService.SetNotify(userID, productID, *request.Notify)
+	if err != nil {
+		respondWishlistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// RemoveFromWishlist removes a product from the authenticated user's wishlist
+// @Summary Remove from wishlist
+// @Description Removes a product from the wishlist and cancels its pending notifications
+// @Tags Wishlist
+// @Param productId path int true "Product ID"
+// @Success 200 {object} gin.H{"message": "Product removed from wishlist"}
+// @Failure 400 {object} gin.H{"error": "Invalid product ID"}
+// @Failure 404 {object} gin.H{"error": "Wishlist item not found"}
+// @Security ApiKeyAuth
+// @Router /users/me/wishlist/{productId} [delete]
+func (wc *WishlistController) RemoveFromWishlist(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	productID, ok := parseIDParam(c, "productId")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	if err := wc.WishlistService.RemoveFromWishlist(userID, productID); err != nil {
+		respondWishlistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Product removed from wishlist"})
+}
+
+// ConfirmUnsubscribe shows what an unsubscribe link is for
+// @Summary Confirm unsubscribing from wishlist notifications
+// @Description Follows the unsubscribe link sent with wishlist notifications without changing anything, so link scanners and prefetching cannot unsubscribe anyone. The product the link is for is returned; a POST to the same address unsubscribes
+// @Tags Wishlist
+// @Produce json
+// @Param token path string true "Unsubscribe token"
+// @Success 200 {object} gin.H{"message": "Send a POST request to this address to stop notifications about this product", "product": string, "notify": bool}
+// @Failure 404 {object} gin.H{"error": "Invalid unsubscribe link"}
+// @Router /wishlist/unsubscribe/{token} [get]
+func (wc *WishlistController) ConfirmUnsubscribe(c *gin.Context) {
+	item, err := wc.WishlistService.GetUnsubscribeItem(c.Param("token"))
+	if err != nil {
+		respondWishlistError(c, err)
+		return
+	}
+
+	response := gin.H{
+		"message": "Send a POST request to this address to stop notifications about this product",
+		"notify":  item.Notify,
+	}
+	if item.Product != nil {
+		response["product"] = item.Product.Name
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Unsubscribe stops wishlist notifications for one product
+// @Summary Unsubscribe from wishlist notifications
+// @Description Turns notifications off for the product an unsubscribe link was sent for, without logging in; the product stays in the wishlist. Also accepts one-click unsubscribe requests (RFC 8058)
+// @Tags Wishlist
+// @Produce json
+// @Param token path string true "Unsubscribe token"
+// @Success 200 {object} gin.H{"message": "You will no longer be notified about this product"}
+// @Failure 404 {object} gin.H{"error": "Invalid unsubscribe link"}
+// @Router /wishlist/unsubscribe/{token} [post]
+func (wc *WishlistController) Unsubscribe(c *gin.Context) {
+	if _, err := wc.WishlistService.Unsubscribe(c.Param("token")); err != nil {
+		respondWishlistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "You will no longer be notified about this product"})
+}
+
+// respondWishlistError maps wishlist service errors to HTTP responses.
+func respondWishlistError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "product not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case "wishlist item not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist item not found"})
+	case "invalid unsubscribe link":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid unsubscribe link"})
+	case "product already in wishlist":
+		c.JSON(http.StatusConflict, gin.H{"error": "Product already in wishlist"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,120 @@
+// Package imaging checks uploaded images and makes thumbnails of them using
+// only the standard library decoders.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// Supported image types.
+const (
+	JPEG = "image/jpeg"
+	PNG  = "image/png"
+	GIF  = "image/gif"
+)
+
+// maxPixels bounds the decoded size of an image, so that a small file
+// claiming huge dimensions cannot exhaust memory.
+const maxPixels = 40_000_000
+
+// DetectType sniffs an image's MIME type from its content. The second result
+// is false for anything but JPEG, PNG and GIF.
+func DetectType(data []byte) (string, bool) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case JPEG, PNG, GIF:
+		return contentType, true
+	}
+	return contentType, false
+}
+
+// Decode decodes an image after checking its dimensions.
+func Decode(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("image could not be read")
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, errors.New("image dimensions are too large")
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("image could not be read")
+	}
+	return img, nil
+}
+
+// Thumbnail scales an image down so that neither side exceeds size, keeping
+// its aspect ratio. Each output pixel is the average of the source pixels
+// it covers. Images already small enough are returned as they are.
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= size && srcH <= size {
+		return img
+	}
+
+	dstW, dstH := size, size
+	if srcW >= srcH {
+		dstH = max(1, srcH*size/srcW)
+	} else {
+		dstW = max(1, srcW*size/srcH)
+	}
+
+	src := image.NewNRGBA(image.Rect(0, 0, srcW, srcH))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+
+	for y := 0; y < dstH; y++ {
+		y0, y1 := y*srcH/dstH, max((y+1)*srcH/dstH, y*srcH/dstH+1)
+		for x := 0; x < dstW; x++ {
+			x0, x1 := x*srcW/dstW, max((x+1)*srcW/dstW, x*srcW/dstW+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					// Weight colour by alpha so transparent pixels do not darken edges
+					alpha := uint64(p[3])
+					r += uint64(p[0]) * alpha
+					g += uint64(p[1]) * alpha
+					b += uint64(p[2]) * alpha
+					a += alpha
+					n++
+				}
+			}
+
+			out := dst.Pix[y*dst.Stride+x*4 : y*dst.Stride+x*4+4]
+			if a > 0 {
+				out[0], out[1], out[2] = uint8(r/a), uint8(g/a), uint8(b/a)
+			}
+			out[3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// Encode encodes a thumbnail. JPEG sources give JPEG thumbnails; PNG and
+// GIF sources give PNG thumbnails so transparency is kept. The second
+// result is the thumbnail's MIME type.
+func Encode(img image.Image, sourceType string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if sourceType == JPEG {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), JPEG, nil
+	}
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), PNG, nil
+}
@@ -0,0 +1,68 @@
+// Package inventory decides which warehouse an order ships from.
+package inventory
+
+import (
+	"ecommerce-api/internal/models"
+	"strings"
+)
+
+// Allocation strategies.
+const (
+	// StrategyNearest ships from the warehouse closest to the destination,
+	// preferring the one with more stock when two are equally close.
+	StrategyNearest = "nearest"
+	// StrategyMostStock ships from the warehouse holding the most stock,
+	// preferring the closer one when two hold the same amount.
+	StrategyMostStock = "most_stock"
+)
+
+// Allocate picks the warehouse that ships quantity units to a destination
+// from the given stock levels, or returns nil if no active warehouse holds
+// enough on its own. Stock levels must have their Warehouse loaded. Ties
+// are broken by the lower warehouse ID so the choice is stable.
+func Allocate(strategy string, levels []models.WarehouseStock, quantity int, country, region string) *models.WarehouseStock {
+	var best *models.WarehouseStock
+	for i := range levels {
+		level := &levels[i]
+		if level.Warehouse == nil || !level.Warehouse.Active || level.Quantity < quantity {
+			continue
+		}
+		if best == nil || better(strategy, level, best, country, region) {
+			best = level
+		}
+	}
+	return best
+}
+
+// better reports whether stock level a should be chosen over b.
+func better(strategy string, a, b *models.WarehouseStock, country, region string) bool {
+	distA, distB := Distance(a.Warehouse, country, region), Distance(b.Warehouse, country, region)
+	if strategy == StrategyMostStock {
+		if a.Quantity != b.Quantity {
+			return a.Quantity > b.Quantity
+		}
+		if distA != distB {
+			return distA < distB
+		}
+	} else {
+		if distA != distB {
+			return distA < distB
+		}
+		if a.Quantity != b.Quantity {
+			return a.Quantity > b.Quantity
+		}
+	}
+	return a.WarehouseID < b.WarehouseID
+}
+
+// Distance ranks how far a warehouse is from a destination without
+// geocoding: 0 in the same region, 1 in the same country and 2 elsewhere.
+func Distance(warehouse *models.Warehouse, country, region string) int {
+	if country == "" || !strings.EqualFold(warehouse.Country, country) {
+		return 2
+	}
+	if region != "" && strings.EqualFold(warehouse.Region, region) {
+		return 0
+	}
+	return 1
+}
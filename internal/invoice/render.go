@@ -0,0 +1,200 @@
+// Package invoice lays out invoices and credit notes as PDF documents.
+package invoice
+
+import (
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/pdf"
+	"fmt"
+	"strings"
+)
+
+// Layout of an A4 page in points.
+const (
+	marginLeft   = 50.0
+	marginRight  = 545.0
+	marginTop    = 790.0
+	marginBottom = 60.0
+	lineHeight   = 14.0
+	fontSize     = 10.0
+)
+
+// Columns of the line item table, given by their right edge.
+const (
+	columnQuantity  = 340.0
+	columnUnitPrice = 440.0
+	columnAmount    = marginRight
+)
+
+// writer places text top to bottom and starts a new page when one is full.
+type writer struct {
+	doc  *pdf.Document
+	page *pdf.Page
+	y    float64
+}
+
+// Render lays out an invoice or credit note and returns the PDF.
+func Render(inv *models.Invoice) []byte {
+	title := "INVOICE"
+	if inv.Kind == models.InvoiceKindCreditNote {
+		title = "CREDIT NOTE"
+	}
+
+	w := &writer{doc: pdf.New(title + " " + inv.Number)}
+	w.newPage()
+
+	w.page.Text(marginLeft, w.y, pdf.HelveticaBold, 20, title)
+	w.y -= 2 * lineHeight
+	w.field("Number", inv.Number)
+	w.field("Date", inv.IssuedAt.Format("2006-01-02"))
+	w.field("Order", fmt.Sprintf("#%d", inv.OrderID))
+	if inv.CreditedInvoiceNumber != "" {
+		w.field("Credits invoice", inv.CreditedInvoiceNumber)
+	}
+	w.y -= lineHeight
+
+	w.parties(inv)
+	w.y -= lineHeight
+
+	// Line items
+	w.room(3)
+	w.page.Text(marginLeft, w.y, pdf.HelveticaBold, fontSize, "Description")
+	w.page.Text(columnQuantity-20, w.y, pdf.HelveticaBold, fontSize, "Qty")
+	w.page.Text(columnUnitPrice-50, w.y, pdf.HelveticaBold, fontSize, "Unit price")
+	w.page.Text(columnAmount-40, w.y, pdf.HelveticaBold, fontSize, "Amount")
+	w.y -= 6
+	w.page.Rule(marginLeft, marginRight, w.y)
+	w.y -= lineHeight
+	for _, line := range inv.Lines {
+		w.room(1)
+		w.page.Text(marginLeft, w.y, pdf.Helvetica, fontSize, line.Description)
+		if line.Quantity != 0 {
+			w.page.TextRight(columnQuantity, w.y, fontSize, fmt.Sprintf("%d", line.Quantity))
+			w.page.TextRight(columnUnitPrice, w.y, fontSize, money(line.UnitPrice))
+		}
+		w.page.TextRight(columnAmount, w.y, fontSize, money(line.Amount))
+		w.y -= lineHeight
+	}
+	w.page.Rule(marginLeft, marginRight, w.y+lineHeight-4)
+	w.y -= lineHeight / 2
+
+	// Tax breakdown
+	if len(inv.TaxBreakdown) > 0 {
+		w.room(2)
+		w.page.Text(marginLeft, w.y, pdf.HelveticaBold, fontSize, "Tax breakdown")
+		w.y -= lineHeight
+		for _, t := range inv.TaxBreakdown {
+			w.room(1)
+			label := fmt.Sprintf("%s %s%% on %s", t.Name, percent(t.Rate), money(t.TaxableAmount))
+			w.page.Text(marginLeft, w.y, pdf.Helvetica, fontSize, label)
+			w.page.TextRight(columnAmount, w.y, fontSize, money(t.TaxAmount))
+			w.y -= lineHeight
+		}
+		w.y -= lineHeight / 2
+	}
+
+	// Totals
+	taxLabel := "Tax"
+	totalLabel := "Total"
+	if inv.PricesIncludeTax {
+		taxLabel = "Included tax"
+		totalLabel = "Total (incl. tax)"
+	}
+	if inv.Kind == models.InvoiceKindCreditNote {
+		totalLabel = "Total credited"
+	}
+	w.total("Subtotal", inv.Subtotal, false)
+	if inv.DiscountTotal != 0 {
+		w.total("Discount", -inv.DiscountTotal, false)
+	}
+	if inv.ShippingTotal != 0 {
+		w.total("Shipping", inv.ShippingTotal, false)
+	}
+	w.total(taxLabel, inv.TaxTotal, false)
+	w.total(totalLabel, inv.GrandTotal, true)
+
+	return w.doc.Bytes()
+}
+
+// newPage starts a new page at the top margin.
+func (w *writer) newPage() {
+	w.page = w.doc.AddPage()
+	w.y = marginTop
+}
+
+// room starts a new page unless the given number of lines still fit.
+func (w *writer) room(lines int) {
+	if w.y-float64(lines-1)*lineHeight < marginBottom {
+		w.newPage()
+	}
+}
+
+// field writes a labelled value in the document header.
+func (w *writer) field(label, value string) {
+	w.page.Text(marginLeft, w.y, pdf.HelveticaBold, fontSize, label+":")
+	w.page.Text(marginLeft+90, w.y, pdf.Helvetica, fontSize, value)
+	w.y -= lineHeight
+}
+
+// parties writes the seller and buyer details side by side.
+func (w *writer) parties(inv *models.Invoice) {
+	seller := append([]string{inv.SellerName}, splitLines(inv.SellerAddress)...)
+	if inv.SellerTaxID != "" {
+		seller = append(seller, "Tax ID: "+inv.SellerTaxID)
+	}
+	buyer := append([]string{inv.BuyerName}, splitLines(inv.BuyerAddress)...)
+	if inv.BuyerEmail != "" {
+		buyer = append(buyer, inv.BuyerEmail)
+	}
+
+	rows := len(seller)
+	if len(buyer) > rows {
+		rows = len(buyer)
+	}
+	w.room(rows + 1)
+	w.page.Text(marginLeft, w.y, pdf.HelveticaBold, fontSize, "From")
+	w.page.Text(320, w.y, pdf.HelveticaBold, fontSize, "Bill to")
+	w.y -= lineHeight
+	for i := 0; i < rows; i++ {
+		if i < len(seller) {
+			w.page.Text(marginLeft, w.y, pdf.Helvetica, fontSize, seller[i])
+		}
+		if i < len(buyer) {
+			w.page.Text(320, w.y, pdf.Helvetica, fontSize, buyer[i])
+		}
+		w.y -= lineHeight
+	}
+}
+
+// total writes one line of the totals block.
+func (w *writer) total(label string, amount float64, bold bool) {
+	w.room(1)
+	font := pdf.Helvetica
+	if bold {
+		font = pdf.HelveticaBold
+	}
+	w.page.Text(columnUnitPrice-50, w.y, font, fontSize, label)
+	w.page.TextRight(columnAmount, w.y, fontSize, money(amount))
+	w.y -= lineHeight
+}
+
+// splitLines splits a multi-line address, dropping empty lines.
+func splitLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// money formats an amount with two decimals.
+func money(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// percent formats a rate fraction as a percentage without needless decimals.
+func percent(rate float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.3f", rate*100), "0")
+	return strings.TrimSuffix(s, ".")
+}
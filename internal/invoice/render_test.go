@@ -0,0 +1,124 @@
+package invoice
+
+import (
+	"bytes"
+	"ecommerce-api/internal/models"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// testInvoice is an order of two lamps with a discount, shipping and two tax rates.
+func testInvoice() *models.Invoice {
+	return &models.Invoice{
+		Kind:          models.InvoiceKindInvoice,
+		Number:        "INV-2026-000041",
+		Year:          2026,
+		Sequence:      41,
+		OrderID:       1207,
+		IssuedAt:      time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC),
+		SellerName:    "Example Shop Ltd",
+		SellerAddress: "1 Market Street\nLondon\n\nEC1A 1AA",
+		SellerTaxID:   "GB123456789",
+		BuyerName:     "Ada Customer",
+		BuyerEmail:    "ada@example.com",
+		BuyerAddress:  "22 Elm Road\nLeeds LS1 4AP",
+		Lines: []models.InvoiceLine{
+			{Description: "Desk lamp (LAMP-1)", Quantity: 2, UnitPrice: 45, Amount: 90},
+			{Description: "Discount SPRING10", Amount: -9},
+			{Description: "Standard shipping", Amount: 5},
+		},
+		TaxBreakdown: []models.InvoiceTax{
+			{Name: "VAT", Rate: 0.2, TaxableAmount: 81, TaxAmount: 16.2},
+			{Name: "VAT shipping", Rate: 0.125, TaxableAmount: 5, TaxAmount: 0.63},
+		},
+		Subtotal:      90,
+		DiscountTotal: 9,
+		ShippingTotal: 5,
+		TaxTotal:      16.83,
+		GrandTotal:    102.83,
+	}
+}
+
+// testCreditNote credits part of testInvoice under the given sequence number.
+func testCreditNote(number string, sequence int, amount, tax float64) *models.Invoice {
+	refundID := uint(sequence)
+	return &models.Invoice{
+		Kind:                  models.InvoiceKindCreditNote,
+		Number:                number,
+		Year:                  2026,
+		Sequence:              sequence,
+		OrderID:               1207,
+		RefundID:              &refundID,
+		CreditedInvoiceNumber: "INV-2026-000041",
+		IssuedAt:              time.Date(2026, 3, 20+sequence, 12, 0, 0, 0, time.UTC),
+		SellerName:            "Example Shop Ltd",
+		SellerAddress:         "1 Market Street\nLondon\nEC1A 1AA",
+		BuyerName:             "Ada Customer",
+		BuyerAddress:          "22 Elm Road\nLeeds LS1 4AP",
+		Lines:                 []models.InvoiceLine{{Description: "Refund for return #88", Amount: amount}},
+		TaxBreakdown:          []models.InvoiceTax{{Name: "VAT", Rate: 0.2, TaxableAmount: amount - tax, TaxAmount: tax}},
+		Subtotal:              amount - tax,
+		TaxTotal:              tax,
+		GrandTotal:            amount,
+	}
+}
+
+func TestRenderMatchesGoldenFiles(t *testing.T) {
+	inclusive := testInvoice()
+	inclusive.Number, inclusive.Sequence = "INV-2026-000042", 42
+	inclusive.PricesIncludeTax = true
+	inclusive.Subtotal, inclusive.GrandTotal = 73.17, 90
+
+	tests := []struct {
+		golden string
+		doc    *models.Invoice
+		// want are texts the document must show, as written in the PDF.
+		want []string
+	}{
+		{"invoice.pdf", testInvoice(), []string{
+			"(INVOICE)", "(INV-2026-000041)", "(#1207)", "(2026-03-14)", "(Tax ID: GB123456789)",
+			"(VAT 20% on 81.00)", "(VAT shipping 12.5% on 5.00)", "(0.63)",
+			"(Subtotal)", "(90.00)", "(Discount)", "(-9.00)", "(Shipping)", "(Tax)", "(16.83)", "(Total)", "(102.83)",
+		}},
+		{"invoice_tax_inclusive.pdf", inclusive, []string{
+			"(INV-2026-000042)", "(Included tax)", "(Total \\(incl. tax\\))", "(73.17)",
+		}},
+		{"credit_note_1.pdf", testCreditNote("CN-2026-000001", 1, 30, 5), []string{
+			"(CREDIT NOTE)", "(CN-2026-000001)", "(Credits invoice:)", "(INV-2026-000041)",
+			"(VAT 20% on 25.00)", "(5.00)", "(Total credited)", "(30.00)",
+		}},
+		{"credit_note_2.pdf", testCreditNote("CN-2026-000002", 2, 12, 2), []string{
+			"(CN-2026-000002)", "(2026-03-22)", "(VAT 20% on 10.00)", "(12.00)",
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.golden, func(t *testing.T) {
+			got := Render(tc.doc)
+			for _, text := range tc.want {
+				if !bytes.Contains(got, []byte(text)) {
+					t.Errorf("document does not show %s", text)
+				}
+			}
+
+			path := filepath.Join("testdata", tc.golden)
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("rendered document differs from %s (run go test with -update to accept the change)", path)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"context"
+	"ecommerce-api/internal/logger"
+	"fmt"
+	"time"
+)
+
+// IdempotencyKeyPurger deletes stored idempotency keys.
+type IdempotencyKeyPurger interface {
+	DeleteOlderThan(cutoff time.Time) (int64, error)
+}
+
+// PurgeIdempotencyKeys returns a task that removes idempotency keys older than ttl.
+func PurgeIdempotencyKeys(purger IdempotencyKeyPurger, ttl time.Duration) Task {
+	return func(ctx context.Context) error {
+		deleted, err := purger.DeleteOlderThan(time.Now().Add(-ttl))
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			logger.Info(fmt.Sprintf("purged %d idempotency keys", deleted))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,7 @@
+package jobs
+
+import "expvar"
+
+// metrics holds the counters of the background jobs, published with the
+// other expvar variables. Keys are prefixed with the job name.
+var metrics = expvar.NewMap("jobs")
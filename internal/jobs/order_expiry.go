@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"ecommerce-api/internal/logger"
+	"fmt"
+	"time"
+)
+
+// PendingOrderExpirer cancels orders left unpaid for too long.
+type PendingOrderExpirer interface {
+	ExpirePendingOrders(ctx context.Context, timeout time.Duration) (int, error)
+}
+
+// ExpirePendingOrders returns a task that cancels orders still pending after
+// timeout. The number of expired orders is counted in the order-expiry.expired metric.
+func ExpirePendingOrders(expirer PendingOrderExpirer, timeout time.Duration) Task {
+	return func(ctx context.Context) error {
+		expired, err := expirer.ExpirePendingOrders(ctx, timeout)
+		metrics.Add("order-expiry.expired", int64(expired))
+		if expired > 0 {
+			logger.Info(fmt.Sprintf("expired %d unpaid orders", expired))
+		}
+		return err
+	}
+}
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"context"
+	"ecommerce-api/internal/logger"
+	"sync"
+	"time"
+)
+
+// Task is a unit of background work run by the Scheduler.
+type Task func(ctx context.Context) error
+
+// scheduledTask pairs a task with its name and run interval.
+type scheduledTask struct {
+	name     string
+	interval time.Duration
+	task     Task
+}
+
+// Scheduler runs registered tasks periodically in the service process.
+type Scheduler struct {
+	tasks []scheduledTask
+	wg    sync.WaitGroup
+}
+
+// NewScheduler creates a new Scheduler instance.
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers a task to run once per interval. Tasks must be registered before Start.
+func (s *Scheduler) Every(name string, interval time.Duration, task Task) {
+	s.tasks = append(s.tasks, scheduledTask{name: name, interval: interval, task: task})
+}
+
+// Start launches one goroutine per task. Each task runs immediately and then on
+// every tick until the context is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go func(t scheduledTask) {
+			defer s.wg.Done()
+			ticker := time.NewTicker(t.interval)
+			defer ticker.Stop()
+
+			for {
+				s.run(ctx, t)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(t)
+	}
+}
+
+// Wait blocks until every task goroutine has returned.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// run executes a task once, logging errors and recovering from panics so that
+// one failing task does not take down the process. Runs and failures are
+// counted in the job metrics.
+func (s *Scheduler) run(ctx context.Context, t scheduledTask) {
+	defer func() {
+		if r := recover(); r != nil {
+			metrics.Add(t.name+".failures", 1)
+			logger.Error("job " + t.name + " panicked")
+		}
+	}()
+
+	metrics.Add(t.name+".runs", 1)
+	if err := t.task(ctx); err != nil {
+		metrics.Add(t.name+".failures", 1)
+		logger.Error("job " + t.name + " failed: " + err.Error())
+	}
+}
@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// Locker runs work while holding a lock shared by every replica of the service.
+type Locker interface {
+	TryWithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error)
+}
+
+// Singleton returns a task that runs task only in the replica that holds the
+// lock for name. Other replicas skip the run.
+func Singleton(locker Locker, name string, task Task) Task {
+	h := fnv.New64a()
+	h.Write([]byte("jobs:" + name))
+	key := int64(h.Sum64())
+
+	return func(ctx context.Context) error {
+		acquired, err := locker.TryWithLock(ctx, key, func(ctx context.Context) error {
+			return task(ctx)
+		})
+		if err == nil && !acquired {
+			metrics.Add(name+".lock_skipped", 1)
+		}
+		return err
+	}
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"ecommerce-api/internal/logger"
+	"fmt"
+	"time"
+)
+
+// SoftDeletePurger permanently removes soft-deleted rows.
+type SoftDeletePurger interface {
+	PurgeDeletedOrders(ctx context.Context, cutoff time.Time) (int64, error)
+	PurgeDeletedUsers(ctx context.Context, cutoff time.Time) (int64, error)
+	PurgeDeletedProducts(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// PurgeSoftDeleted returns a task that permanently removes orders, users and
+// products soft-deleted more than retention ago. Orders go first because
+// users and products are kept while an order still refers to them. The
+// number of purged rows is counted in the soft-delete-purge.<table> metrics.
+func PurgeSoftDeleted(purger SoftDeletePurger, retention time.Duration) Task {
+	return func(ctx context.Context) error {
+		cutoff := time.Now().Add(-retention)
+		steps := []struct {
+			table string
+			purge func(ctx context.Context, cutoff time.Time) (int64, error)
+		}{
+			{"orders", purger.PurgeDeletedOrders},
+			{"users", purger.PurgeDeletedUsers},
+			{"products", purger.PurgeDeletedProducts},
+		}
+		for _, step := range steps {
+			purged, err := step.purge(ctx, cutoff)
+			if err != nil {
+				return fmt.Errorf("purging %s: %w", step.table, err)
+			}
+			metrics.Add("soft-delete-purge."+step.table, purged)
+			if purged > 0 {
+				logger.Info(fmt.Sprintf("purged %d deleted %s", purged, step.table))
+			}
+		}
+		return nil
+	}
+}
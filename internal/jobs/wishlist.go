@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"ecommerce-api/internal/logger"
+	"fmt"
+)
+
+// WishlistDigestSender sends the pending wishlist notifications.
+type WishlistDigestSender interface {
+	SendWishlistDigests(ctx context.Context) (int, error)
+}
+
+// SendWishlistDigests returns a task that sends the pending wishlist
+// notifications as one digest per user. The number of digests sent is
+// counted in the wishlist-digest.sent metric.
+func SendWishlistDigests(sender WishlistDigestSender) Task {
+	return func(ctx context.Context) error {
+		sent, err := sender.SendWishlistDigests(ctx)
+		metrics.Add("wishlist-digest.sent", int64(sent))
+		if sent > 0 {
+			logger.Info(fmt.Sprintf("sent %d wishlist digests", sent))
+		}
+		return err
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"ecommerce-api/internal/models"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyHeader is the request header carrying the client's idempotency key.
+const IdempotencyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength is the longest key accepted from clients.
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyStore claims and completes idempotency keys.
+type IdempotencyStore interface {
+	Begin(userID uint, key, requestHash string) (*models.IdempotencyKey, bool, error)
+	Complete(id uint, code int, contentType string, body []byte) error
+	Release(id uint) error
+}
+
+// responseRecorder copies everything written to the response so it can be stored.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write writes to the client and to the recorded body.
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes to the client and to the recorded body.
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes requests carrying an Idempotency-Key header safe to retry.
+// The first request with a key runs normally and its response is stored per
+// user. Replays with the same body get the stored response; reusing the key
+// with a different body gets 422, and a replay while the first request is
+// still running gets 409. The key is released when the request fails with a
+// server error or panics, so it can be retried. Requests without the header
+// are not affected.
+// It must run after JWTMiddleware.
+func Idempotency(store IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		userID, err := strconv.ParseUint(c.GetString("userID"), 10, 32)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Could not read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		record, claimed, err := store.Begin(uint(userID), key, requestHash)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Could not process idempotency key"})
+			return
+		}
+
+		if !claimed {
+			switch {
+			case record.RequestHash != requestHash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+			case record.Status == models.IdempotencyStatusInProgress:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(record.ResponseCode, record.ResponseType, record.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		// A handler that panics never finishes, so its key is released for
+		// a retry before the panic carries on to the recovery middleware
+		defer func() {
+			if r := recover(); r != nil {
+				if err := store.Release(record.ID); err != nil {
+					log.Printf("Error releasing idempotency key %d: %v", record.ID, err)
+				}
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors are not stored so the client can retry with the same key
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Release(record.ID); err != nil {
+				log.Printf("Error releasing idempotency key %d: %v", record.ID, err)
+			}
+			return
+		}
+
+		if err := store.Complete(record.ID, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			log.Printf("Error storing idempotent response %d: %v", record.ID, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"ecommerce-api/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeIdempotencyStore claims every key and records what happened to it.
+type fakeIdempotencyStore struct {
+	released, completed []uint
+}
+
+func (s *fakeIdempotencyStore) Begin(userID uint, key, requestHash string) (*models.IdempotencyKey, bool, error) {
+	return &models.IdempotencyKey{ID: 7, RequestHash: requestHash, Status: models.IdempotencyStatusInProgress}, true, nil
+}
+
+func (s *fakeIdempotencyStore) Complete(id uint, code int, contentType string, body []byte) error {
+	s.completed = append(s.completed, id)
+	return nil
+}
+
+func (s *fakeIdempotencyStore) Release(id uint) error {
+	s.released = append(s.released, id)
+	return nil
+}
+
+func TestIdempotencyReleasesKeyWhenHandlerPanics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &fakeIdempotencyStore{}
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(func(c *gin.Context) { c.Set("userID", "3") })
+	router.POST("/orders", Idempotency(store), func(c *gin.Context) {
+		panic("boom")
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"product_id":1}`))
+	request.Header.Set(IdempotencyHeader, "retry-me")
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	if response.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d, want the panic to reach the recovery middleware", response.Code)
+	}
+	if len(store.released) != 1 || store.released[0] != 7 {
+		t.Errorf("got released keys %v, want [7]", store.released)
+	}
+	if len(store.completed) != 0 {
+		t.Errorf("got completed keys %v, want none", store.completed)
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Address represents a postal address saved by a user.
+type Address struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"not null;index"`
+	FullName   string    `json:"full_name" gorm:"not null"`
+	Line1      string    `json:"line1" gorm:"not null"`
+	Line2      string    `json:"line2"`
+	City       string    `json:"city" gorm:"not null"`
+	Region     string    `json:"region"`
+	PostalCode string    `json:"postal_code"`
+	Country    string    `json:"country" gorm:"not null"`
+	Phone      string    `json:"phone"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// AuditLog records a security or compliance relevant action.
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ActorID    uint      `json:"actor_id" gorm:"index"`
+	Action     string    `json:"action" gorm:"not null;index"`
+	TargetType string    `json:"target_type"`
+	TargetID   uint      `json:"target_id"`
+	Details    string    `json:"details"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Audit actions.
+const (
+	AuditActionUserErasureRequested = "user.erasure_requested"
+	AuditActionUserErased           = "user.erased"
+	AuditActionImpersonationStarted = "admin.impersonation_started"
+	AuditActionImpersonatedRequest  = "admin.impersonated_request"
+)
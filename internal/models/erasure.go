@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ErasureRequest tracks a user's right-to-be-forgotten request until the
+// background job has anonymised their personal data.
+type ErasureRequest struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"not null;default:'Pending'"`
+	Error       string     `json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ErasureStatus represents the possible statuses of an erasure request.
+const (
+	ErasureStatusPending   = "Pending"
+	ErasureStatusCompleted = "Completed"
+	ErasureStatusFailed    = "Failed"
+)
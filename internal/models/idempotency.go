@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// IdempotencyKey stores the outcome of a request sent with an Idempotency-Key
+// header so that retries of the same request get the same response.
+type IdempotencyKey struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	Key          string    `json:"key" gorm:"not null;size:255;uniqueIndex:idx_idempotency_user_key"`
+	RequestHash  string    `json:"request_hash" gorm:"not null"`
+	Status       string    `json:"status" gorm:"not null"`
+	ResponseCode int       `json:"response_code"`
+	ResponseType string    `json:"response_type"`
+	ResponseBody []byte    `json:"-"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// IdempotencyStatus represents the possible states of an idempotency key.
+const (
+	IdempotencyStatusInProgress = "InProgress"
+	IdempotencyStatusCompleted  = "Completed"
+)
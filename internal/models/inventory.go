@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// StockMovement is an entry in the inventory ledger. Every change to a
+// product's stock is recorded as one, together with the stock level after it.
+type StockMovement struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	ProductID uint `json:"product_id" gorm:"not null;index"`
+	// Change is positive when stock comes in and negative when it goes out.
+	Change     int    `json:"change" gorm:"not null"`
+	StockAfter int    `json:"stock_after" gorm:"not null"`
+	Reason     string `json:"reason" gorm:"not null"`
+	OrderID    *uint  `json:"order_id" gorm:"index"`
+	// WarehouseID is the location whose stock changed, or nil for stock not
+	// yet assigned to a warehouse. LocationStockAfter is that location's
+	// stock level after the change.
+	WarehouseID        *uint `json:"warehouse_id" gorm:"index"`
+	LocationStockAfter *int  `json:"location_stock_after,omitempty"`
+	// ActorID is the user who made the change, or zero for the system.
+	ActorID   uint      `json:"actor_id"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// StockMovementReason represents why stock changed.
+const (
+	StockReasonSale       = "sale"
+	StockReasonCancel     = "cancel"
+	StockReasonRestock    = "restock"
+	StockReasonAdjustment = "adjustment"
+	StockReasonReturn     = "return"
+	StockReasonTransfer   = "transfer"
+)
+
+// Warehouse is a location that holds stock and ships orders.
+type Warehouse struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"not null"`
+	Code string `json:"code" gorm:"not null;uniqueIndex"`
+	// The warehouse address is used to find the one nearest to a customer.
+	City       string    `json:"city"`
+	Region     string    `json:"region"`
+	PostalCode string    `json:"postal_code"`
+	Country    string    `json:"country" gorm:"not null"`
+	Active     bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WarehouseStock is the stock of one product held at one warehouse. The
+// product's Stock is the total over all warehouses plus any stock not yet
+// assigned to one.
+type WarehouseStock struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	WarehouseID uint       `json:"warehouse_id" gorm:"not null;uniqueIndex:idx_warehouse_product"`
+	ProductID   uint       `json:"product_id" gorm:"not null;uniqueIndex:idx_warehouse_product;index"`
+	Quantity    int        `json:"quantity" gorm:"not null;default:0"`
+	Warehouse   *Warehouse `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ProductStock is the breakdown of a product's stock by location.
+type ProductStock struct {
+	ProductID  uint             `json:"product_id"`
+	Total      int              `json:"total"`
+	Unassigned int              `json:"unassigned"`
+	Locations  []WarehouseStock `json:"locations"`
+}
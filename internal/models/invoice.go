@@ -0,0 +1,86 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invoice is an issued invoice or credit note. It keeps a snapshot of every
+// detail printed on it together with the rendered PDF, and cannot be changed
+// or deleted once stored.
+type Invoice struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Kind string `json:"kind" gorm:"not null;uniqueIndex:idx_invoice_sequence"`
+	// Number is the printed number, made of a prefix, the year and Sequence.
+	Number   string `json:"number" gorm:"not null;uniqueIndex"`
+	Year     int    `json:"year" gorm:"not null;uniqueIndex:idx_invoice_sequence"`
+	Sequence int    `json:"sequence" gorm:"not null;uniqueIndex:idx_invoice_sequence"`
+	OrderID  uint   `json:"order_id" gorm:"not null;index"`
+	// RefundID and CreditedInvoiceNumber are set on credit notes.
+	RefundID              *uint     `json:"refund_id" gorm:"uniqueIndex"`
+	CreditedInvoiceNumber string    `json:"credited_invoice_number"`
+	IssuedAt              time.Time `json:"issued_at" gorm:"not null"`
+
+	SellerName    string `json:"seller_name"`
+	SellerAddress string `json:"seller_address"`
+	SellerTaxID   string `json:"seller_tax_id"`
+	BuyerName     string `json:"buyer_name"`
+	BuyerEmail    string `json:"buyer_email"`
+	BuyerAddress  string `json:"buyer_address"`
+
+	Lines            []InvoiceLine `json:"lines" gorm:"serializer:json"`
+	TaxBreakdown     []InvoiceTax  `json:"tax_breakdown" gorm:"serializer:json"`
+	PricesIncludeTax bool          `json:"prices_include_tax"`
+	Subtotal         float64       `json:"subtotal"`
+	DiscountTotal    float64       `json:"discount_total"`
+	ShippingTotal    float64       `json:"shipping_total"`
+	TaxTotal         float64       `json:"tax_total"`
+	GrandTotal       float64       `json:"grand_total"`
+
+	PDF       []byte    `json:"-"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// InvoiceLine is a line printed on an invoice.
+type InvoiceLine struct {
+	Description string  `json:"description"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+}
+
+// InvoiceTax is the tax charged at one rate on an invoice.
+type InvoiceTax struct {
+	Name          string  `json:"name"`
+	Rate          float64 `json:"rate"`
+	TaxableAmount float64 `json:"taxable_amount"`
+	TaxAmount     float64 `json:"tax_amount"`
+}
+
+// InvoiceKind represents the documents numbered in their own sequence.
+const (
+	InvoiceKindInvoice    = "invoice"
+	InvoiceKindCreditNote = "credit_note"
+)
+
+// errInvoiceImmutable is returned when an issued invoice would be changed.
+var errInvoiceImmutable = errors.New("issued invoices cannot be changed")
+
+// BeforeUpdate keeps issued invoices from being changed.
+func (i *Invoice) BeforeUpdate(tx *gorm.DB) error {
+	return errInvoiceImmutable
+}
+
+// BeforeDelete keeps issued invoices from being deleted.
+func (i *Invoice) BeforeDelete(tx *gorm.DB) error {
+	return errInvoiceImmutable
+}
+
+// InvoiceSequence holds the last number used for a kind of document in a year.
+type InvoiceSequence struct {
+	Kind       string `gorm:"primaryKey"`
+	Year       int    `gorm:"primaryKey;autoIncrement:false"`
+	LastNumber int    `gorm:"not null;default:0"`
+}
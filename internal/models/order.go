@@ -2,22 +2,131 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Order represents an order in the e-commerce application.
+// Amounts are calculated by the server when the order is placed and never
+// change afterwards, so later price changes do not rewrite order history.
 type Order struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	UserID    uint      `json:"user_id" gorm:"not null"`
-	ProductID uint      `json:"product_id" gorm:"not null"`
-	Quantity  int       `json:"quantity" gorm:"not null"`
-	Status    string    `json:"status" gorm:"not null;default:'Pending'"`
+	ID            uint    `json:"id" gorm:"primaryKey"`
+	UserID        uint    `json:"user_id" gorm:"not null"`
+	ProductID     uint    `json:"product_id" gorm:"not null"`
+	Quantity      int     `json:"quantity" gorm:"not null"`
+	Status        string  `json:"status" gorm:"not null;default:'Pending'"`
+	UnitPrice     float64 `json:"unit_price" gorm:"not null;default:0"`
+	Subtotal      float64 `json:"subtotal" gorm:"not null;default:0"`
+	DiscountTotal float64 `json:"discount_total" gorm:"not null;default:0"`
+	TaxTotal      float64 `json:"tax_total" gorm:"not null;default:0"`
+	ShippingTotal float64 `json:"shipping_total" gorm:"not null;default:0"`
+	GrandTotal    float64 `json:"grand_total" gorm:"not null;default:0"`
+	// RefundedTotal is the part of GrandTotal paid back, or being paid back,
+	// through refunds. Failed refunds are not counted.
+	RefundedTotal float64 `json:"refunded_total" gorm:"not null;default:0"`
+	// PaidAt is set when payment for the order was received. Paid orders
+	// never expire.
+	PaidAt *time.Time `json:"paid_at,omitempty"`
+	// Version is incremented by every update so concurrent edits can be detected.
+	Version   int       `json:"version" gorm:"not null;default:1"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	// DeletedAt is set when the order is soft-deleted by an admin.
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// PricesIncludeTax reports whether UnitPrice and Subtotal already contain
+	// TaxTotal, in which case it is not added again to GrandTotal.
+	PricesIncludeTax bool `json:"prices_include_tax" gorm:"not null;default:false"`
+
+	// Shipping address snapshot, taken when the order is placed.
+	ShippingName       string `json:"shipping_name"`
+	ShippingLine1      string `json:"shipping_line1"`
+	ShippingLine2      string `json:"shipping_line2"`
+	ShippingCity       string `json:"shipping_city"`
+	ShippingRegion     string `json:"shipping_region"`
+	ShippingPostalCode string `json:"shipping_postal_code"`
+	ShippingCountry    string `json:"shipping_country"`
+
+	// ShippingMethodID and ShippingMethodName record the shipping method
+	// chosen at checkout. ShippingTotal holds its cost.
+	ShippingMethodID   *uint  `json:"shipping_method_id"`
+	ShippingMethodName string `json:"shipping_method_name"`
+
+	// WarehouseID is the warehouse the order ships from, or nil when its
+	// stock was not assigned to a warehouse.
+	WarehouseID *uint `json:"warehouse_id" gorm:"index"`
+
+	// Redemptions lists the promotion codes applied to the order.
+	Redemptions []PromotionRedemption `json:"redemptions,omitempty" gorm:"foreignKey:OrderID"`
+	// TaxLines breaks TaxTotal down by taxed line.
+	TaxLines []OrderTaxLine `json:"tax_lines,omitempty" gorm:"foreignKey:OrderID"`
+	// Shipments lists the parcels sent for the order.
+	Shipments []Shipment `json:"shipments,omitempty" gorm:"foreignKey:OrderID"`
+	// Refunds lists the money paid back for the order.
+	Refunds []Refund `json:"refunds,omitempty" gorm:"foreignKey:OrderID"`
+	// History lists the status changes of the order, oldest first.
+	History []OrderEvent `json:"history,omitempty" gorm:"foreignKey:OrderID"`
 }
 
+// OrderEvent records a status change of an order and why it happened.
+type OrderEvent struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	OrderID    uint   `json:"order_id" gorm:"not null;index"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status" gorm:"not null"`
+	// ActorID is the user who made the change, or zero for the system.
+	ActorID   uint      `json:"actor_id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// OrderTaxLine records the tax charged on one line of an order.
+type OrderTaxLine struct {
+	ID            uint    `json:"id" gorm:"primaryKey"`
+	OrderID       uint    `json:"order_id" gorm:"not null;index"`
+	Line          string  `json:"line" gorm:"not null"`
+	TaxClass      string  `json:"tax_class"`
+	RateName      string  `json:"rate_name"`
+	Rate          float64 `json:"rate" gorm:"not null"`
+	TaxableAmount float64 `json:"taxable_amount" gorm:"not null"`
+	TaxAmount     float64 `json:"tax_amount" gorm:"not null"`
+}
+
+// Order line references used in OrderTaxLine.Line and PromotionRedemption.Line.
+const (
+	OrderLineProduct  = "product"
+	OrderLineShipping = "shipping"
+)
+
 // OrderStatus represents the possible statuses of an order.
 const (
-	OrderStatusPending   = "Pending"
-	OrderStatusCompleted = "Completed"
-	OrderStatusCancelled = "Cancelled"
+	OrderStatusPending          = "Pending"
+	OrderStatusPartiallyShipped = "PartiallyShipped"
+	OrderStatusShipped          = "Shipped"
+	OrderStatusCompleted        = "Completed"
+	OrderStatusCancelled        = "Cancelled"
 )
+
+// orderTransitions lists the statuses an order may move to from each status.
+// Cancelled and completed orders are final and no order goes back to
+// pending, so reserved stock is released at most once and stock that has
+// left the warehouse is never put back by a status change.
+var orderTransitions = map[string][]string{
+	OrderStatusPending:          {OrderStatusPartiallyShipped, OrderStatusShipped, OrderStatusCompleted, OrderStatusCancelled},
+	OrderStatusPartiallyShipped: {OrderStatusShipped, OrderStatusCompleted},
+	OrderStatusShipped:          {OrderStatusCompleted},
+}
+
+// CanTransitionOrder reports whether an order may move from one status to another.
+func CanTransitionOrder(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenOrderStatuses are the statuses of orders that are still being
+// fulfilled. Products and orders cannot be deleted while such orders exist.
+var OpenOrderStatuses = []string{OrderStatusPending, OrderStatusPartiallyShipped, OrderStatusShipped}
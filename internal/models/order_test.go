@@ -0,0 +1,44 @@
+package models
+
+import "testing"
+
+func TestCanTransitionOrder(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{OrderStatusPending, OrderStatusCancelled, true},
+		{OrderStatusPending, OrderStatusShipped, true},
+		{OrderStatusPartiallyShipped, OrderStatusShipped, true},
+		{OrderStatusShipped, OrderStatusCompleted, true},
+		{OrderStatusCancelled, OrderStatusPending, false},
+		{OrderStatusCancelled, OrderStatusShipped, false},
+		{OrderStatusShipped, OrderStatusPending, false},
+		{OrderStatusShipped, OrderStatusCancelled, false},
+		{OrderStatusPartiallyShipped, OrderStatusPending, false},
+		{OrderStatusPartiallyShipped, OrderStatusCancelled, false},
+		{OrderStatusCompleted, OrderStatusPending, false},
+		{OrderStatusCompleted, OrderStatusCancelled, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransitionOrder(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionOrder(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+// Stock is released when a pending order is cancelled, so an order must not
+// be able to reach Pending again and be cancelled a second time.
+func TestCancelledOrderCannotReleaseStockTwice(t *testing.T) {
+	for _, start := range []string{OrderStatusCancelled, OrderStatusShipped, OrderStatusCompleted} {
+		status := start
+		for _, next := range []string{OrderStatusPending, OrderStatusCancelled} {
+			if CanTransitionOrder(status, next) {
+				status = next
+			}
+		}
+		if status != start {
+			t.Errorf("order starting %s moved to %s, want it to stay %s", start, status, start)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PasswordResetToken is a single-use token that lets a user choose a new password.
+// Only a hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
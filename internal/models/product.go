@@ -1,14 +1,36 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Product represents the structure of a product in the e-commerce application.
 type Product struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"not null"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price" gorm:"not null"`
-	Stock       int       `json:"stock" gorm:"not null"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID uint `json:"id" gorm:"primaryKey"`
+	// SKU is the merchant's stock keeping unit. It is optional, but unique when set.
+	SKU         string  `json:"sku" gorm:"not null;default:'';uniqueIndex:idx_product_sku,where:sku <> ''"`
+	Name        string  `json:"name" gorm:"not null"`
+	Description string  `json:"description"`
+	Category    string  `json:"category" gorm:"index"`
+	TaxClass    string  `json:"tax_class" gorm:"not null;default:'standard'"`
+	Price       float64 `json:"price" gorm:"not null"`
+	Stock       int     `json:"stock" gorm:"not null"`
+	// ReorderThreshold raises a low-stock alert when stock falls below it; 0 disables it.
+	ReorderThreshold int `json:"reorder_threshold" gorm:"not null;default:0"`
+	// Weight is in kilograms and the dimensions in centimetres. They are used
+	// to work out shipping costs.
+	Weight float64 `json:"weight" gorm:"not null;default:0"`
+	Length float64 `json:"length" gorm:"not null;default:0"`
+	Width  float64 `json:"width" gorm:"not null;default:0"`
+	Height float64 `json:"height" gorm:"not null;default:0"`
+	// Version is incremented by every update so concurrent edits can be
+	// detected. Stock changes go through the inventory ledger and leave it alone.
+	Version   int       `json:"version" gorm:"not null;default:1"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is set when the product is soft-deleted. Deleted products
+	// keep their SKU until they are purged.
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
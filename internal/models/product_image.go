@@ -0,0 +1,87 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ProductImage is a picture of a product kept in blob storage. Images are
+// shown in SortOrder, lowest first.
+type ProductImage struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	ProductID   uint   `json:"product_id" gorm:"not null;index"`
+	Key         string `json:"-" gorm:"not null"`
+	ContentType string `json:"content_type" gorm:"not null"`
+	Size        int64  `json:"size" gorm:"not null"`
+	Width       int    `json:"width" gorm:"not null"`
+	Height      int    `json:"height" gorm:"not null"`
+	AltText     string `json:"alt_text"`
+	SortOrder   int    `json:"sort_order" gorm:"not null;default:0"`
+	// Thumbnails lists the resized copies made when the image was uploaded.
+	Thumbnails ImageThumbnails `json:"thumbnails" gorm:"type:text"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// ImageThumbnail is a resized copy of a product image.
+type ImageThumbnail struct {
+	Name        string `json:"name"`
+	Key         string `json:"-"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// ImageThumbnails is stored as a JSON column. Unlike in API responses, the
+// stored form keeps each thumbnail's blob key.
+type ImageThumbnails []ImageThumbnail
+
+// storedThumbnail is the database form of an ImageThumbnail.
+type storedThumbnail struct {
+	Name        string `json:"name"`
+	Key         string `json:"key"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// Value implements driver.Valuer.
+func (t ImageThumbnails) Value() (driver.Value, error) {
+	stored := make([]storedThumbnail, len(t))
+	for i, thumbnail := range t {
+		stored[i] = storedThumbnail(thumbnail)
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner.
+func (t *ImageThumbnails) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into image thumbnails", value)
+	}
+	var stored []storedThumbnail
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	thumbnails := make(ImageThumbnails, len(stored))
+	for i, thumbnail := range stored {
+		thumbnails[i] = ImageThumbnail(thumbnail)
+	}
+	*t = thumbnails
+	return nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestImageThumbnailsKeepKeysInDatabaseOnly(t *testing.T) {
+	thumbnails := ImageThumbnails{{Name: "small", Key: "products/1/abc/small.png", ContentType: "image/png", Width: 200, Height: 100}}
+
+	value, err := thumbnails.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scanned ImageThumbnails
+	if err := scanned.Scan([]byte(value.(string))); err != nil {
+		t.Fatal(err)
+	}
+	if len(scanned) != 1 || scanned[0] != thumbnails[0] {
+		t.Errorf("got %+v after a database round trip, want %+v", scanned, thumbnails)
+	}
+
+	response, err := json.Marshal(ProductImage{Key: "products/1/abc/original.png", Thumbnails: thumbnails})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(response), "products/1/abc") {
+		t.Errorf("API response exposes blob keys: %s", response)
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Promotion is a discount code used in marketing campaigns.
+type Promotion struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Code        string `json:"code" gorm:"not null;uniqueIndex"`
+	Description string `json:"description"`
+	Type        string `json:"type" gorm:"not null"`
+	// Value is the percentage for PromotionTypePercentage and the amount for
+	// PromotionTypeFixedAmount. It is unused by the other types.
+	Value float64 `json:"value"`
+	// BuyQuantity and GetQuantity configure PromotionTypeBuyXGetY: for every
+	// BuyQuantity items paid for, GetQuantity more are free.
+	BuyQuantity   int     `json:"buy_quantity"`
+	GetQuantity   int     `json:"get_quantity"`
+	MinOrderValue float64 `json:"min_order_value"`
+	// EligibleProductIDs and EligibleCategories restrict the promotion to some
+	// products. When both are empty every product is eligible.
+	EligibleProductIDs []uint   `json:"eligible_product_ids" gorm:"serializer:json"`
+	EligibleCategories []string `json:"eligible_categories" gorm:"serializer:json"`
+	// UsageLimit caps redemptions across all users and PerUserLimit caps them
+	// per user. Zero means unlimited.
+	UsageLimit   int        `json:"usage_limit"`
+	PerUserLimit int        `json:"per_user_limit"`
+	UsageCount   int        `json:"usage_count" gorm:"not null;default:0"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	Stackable    bool       `json:"stackable"`
+	Active       bool       `json:"active" gorm:"not null;default:true"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// PromotionType represents the kinds of discount a promotion can give.
+const (
+	PromotionTypePercentage   = "percentage"
+	PromotionTypeFixedAmount  = "fixed_amount"
+	PromotionTypeFreeShipping = "free_shipping"
+	PromotionTypeBuyXGetY     = "buy_x_get_y"
+)
+
+// PromotionRedemption records a promotion being used on an order. Line is
+// the order line the discount comes off, product or shipping.
+type PromotionRedemption struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	PromotionID uint      `json:"promotion_id" gorm:"not null;index:idx_redemption_promotion_user"`
+	UserID      uint      `json:"user_id" gorm:"not null;index:idx_redemption_promotion_user"`
+	OrderID     uint      `json:"order_id" gorm:"not null;index"`
+	Code        string    `json:"code" gorm:"not null"`
+	Discount    float64   `json:"discount" gorm:"not null"`
+	Line        string    `json:"line" gorm:"not null;default:product"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// ReturnRequest is a customer's request to send back items of an order (an RMA).
+type ReturnRequest struct {
+	ID      uint         `json:"id" gorm:"primaryKey"`
+	OrderID uint         `json:"order_id" gorm:"not null;index"`
+	UserID  uint         `json:"user_id" gorm:"not null;index"`
+	Status  string       `json:"status" gorm:"not null;index"`
+	Reason  string       `json:"reason" gorm:"not null"`
+	Items   []ReturnItem `json:"items" gorm:"foreignKey:ReturnRequestID"`
+	// Restocked reports whether the received items were put back into stock.
+	Restocked      bool          `json:"restocked"`
+	RefundedAmount float64       `json:"refunded_amount" gorm:"not null;default:0"`
+	History        []ReturnEvent `json:"history,omitempty" gorm:"foreignKey:ReturnRequestID"`
+	CreatedAt      time.Time     `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ReturnItem is a quantity of one ordered product being returned.
+type ReturnItem struct {
+	ID              uint `json:"id" gorm:"primaryKey"`
+	ReturnRequestID uint `json:"return_request_id" gorm:"not null;index"`
+	ProductID       uint `json:"product_id" gorm:"not null"`
+	Quantity        int  `json:"quantity" gorm:"not null"`
+}
+
+// ReturnEvent records a status change of a return request.
+type ReturnEvent struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	ReturnRequestID uint      `json:"return_request_id" gorm:"not null;index"`
+	FromStatus      string    `json:"from_status"`
+	ToStatus        string    `json:"to_status" gorm:"not null"`
+	ActorID         uint      `json:"actor_id"`
+	Note            string    `json:"note"`
+	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// ReturnStatus represents the stages of a return request.
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+	ReturnStatusReceived  = "received"
+	ReturnStatusRefunded  = "refunded"
+)
+
+// returnTransitions lists the statuses a return request may move to from
+// each status. A refunded return can be refunded again, for partial refunds.
+var returnTransitions = map[string][]string{
+	ReturnStatusRequested: {ReturnStatusApproved, ReturnStatusRejected},
+	ReturnStatusApproved:  {ReturnStatusReceived},
+	ReturnStatusReceived:  {ReturnStatusRefunded},
+	ReturnStatusRefunded:  {ReturnStatusRefunded},
+}
+
+// CanTransitionReturn reports whether a return request may move from one status to another.
+func CanTransitionReturn(from, to string) bool {
+	for _, next := range returnTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Refund records money paid back to the customer for an order. A refund is
+// recorded as pending before the payment provider is asked to pay it out,
+// and completed or failed once the provider has answered.
+type Refund struct {
+	ID              uint    `json:"id" gorm:"primaryKey"`
+	OrderID         uint    `json:"order_id" gorm:"not null;index"`
+	ReturnRequestID *uint   `json:"return_request_id" gorm:"index"`
+	Amount          float64 `json:"amount" gorm:"not null"`
+	Status          string  `json:"status" gorm:"not null;default:'completed';index"`
+	// ProviderReference is the payment provider's ID for the refund.
+	ProviderReference string `json:"provider_reference"`
+	// FailureReason explains why the provider did not pay a failed refund out.
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// RefundStatus represents the stages of a refund.
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusCompleted = "completed"
+	RefundStatusFailed    = "failed"
+)
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Review is a customer's rating of a product they bought. Each user can
+// review a product once. Reviews are only shown publicly once approved.
+type Review struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	ProductID uint `json:"product_id" gorm:"not null;uniqueIndex:idx_review_product_user;index"`
+	UserID    uint `json:"user_id" gorm:"not null;uniqueIndex:idx_review_product_user"`
+	// OrderID is the completed order that makes the review a verified purchase.
+	OrderID uint   `json:"order_id" gorm:"not null"`
+	Rating  int    `json:"rating" gorm:"not null"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	Status  string `json:"status" gorm:"not null;default:'pending';index"`
+	// HelpfulCount is the number of other users who voted the review helpful.
+	HelpfulCount   int        `json:"helpful_count" gorm:"not null;default:0"`
+	ModeratorID    *uint      `json:"moderator_id,omitempty"`
+	ModerationNote string     `json:"moderation_note,omitempty"`
+	ModeratedAt    *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ReviewStatus represents the moderation states of a review.
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+// ReviewVote records that a user found a review helpful.
+type ReviewVote struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ReviewID  uint      `json:"review_id" gorm:"not null;uniqueIndex:idx_review_vote"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_review_vote"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// ProductRating summarises the approved reviews of a product: how many
+// there are, their average rating and how many gave each number of stars.
+type ProductRating struct {
+	ProductID uint      `json:"product_id" gorm:"primaryKey;autoIncrement:false"`
+	Count     int       `json:"count" gorm:"not null;default:0"`
+	Average   float64   `json:"average" gorm:"not null;default:0"`
+	Stars1    int       `json:"stars_1" gorm:"not null;default:0"`
+	Stars2    int       `json:"stars_2" gorm:"not null;default:0"`
+	Stars3    int       `json:"stars_3" gorm:"not null;default:0"`
+	Stars4    int       `json:"stars_4" gorm:"not null;default:0"`
+	Stars5    int       `json:"stars_5" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Session represents a single login of a user on a device.
+type Session struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	LastSeenAt time.Time  `json:"last_seen_at" gorm:"not null"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// ImpersonatorID is set when an admin opened the session to act as the user.
+	ImpersonatorID *uint     `json:"impersonator_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// IsActive reports whether the session can still be used at the given time.
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Shipment is a parcel sent to the customer for part or all of an order.
+type Shipment struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	OrderID        uint           `json:"order_id" gorm:"not null;index"`
+	Carrier        string         `json:"carrier" gorm:"not null"`
+	TrackingNumber string         `json:"tracking_number"`
+	Items          []ShipmentItem `json:"items" gorm:"foreignKey:ShipmentID"`
+	ShippedAt      time.Time      `json:"shipped_at" gorm:"not null"`
+	DeliveredAt    *time.Time     `json:"delivered_at"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ShipmentItem is a quantity of one ordered product included in a shipment.
+type ShipmentItem struct {
+	ID         uint `json:"id" gorm:"primaryKey"`
+	ShipmentID uint `json:"shipment_id" gorm:"not null;index"`
+	ProductID  uint `json:"product_id" gorm:"not null"`
+	Quantity   int  `json:"quantity" gorm:"not null"`
+}
+
+// FulfillmentStatus works out the order status implied by its shipments:
+// Completed once every item has been delivered, Shipped once every item has
+// shipped and PartiallyShipped while some items are still to go. An order
+// without shipments keeps its current status.
+func FulfillmentStatus(order *Order, shipments []Shipment) string {
+	shipped, delivered := 0, 0
+	for _, shipment := range shipments {
+		for _, item := range shipment.Items {
+			if item.ProductID != order.ProductID {
+				continue
+			}
+			shipped += item.Quantity
+			if shipment.DeliveredAt != nil {
+				delivered += item.Quantity
+			}
+		}
+	}
+
+	switch {
+	case shipped == 0:
+		return order.Status
+	case delivered >= order.Quantity:
+		return OrderStatusCompleted
+	case shipped >= order.Quantity:
+		return OrderStatusShipped
+	default:
+		return OrderStatusPartiallyShipped
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// ShippingZone groups the destinations that share a set of shipping methods.
+type ShippingZone struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"not null"`
+	// Countries lists the ISO country codes in the zone. A zone without
+	// countries matches every destination not covered by another zone.
+	Countries []string `json:"countries" gorm:"serializer:json"`
+	// Regions narrows the zone to some regions of its countries. When empty
+	// the whole country is covered.
+	Regions   []string         `json:"regions" gorm:"serializer:json"`
+	Methods   []ShippingMethod `json:"methods,omitempty" gorm:"foreignKey:ZoneID"`
+	CreatedAt time.Time        `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time        `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ShippingMethod is a way of shipping to a zone and how it is priced.
+type ShippingMethod struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	ZoneID uint   `json:"zone_id" gorm:"not null;index"`
+	Name   string `json:"name" gorm:"not null"`
+	Type   string `json:"type" gorm:"not null"`
+	// Rate is the flat cost, or the base cost of a weight-based method.
+	Rate float64 `json:"rate"`
+	// PerKg is added for every kilogram of a weight-based method.
+	PerKg float64 `json:"per_kg"`
+	// FreeThreshold is the subtotal from which a free-over-threshold method
+	// costs nothing. Below it the method costs Rate.
+	FreeThreshold float64 `json:"free_threshold"`
+	// MinWeight and MaxWeight limit the method to parcels in a weight range
+	// in kilograms. Zero means no limit.
+	MinWeight float64   `json:"min_weight"`
+	MaxWeight float64   `json:"max_weight"`
+	Active    bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ShippingMethodType represents how a shipping method is priced.
+const (
+	ShippingMethodFlat          = "flat"
+	ShippingMethodWeightBased   = "weight_based"
+	ShippingMethodFreeThreshold = "free_over_threshold"
+)
@@ -1,38 +1,51 @@
 package models
 
 import (
-	"log"
+	"ecommerce-api/internal/password"
+	"errors"
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 // User represents the user model in the application.
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Role      string    `json:"role" gorm:"default:user"`
-	Email     string    `json:"email" gorm:"unique;not null"`
-	Password  string    `json:"password" gorm:"not null"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Role      string     `json:"role" gorm:"default:user"`
+	Email     string     `json:"email" gorm:"unique;not null"`
+	Name      string     `json:"name"`
+	Password  string     `json:"password" gorm:"not null"`
+	TaxExempt bool       `json:"tax_exempt" gorm:"not null;default:false"`
+	ErasedAt  *time.Time `json:"erased_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	// DeletedAt is set when the user is soft-deleted. Deleted users cannot
+	// log in and keep their email address until they are purged.
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
 
-// BeforeSave is a GORM hook to hash the password before saving.
+// BeforeSave is a GORM hook that refuses to persist a plain-text password.
+// Passwords are hashed once, by the service, when they actually change, so
+// saving a user that was loaded from the database never re-hashes the hash.
 func (user *User) BeforeSave(tx *gorm.DB) (err error) {
-	if user.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-		if err != nil {
-			log.Println("Error hashing password:", err)
-			return err
-		}
-		user.Password = string(hashedPassword)
+	if user.Password != "" && !password.IsHash(user.Password) {
+		return errors.New("password must be hashed before saving")
 	}
 	return nil
 }
 
+// SetPassword hashes a new plain-text password and stores the hash on the user.
+func (u *User) SetPassword(hasher password.PasswordHasher, plain string) error {
+	hashed, err := hasher.Hash(plain)
+	if err != nil {
+		return err
+	}
+	u.Password = hashed
+	return nil
+}
+
 // CheckPassword compares a plain text password with the hashed password in the database.
-func (u *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
-	return err == nil
+func (u *User) CheckPassword(hasher password.PasswordHasher, plain string) bool {
+	ok, err := hasher.Verify(u.Password, plain)
+	return err == nil && ok
 }
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// WishlistItem is a product a user saved for later. While Notify is set the
+// user is told when the product comes back in stock or its price drops.
+type WishlistItem struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	UserID    uint `json:"user_id" gorm:"not null;uniqueIndex:idx_wishlist_user_product"`
+	ProductID uint `json:"product_id" gorm:"not null;uniqueIndex:idx_wishlist_user_product;index"`
+	Notify    bool `json:"notify" gorm:"not null"`
+	// UnsubscribeToken goes into the unsubscribe link of every notification
+	// about the product, so the user can stop them without logging in.
+	UnsubscribeToken string    `json:"-" gorm:"not null;uniqueIndex"`
+	Product          *Product  `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// WishlistNotification is a change to a wishlisted product waiting to be
+// sent to a subscriber. Pending notifications of a user are sent together in
+// one digest, at most once per rate limit interval.
+type WishlistNotification struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"not null;index:idx_wishlist_notification_pending"`
+	ProductID uint   `json:"product_id" gorm:"not null;index"`
+	Kind      string `json:"kind" gorm:"not null"`
+	// OldPrice and NewPrice describe a price drop. Later drops before the
+	// digest goes out keep the first OldPrice and move NewPrice.
+	OldPrice  float64    `json:"old_price"`
+	NewPrice  float64    `json:"new_price"`
+	SentAt    *time.Time `json:"sent_at,omitempty" gorm:"index:idx_wishlist_notification_pending"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// WishlistNotificationKind represents the changes subscribers are told about.
+const (
+	WishlistNotificationBackInStock = "back_in_stock"
+	WishlistNotificationPriceDrop   = "price_drop"
+)
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"ecommerce-api/internal/logger"
+	"errors"
+)
+
+// Message is a notification addressed to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users or operators.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// LogNotifier writes messages to the application log instead of delivering
+// them. It is meant for development and as a fallback when no transport is set up.
+type LogNotifier struct{}
+
+// NewLogNotifier creates a new LogNotifier instance.
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Notify logs the message.
+func (n *LogNotifier) Notify(ctx context.Context, msg Message) error {
+	logger.Info("notification to " + msg.To + ": " + msg.Subject + "\n" + msg.Body)
+	return nil
+}
+
+// MultiNotifier delivers each message through several notifiers.
+type MultiNotifier struct {
+	notifiers []Notifier
+}
+
+// NewMultiNotifier creates a new MultiNotifier instance.
+func NewMultiNotifier(notifiers ...Notifier) *MultiNotifier {
+	return &MultiNotifier{notifiers: notifiers}
+}
+
+// Notify hands the message to every notifier, even when an earlier one
+// fails, and returns their errors joined together.
+func (n *MultiNotifier) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, notifier := range n.notifiers {
+		if err := notifier.Notify(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
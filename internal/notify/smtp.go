@@ -0,0 +1,36 @@
+package notify
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPNotifier delivers messages as plain-text email through an SMTP server.
+type SMTPNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPNotifier creates a new SMTPNotifier. Without a username the server
+// is used without authentication.
+func NewSMTPNotifier(host, port, username, password, from string) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPNotifier{addr: net.JoinHostPort(host, port), auth: auth, from: from}
+}
+
+// Notify sends the message as an email to msg.To.
+func (n *SMTPNotifier) Notify(ctx context.Context, msg Message) error {
+	var body strings.Builder
+	body.WriteString("From: " + n.from + "\r\n")
+	body.WriteString("To: " + msg.To + "\r\n")
+	body.WriteString("Subject: " + msg.Subject + "\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return smtp.SendMail(n.addr, n.auth, n.from, []string{msg.To}, []byte(body.String()))
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifier delivers messages by POSTing them as JSON to a URL, for
+// example a chat integration.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a new WebhookNotifier instance.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify posts the message to the webhook. Any non-2xx response is an error.
+func (n *WebhookNotifier) Notify(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]string{
+		"to":      msg.To,
+		"subject": msg.Subject,
+		"body":    msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
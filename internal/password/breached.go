@@ -0,0 +1,57 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"strings"
+	"sync"
+)
+
+// prefixLength is the number of hex characters of the SHA-1 hash used as the
+// range key, the same split used by the Have I Been Pwned range API.
+const prefixLength = 5
+
+//go:embed breached.txt
+var breachedList string
+
+var (
+	breachedOnce   sync.Once
+	breachedRanges map[string]map[string]struct{}
+)
+
+// loadBreachedRanges indexes the bundled hash list by hash prefix.
+func loadBreachedRanges() {
+	breachedRanges = make(map[string]map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(breachedList))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || len(line) <= prefixLength {
+			continue
+		}
+		line = strings.ToUpper(line)
+		prefix, suffix := line[:prefixLength], line[prefixLength:]
+		if breachedRanges[prefix] == nil {
+			breachedRanges[prefix] = make(map[string]struct{})
+		}
+		breachedRanges[prefix][suffix] = struct{}{}
+	}
+}
+
+// BreachedRange returns the hash suffixes known for a hash prefix. Only the
+// prefix of a candidate's hash is ever used as a lookup key, so the list can
+// later be served remotely without revealing which password was checked.
+func BreachedRange(prefix string) map[string]struct{} {
+	breachedOnce.Do(loadBreachedRanges)
+	return breachedRanges[strings.ToUpper(prefix)]
+}
+
+// IsBreached reports whether the password appears in the bundled list of
+// common or breached passwords.
+func IsBreached(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, found := BreachedRange(hash[:prefixLength])[hash[prefixLength:]]
+	return found
+}
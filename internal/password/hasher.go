@@ -0,0 +1,259 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Supported hashing algorithms.
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// ErrUnknownHash is returned when a stored hash was not produced by any known hasher.
+var ErrUnknownHash = errors.New("unrecognized password hash format")
+
+// PasswordHasher hashes passwords and verifies them against stored hashes.
+type PasswordHasher interface {
+	// Hash returns an encoded hash of the password, including its parameters.
+	Hash(password string) (string, error)
+	// Verify reports whether the password matches the encoded hash.
+	Verify(hash, password string) (bool, error)
+	// Recognizes reports whether the encoded hash was produced by this algorithm.
+	Recognizes(hash string) bool
+	// NeedsRehash reports whether the hash uses weaker parameters than the hasher is configured with.
+	NeedsRehash(hash string) bool
+}
+
+// BcryptHasher hashes passwords with bcrypt.
+type BcryptHasher struct {
+	Cost int
+}
+
+// NewBcryptHasher creates a new BcryptHasher with the given cost.
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{Cost: cost}
+}
+
+// Hash hashes the password with bcrypt.
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// Verify compares the password with a bcrypt hash.
+func (h *BcryptHasher) Verify(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Recognizes reports whether the hash is a bcrypt hash.
+func (h *BcryptHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// NeedsRehash reports whether the hash was made with a lower cost.
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < h.Cost
+}
+
+// Argon2idHasher hashes passwords with argon2id and encodes them in the PHC string format.
+type Argon2idHasher struct {
+	Time    uint32
+	Memory  uint32 // in KiB
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+// NewArgon2idHasher creates a new Argon2idHasher. Zero values fall back to the
+// RFC 9106 second recommended option.
+func NewArgon2idHasher(time, memory uint32, threads uint8) *Argon2idHasher {
+	if time == 0 {
+		time = 3
+	}
+	if memory == 0 {
+		memory = 64 * 1024
+	}
+	if threads == 0 {
+		threads = 4
+	}
+	return &Argon2idHasher{Time: time, Memory: memory, Threads: threads, KeyLen: 32, SaltLen: 16}
+}
+
+// Hash hashes the password with argon2id using a random salt.
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// argon2Params are the parameters decoded from an encoded argon2id hash.
+type argon2Params struct {
+	version int
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+// decodeArgon2id parses a PHC formatted argon2id hash.
+func decodeArgon2id(hash string) (*argon2Params, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return nil, ErrUnknownHash
+	}
+
+	var p argon2Params
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &p.version); err != nil {
+		return nil, ErrUnknownHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return nil, ErrUnknownHash
+	}
+
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, ErrUnknownHash
+	}
+	if p.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, ErrUnknownHash
+	}
+	return &p, nil
+}
+
+// Verify compares the password with an argon2id hash in constant time.
+func (h *Argon2idHasher) Verify(hash, password string) (bool, error) {
+	p, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+	if p.version != argon2.Version {
+		return false, ErrUnknownHash
+	}
+
+	key := argon2.IDKey([]byte(password), p.salt, p.time, p.memory, p.threads, uint32(len(p.key)))
+	return subtle.ConstantTimeCompare(key, p.key) == 1, nil
+}
+
+// Recognizes reports whether the hash is an argon2id hash.
+func (h *Argon2idHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+// NeedsRehash reports whether the hash was made with weaker parameters.
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	p, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return p.version != argon2.Version ||
+		p.memory < h.Memory ||
+		p.time < h.Time ||
+		p.threads < h.Threads ||
+		uint32(len(p.key)) < h.KeyLen
+}
+
+// MultiHasher hashes new passwords with the current algorithm while still
+// verifying hashes made by any of the others, so the algorithm can be changed
+// without locking anyone out.
+type MultiHasher struct {
+	current PasswordHasher
+	others  []PasswordHasher
+}
+
+// NewMultiHasher creates a MultiHasher that hashes with current and also
+// accepts hashes produced by others.
+func NewMultiHasher(current PasswordHasher, others ...PasswordHasher) *MultiHasher {
+	return &MultiHasher{current: current, others: others}
+}
+
+// Hash hashes the password with the current algorithm.
+func (h *MultiHasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+// Verify compares the password with a hash made by any known algorithm.
+func (h *MultiHasher) Verify(hash, password string) (bool, error) {
+	hasher := h.hasherFor(hash)
+	if hasher == nil {
+		return false, ErrUnknownHash
+	}
+	return hasher.Verify(hash, password)
+}
+
+// Recognizes reports whether any known algorithm produced the hash.
+func (h *MultiHasher) Recognizes(hash string) bool {
+	return h.hasherFor(hash) != nil
+}
+
+// NeedsRehash reports whether the hash was made by another algorithm or with
+// weaker parameters than the current one.
+func (h *MultiHasher) NeedsRehash(hash string) bool {
+	if !h.current.Recognizes(hash) {
+		return true
+	}
+	return h.current.NeedsRehash(hash)
+}
+
+// hasherFor returns the hasher that produced the hash, or nil.
+func (h *MultiHasher) hasherFor(hash string) PasswordHasher {
+	if h.current.Recognizes(hash) {
+		return h.current
+	}
+	for _, other := range h.others {
+		if other.Recognizes(hash) {
+			return other
+		}
+	}
+	return nil
+}
+
+// NewHasher builds the hasher for the configured algorithm. Hashes made by the
+// other supported algorithm remain verifiable and are upgraded on login.
+func NewHasher(algorithm string, bcryptCost int, argonTime, argonMemory uint32, argonThreads uint8) (*MultiHasher, error) {
+	bcryptHasher := NewBcryptHasher(bcryptCost)
+	argonHasher := NewArgon2idHasher(argonTime, argonMemory, argonThreads)
+
+	switch algorithm {
+	case AlgorithmBcrypt, "":
+		return NewMultiHasher(bcryptHasher, argonHasher), nil
+	case AlgorithmArgon2id:
+		return NewMultiHasher(argonHasher, bcryptHasher), nil
+	default:
+		return nil, fmt.Errorf("unsupported password hashing algorithm %q", algorithm)
+	}
+}
+
+// IsHash reports whether the value looks like a hash produced by a supported
+// algorithm rather than a plain-text password.
+func IsHash(value string) bool {
+	return (&BcryptHasher{}).Recognizes(value) || (&Argon2idHasher{}).Recognizes(value)
+}
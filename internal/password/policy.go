@@ -0,0 +1,103 @@
+package password
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// BcryptMaxLength is the number of bytes bcrypt considers; anything longer is silently ignored.
+const BcryptMaxLength = 72
+
+// Policy rule identifiers, returned in PolicyError so clients can tell which rule failed.
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleUppercase = "uppercase"
+	RuleLowercase = "lowercase"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RuleBreached  = "breached"
+)
+
+// PolicyError describes the first password rule that was not met.
+type PolicyError struct {
+	Rule    string
+	Message string
+}
+
+// Error implements the error interface.
+func (e *PolicyError) Error() string {
+	return e.Message
+}
+
+// Policy describes the requirements a new password must meet.
+type Policy struct {
+	MinLength      int
+	MaxLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSymbol  bool
+	RejectBreached bool
+}
+
+// DefaultPolicy returns the policy used when nothing is configured.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		MinLength:      8,
+		MaxLength:      BcryptMaxLength,
+		RequireUpper:   false,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSymbol:  false,
+		RejectBreached: true,
+	}
+}
+
+// Validate checks the password against every rule of the policy and returns a
+// *PolicyError for the first rule that fails.
+func (p *Policy) Validate(password string) error {
+	length := len([]rune(password))
+	if length < p.MinLength {
+		return &PolicyError{Rule: RuleMinLength, Message: fmt.Sprintf("password must be at least %d characters long", p.MinLength)}
+	}
+
+	maxLength := p.MaxLength
+	if maxLength <= 0 || maxLength > BcryptMaxLength {
+		maxLength = BcryptMaxLength
+	}
+	if len(password) > maxLength {
+		return &PolicyError{Rule: RuleMaxLength, Message: fmt.Sprintf("password must be at most %d bytes long", maxLength)}
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		return &PolicyError{Rule: RuleUppercase, Message: "password must contain an uppercase letter"}
+	}
+	if p.RequireLower && !hasLower {
+		return &PolicyError{Rule: RuleLowercase, Message: "password must contain a lowercase letter"}
+	}
+	if p.RequireDigit && !hasDigit {
+		return &PolicyError{Rule: RuleDigit, Message: "password must contain a digit"}
+	}
+	if p.RequireSymbol && !hasSymbol {
+		return &PolicyError{Rule: RuleSymbol, Message: "password must contain a symbol"}
+	}
+
+	if p.RejectBreached && IsBreached(password) {
+		return &PolicyError{Rule: RuleBreached, Message: "password is too common or has appeared in a data breach"}
+	}
+	return nil
+}
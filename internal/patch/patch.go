@@ -0,0 +1,385 @@
+// Package patch applies partial updates to JSON documents, either as an
+// RFC 7396 JSON Merge Patch or as an RFC 6902 JSON Patch.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Patch formats, named by their media types.
+const (
+	FormatMergePatch = "application/merge-patch+json"
+	FormatJSONPatch  = "application/json-patch+json"
+)
+
+// ErrTestFailed is returned when a JSON Patch "test" operation does not match
+// the document, which means it changed since the client read it.
+var ErrTestFailed = errors.New("patch test failed")
+
+// FormatFromContentType picks the patch format from a Content-Type header.
+// Plain JSON is read as a merge patch. It returns "" for other media types.
+func FormatFromContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case FormatMergePatch, "application/json":
+		return FormatMergePatch
+	case FormatJSONPatch:
+		return FormatJSONPatch
+	}
+	return ""
+}
+
+// Apply applies a patch in the given format to a JSON document and returns
+// the patched document.
+func Apply(format string, doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := decode(doc, &target); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+
+	var err error
+	switch format {
+	case FormatMergePatch:
+		var p interface{}
+		if err := decode(patch, &p); err != nil {
+			return nil, fmt.Errorf("invalid merge patch: %w", err)
+		}
+		target = MergePatch(target, p)
+	case FormatJSONPatch:
+		var ops []Operation
+		if err := decode(patch, &ops); err != nil {
+			return nil, fmt.Errorf("invalid JSON patch: %w", err)
+		}
+		target, err = ApplyOperations(target, ops)
+	default:
+		return nil, fmt.Errorf("unsupported patch format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(target)
+}
+
+// MergePatch applies an RFC 7396 merge patch to a decoded JSON value. Members
+// set to null in the patch are removed, objects are merged recursively and
+// any other value replaces the target.
+func MergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = MergePatch(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// Operation is one RFC 6902 JSON Patch operation.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyOperations applies RFC 6902 operations in order to a decoded JSON
+// value. It stops at the first operation that fails.
+func ApplyOperations(doc interface{}, ops []Operation) (interface{}, error) {
+	var err error
+	for i, op := range ops {
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			if errors.Is(err, ErrTestFailed) {
+				return nil, fmt.Errorf("%w at %s", ErrTestFailed, op.Path)
+			}
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+// applyOperation applies a single JSON Patch operation.
+func applyOperation(doc interface{}, op Operation) (interface{}, error) {
+	var value interface{}
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, errors.New("value is required")
+		}
+		if err := decode(op.Value, &value); err != nil {
+			return nil, err
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		return add(doc, op.Path, value)
+	case "remove":
+		doc, _, err := remove(doc, op.Path)
+		return doc, err
+	case "replace":
+		if op.Path == "" {
+			return value, nil
+		}
+		doc, _, err := remove(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, value)
+	case "move":
+		if op.Path != op.From && strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, errors.New("cannot move a value into itself")
+		}
+		doc, moved, err := remove(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, moved)
+	case "copy":
+		copied, err := get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, deepCopy(copied))
+	case "test":
+		current, err := get(doc, op.Path)
+		if err != nil || !reflect.DeepEqual(normalize(current), normalize(value)) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid path %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// get returns the value a pointer refers to.
+func get(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found", pointer)
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("path %q not found", pointer)
+		}
+	}
+	return current, nil
+}
+
+// add sets the value at a pointer, inserting into arrays, and returns the
+// updated document.
+func add(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return update(doc, pointer, tokens, func(parent interface{}, last string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[last] = value
+			return node, nil
+		case []interface{}:
+			index := len(node)
+			if last != "-" {
+				var err error
+				if index, err = arrayIndex(last, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+		return nil, fmt.Errorf("path %q not found", pointer)
+	})
+}
+
+// remove deletes the value at a pointer and returns the updated document
+// along with the removed value.
+func remove(doc interface{}, pointer string) (interface{}, interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+	var removed interface{}
+	doc, err = update(doc, pointer, tokens, func(parent interface{}, last string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			value, ok := node[last]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found", pointer)
+			}
+			removed = value
+			delete(node, last)
+			return node, nil
+		case []interface{}:
+			index, err := arrayIndex(last, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[index]
+			return append(node[:index:index], node[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("path %q not found", pointer)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, removed, nil
+}
+
+// update walks down to the container holding the last token of a pointer,
+// lets change return a new version of it and stores that back in its parent,
+// since inserting into or removing from an array makes a new slice.
+func update(doc interface{}, pointer string, tokens []string, change func(parent interface{}, last string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return change(doc, tokens[0])
+	}
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("path %q not found", pointer)
+		}
+		updated, err := update(child, pointer, tokens[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		node[tokens[0]] = updated
+		return node, nil
+	case []interface{}:
+		index, err := arrayIndex(tokens[0], len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := update(node[index], pointer, tokens[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = updated
+		return node, nil
+	}
+	return nil, fmt.Errorf("path %q not found", pointer)
+}
+
+// arrayIndex parses an array index token no greater than max.
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max {
+		return 0, fmt.Errorf("array index %q out of range", token)
+	}
+	return index, nil
+}
+
+// decode parses JSON keeping numbers exact.
+func decode(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after JSON value")
+	}
+	return nil
+}
+
+// normalize turns numbers into float64 so that 1 and 1.0 compare equal.
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return f
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = normalize(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = normalize(item)
+		}
+		return out
+	}
+	return value
+}
+
+// deepCopy copies a decoded JSON value so copies do not share maps or slices.
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = deepCopy(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = deepCopy(item)
+		}
+		return out
+	}
+	return value
+}
@@ -0,0 +1,42 @@
+package payment
+
+import (
+	"context"
+	"ecommerce-api/internal/logger"
+	"fmt"
+)
+
+// RefundRequest asks the payment provider to pay money back to a customer.
+type RefundRequest struct {
+	OrderID uint
+	Amount  float64
+	// Reference identifies the refund to the provider. Sending the same
+	// reference again must not pay out twice.
+	Reference string
+	Reason    string
+}
+
+// RefundResult is the provider's record of a refund.
+type RefundResult struct {
+	ProviderReference string
+}
+
+// Provider moves money through a payment service.
+type Provider interface {
+	Refund(ctx context.Context, req RefundRequest) (*RefundResult, error)
+}
+
+// ManualProvider logs refunds so that they can be paid out by hand. It is
+// used until a payment service is integrated.
+type ManualProvider struct{}
+
+// NewManualProvider creates a new ManualProvider instance.
+func NewManualProvider() *ManualProvider {
+	return &ManualProvider{}
+}
+
+// Refund logs the refund and returns its reference.
+func (p *ManualProvider) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	logger.Info(fmt.Sprintf("manual refund of %.2f for order %d (%s): %s", req.Amount, req.OrderID, req.Reference, req.Reason))
+	return &RefundResult{ProviderReference: "manual:" + req.Reference}, nil
+}
@@ -0,0 +1,175 @@
+// Package pdf writes simple text documents in the PDF format. It supports the
+// standard Helvetica and Courier fonts, text and horizontal rules, which is
+// all invoices need, and produces the same bytes for the same input.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 page size in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Font is one of the standard PDF fonts available without embedding.
+type Font string
+
+// Fonts that can be used with Page.Text.
+const (
+	Helvetica     Font = "F1"
+	HelveticaBold Font = "F2"
+	Courier       Font = "F3"
+)
+
+// fontNames maps the resource names used in content streams to base fonts.
+var fontNames = []struct {
+	resource Font
+	base     string
+}{
+	{Helvetica, "Helvetica"},
+	{HelveticaBold, "Helvetica-Bold"},
+	{Courier, "Courier"},
+}
+
+// Document is a PDF document being built page by page.
+type Document struct {
+	pages []*Page
+	title string
+}
+
+// Page is a single page of a Document. Coordinates are in points from the
+// bottom left corner.
+type Page struct {
+	content bytes.Buffer
+}
+
+// New creates an empty document with the given title.
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+// AddPage appends a blank A4 page to the document.
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Text draws a single line of text with its baseline starting at x, y.
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		font, number(size), number(x), number(y), escape(text))
+}
+
+// TextRight draws a line of Courier text ending at x. Courier has a fixed
+// width, which makes columns of amounts line up.
+func (p *Page) TextRight(x, y float64, size float64, text string) {
+	width := float64(len([]rune(text))) * size * 0.6
+	p.Text(x-width, y, Courier, size, text)
+}
+
+// Rule draws a thin horizontal line from x1 to x2 at height y.
+func (p *Page) Rule(x1, x2, y float64) {
+	fmt.Fprintf(&p.content, "0.5 w %s %s m %s %s l S\n", number(x1), number(y), number(x2), number(y))
+}
+
+// WriteTo writes the document in PDF format.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	// Object numbers: 1 catalog, 2 page tree, 3 info, then the fonts and
+	// finally a page object and a content stream for every page.
+	firstFont := 4
+	firstPage := firstFont + len(fontNames)
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	object(fmt.Sprintf("<< /Title (%s) /Producer (ecommerce-api) >>", escape(d.title)))
+
+	fonts := make([]string, len(fontNames))
+	for i, f := range fontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.base))
+		fonts[i] = fmt.Sprintf("/%s %d 0 R", f.resource, firstFont+i)
+	}
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			number(PageWidth), number(PageHeight), strings.Join(fonts, " "), firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.WriteTo(w)
+}
+
+// Bytes returns the document in PDF format.
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	d.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// number formats a coordinate or size without needless decimals.
+func number(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// winAnsi maps characters outside Latin-1 that WinAnsiEncoding supports.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// escape encodes text as the body of a PDF string literal in WinAnsiEncoding.
+// Characters the encoding lacks are replaced by a question mark.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		var c byte
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			c = byte(r)
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			c = byte(r)
+		default:
+			var ok bool
+			if c, ok = winAnsi[r]; !ok {
+				c = '?'
+			}
+		}
+		if c >= 0x80 {
+			fmt.Fprintf(&b, "\\%03o", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
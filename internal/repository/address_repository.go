@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// AddressRepository defines the methods for interacting with user addresses in the database.
+type AddressRepository interface {
+	CreateAddress(address *models.Address) error
+	GetAddressesByUser(userID uint) ([]models.Address, error)
+	GetAddressByID(userID, addressID uint) (*models.Address, error)
+	DeleteAddress(userID, addressID uint) error
+}
+
+// addressRepository implements the AddressRepository interface.
+type addressRepository struct {
+	db *gorm.DB
+}
+
+// NewAddressRepository creates a new instance of AddressRepository.
+func NewAddressRepository(db *gorm.DB) AddressRepository {
+	return &addressRepository{db: db}
+}
+
+// CreateAddress inserts a new address into the database.
+func (r *addressRepository) CreateAddress(address *models.Address) error {
+	return r.db.Create(address).Error
+}
+
+// GetAddressesByUser retrieves all addresses saved by a user.
+func (r *addressRepository) GetAddressesByUser(userID uint) ([]models.Address, error) {
+	var addresses []models.Address
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&addresses).Error; err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// GetAddressByID retrieves an address, scoped to the user that owns it.
+func (r *addressRepository) GetAddressByID(userID, addressID uint) (*models.Address, error) {
+	var address models.Address
+	if err := r.db.Where("user_id = ?", userID).First(&address, addressID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("address not found")
+		}
+		return nil, err
+	}
+	return &address, nil
+}
+
+// DeleteAddress removes an address, scoped to the user that owns it.
+func (r *addressRepository) DeleteAddress(userID, addressID uint) error {
+	result := r.db.Where("user_id = ?", userID).Delete(&models.Address{}, addressID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("address not found")
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepository defines the methods for writing and reading the audit log.
+type AuditRepository interface {
+	CreateEntry(entry *models.AuditLog) error
+	GetEntriesByTarget(targetType string, targetID uint) ([]models.AuditLog, error)
+}
+
+// auditRepository implements the AuditRepository interface.
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository creates a new instance of AuditRepository.
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// CreateEntry appends an entry to the audit log.
+func (r *auditRepository) CreateEntry(entry *models.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+// GetEntriesByTarget retrieves the audit trail of a single record, oldest first.
+func (r *auditRepository) GetEntriesByTarget(targetType string, targetID uint) ([]models.AuditLog, error) {
+	var entries []models.AuditLog
+	if err := r.db.Where("target_type = ? AND target_id = ?", targetType, targetID).Order("id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepository defines the methods for storing idempotency keys.
+type IdempotencyRepository interface {
+	Begin(userID uint, key, requestHash string) (*models.IdempotencyKey, bool, error)
+	Complete(id uint, code int, contentType string, body []byte) error
+	Release(id uint) error
+	DeleteOlderThan(cutoff time.Time) (int64, error)
+}
+
+// idempotencyRepository implements the IdempotencyRepository interface.
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository creates a new instance of IdempotencyRepository.
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Begin claims a key for a user. It returns the stored record and true when the
+// key was claimed by this call, or the existing record and false when the key
+// had already been used. The unique index makes the claim atomic.
+func (r *idempotencyRepository) Begin(userID uint, key, requestHash string) (*models.IdempotencyKey, bool, error) {
+	record := &models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      models.IdempotencyStatusInProgress,
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return record, true, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := r.db.Where("user_id = ? AND key = ?", userID, key).First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+// Complete stores the response of the request that claimed the key.
+func (r *idempotencyRepository) Complete(id uint, code int, contentType string, body []byte) error {
+	return r.db.Model(&models.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":        models.IdempotencyStatusCompleted,
+		"response_code": code,
+		"response_type": contentType,
+		"response_body": body,
+	}).Error
+}
+
+// Release removes a claimed key so the request can be retried.
+func (r *idempotencyRepository) Release(id uint) error {
+	return r.db.Delete(&models.IdempotencyKey{}, id).Error
+}
+
+// DeleteOlderThan removes keys created before the cutoff.
+func (r *idempotencyRepository) DeleteOlderThan(cutoff time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", cutoff).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
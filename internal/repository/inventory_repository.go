@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InventoryRepository defines the methods for changing stock through the inventory ledger.
+type InventoryRepository interface {
+	AdjustStock(movement *models.StockMovement) error
+	SetStock(movement *models.StockMovement, level int) error
+	GetMovementsByProduct(productID uint, page, pageSize int) ([]models.StockMovement, int64, error)
+	GetMovementsByOrder(orderID uint) ([]models.StockMovement, error)
+	GetSalesSince(since time.Time) (map[uint]int, error)
+	GetStockLevels(productID uint) ([]models.WarehouseStock, error)
+	GetProductStock(productID uint) (*models.ProductStock, error)
+	TransferStock(productID uint, fromWarehouseID *uint, toWarehouseID uint, quantity int, actorID uint, note string) ([]models.StockMovement, error)
+}
+
+// inventoryRepository implements the InventoryRepository interface.
+type inventoryRepository struct {
+	db *gorm.DB
+}
+
+// NewInventoryRepository creates a new instance of InventoryRepository.
+func NewInventoryRepository(db *gorm.DB) InventoryRepository {
+	return &inventoryRepository{db: db}
+}
+
+// AdjustStock changes a product's stock by movement.Change and records the movement.
+func (r *inventoryRepository) AdjustStock(movement *models.StockMovement) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return applyStockChange(tx, movement)
+	})
+}
+
+// SetStock sets a product's stock to level, recording the difference as a
+// movement. When the movement names a warehouse, level is the stock held at
+// that warehouse; otherwise it is the product's total stock.
+func (r *inventoryRepository) SetStock(movement *models.StockMovement, level int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return setStockLevel(tx, movement, level)
+	})
+}
+
+// setStockLevel does the work of SetStock inside a transaction.
+func setStockLevel(tx *gorm.DB, movement *models.StockMovement, level int) error {
+	product, err := lockProduct(tx, movement.ProductID)
+	if err != nil {
+		return err
+	}
+
+	current := product.Stock
+	if movement.WarehouseID != nil {
+		var location models.WarehouseStock
+		err := tx.Where("warehouse_id = ? AND product_id = ?", *movement.WarehouseID, movement.ProductID).
+			Limit(1).Find(&location).Error
+		if err != nil {
+			return err
+		}
+		current = location.Quantity
+		movement.LocationStockAfter = &level
+	}
+
+	movement.Change = level - current
+	if movement.Change == 0 {
+		movement.StockAfter = product.Stock
+		return nil
+	}
+	return applyStockChange(tx, movement)
+}
+
+// GetMovementsByOrder retrieves the stock movements recorded for an order, oldest first.
+func (r *inventoryRepository) GetMovementsByOrder(orderID uint) ([]models.StockMovement, error) {
+	var movements []models.StockMovement
+	if err := r.db.Where("order_id = ?", orderID).Order("id").Find(&movements).Error; err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// GetSalesSince adds up the quantities ordered of each product since a point
+// in time, keyed by product ID. Cancelled orders are left out.
+func (r *inventoryRepository) GetSalesSince(since time.Time) (map[uint]int, error) {
+	var rows []struct {
+		ProductID uint
+		Sold      int
+	}
+	err := r.db.Model(&models.Order{}).
+		Select("product_id, SUM(quantity) AS sold").
+		Where("created_at >= ? AND status <> ?", since, models.OrderStatusCancelled).
+		Group("product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sales := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		sales[row.ProductID] = row.Sold
+	}
+	return sales, nil
+}
+
+// GetStockLevels retrieves the stock each warehouse holds of a product, with the warehouses loaded.
+func (r *inventoryRepository) GetStockLevels(productID uint) ([]models.WarehouseStock, error) {
+	var levels []models.WarehouseStock
+	err := r.db.Preload("Warehouse").Where("product_id = ?", productID).Order("warehouse_id").Find(&levels).Error
+	if err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+// GetProductStock breaks a product's stock down by warehouse.
+func (r *inventoryRepository) GetProductStock(productID uint) (*models.ProductStock, error) {
+	var product models.Product
+	if err := r.db.First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, err
+	}
+
+	levels, err := r.GetStockLevels(productID)
+	if err != nil {
+		return nil, err
+	}
+	stock := &models.ProductStock{ProductID: product.ID, Total: product.Stock, Unassigned: product.Stock, Locations: levels}
+	for _, level := range levels {
+		stock.Unassigned -= level.Quantity
+	}
+	return stock, nil
+}
+
+// TransferStock moves stock of a product from one warehouse to another, or
+// assigns unassigned stock to a warehouse when fromWarehouseID is nil. The
+// product's total stock does not change; both sides are recorded as
+// transfer movements.
+func (r *inventoryRepository) TransferStock(productID uint, fromWarehouseID *uint, toWarehouseID uint, quantity int, actorID uint, note string) ([]models.StockMovement, error) {
+	var movements []models.StockMovement
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := lockProduct(tx, productID)
+		if err != nil {
+			return err
+		}
+
+		out := models.StockMovement{
+			ProductID:   productID,
+			Change:      -quantity,
+			StockAfter:  product.Stock,
+			Reason:      models.StockReasonTransfer,
+			WarehouseID: fromWarehouseID,
+			ActorID:     actorID,
+			Note:        note,
+		}
+		if fromWarehouseID != nil {
+			level, err := changeLocationStock(tx, *fromWarehouseID, productID, -quantity)
+			if err != nil {
+				return err
+			}
+			out.LocationStockAfter = &level
+		} else {
+			unassigned, err := unassignedStock(tx, product)
+			if err != nil {
+				return err
+			}
+			if unassigned < quantity {
+				return errors.New("insufficient stock")
+			}
+		}
+
+		level, err := changeLocationStock(tx, toWarehouseID, productID, quantity)
+		if err != nil {
+			return err
+		}
+		in := out
+		in.Change = quantity
+		in.WarehouseID = &toWarehouseID
+		in.LocationStockAfter = &level
+
+		movements = []models.StockMovement{out, in}
+		return tx.Create(&movements).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// GetMovementsByProduct retrieves a page of a product's stock movements,
+// newest first, along with the total number of movements.
+func (r *inventoryRepository) GetMovementsByProduct(productID uint, page, pageSize int) ([]models.StockMovement, int64, error) {
+	query := r.db.Model(&models.StockMovement{}).Where("product_id = ?", productID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var movements []models.StockMovement
+	err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&movements).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return movements, total, nil
+}
+
+// applyStockChange changes a product's stock inside a transaction and records
+// the movement with the resulting stock level. When the movement names a
+// warehouse, that location's stock changes too; otherwise the change comes
+// out of the stock not assigned to any warehouse. Stock can never go below
+// zero. Every stock change goes through here so that the ledger and the
+// stock columns stay in step. Deleted products cannot be sold, but orders
+// for them can still be cancelled and returned.
+func applyStockChange(tx *gorm.DB, movement *models.StockMovement) error {
+	product, err := lockProduct(tx, movement.ProductID)
+	if err != nil {
+		return err
+	}
+	if product.DeletedAt.Valid && movement.Reason == models.StockReasonSale {
+		return errors.New("product not found")
+	}
+
+	after := product.Stock + movement.Change
+	if after < 0 {
+		return errors.New("insufficient stock")
+	}
+	if movement.WarehouseID != nil {
+		level, err := changeLocationStock(tx, *movement.WarehouseID, movement.ProductID, movement.Change)
+		if err != nil {
+			return err
+		}
+		movement.LocationStockAfter = &level
+	} else if movement.Change < 0 {
+		unassigned, err := unassignedStock(tx, product)
+		if err != nil {
+			return err
+		}
+		if unassigned < -movement.Change {
+			return errors.New("insufficient stock")
+		}
+	}
+	if err := tx.Unscoped().Model(product).UpdateColumn("stock", after).Error; err != nil {
+		return err
+	}
+
+	movement.ID = 0
+	movement.StockAfter = after
+	return tx.Create(movement).Error
+}
+
+// changeLocationStock changes the stock a warehouse holds of a product and
+// returns the new level. The caller must hold the product's row lock.
+func changeLocationStock(tx *gorm.DB, warehouseID, productID uint, change int) (int, error) {
+	var level models.WarehouseStock
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("warehouse_id = ? AND product_id = ?", warehouseID, productID).
+		First(&level).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := tx.First(&models.Warehouse{}, warehouseID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, errors.New("warehouse not found")
+			}
+			return 0, err
+		}
+		level = models.WarehouseStock{WarehouseID: warehouseID, ProductID: productID}
+		if err := tx.Create(&level).Error; err != nil {
+			return 0, err
+		}
+	} else if err != nil {
+		return 0, err
+	}
+
+	after := level.Quantity + change
+	if after < 0 {
+		return 0, errors.New("insufficient stock")
+	}
+	if err := tx.Model(&level).UpdateColumn("quantity", after).Error; err != nil {
+		return 0, err
+	}
+	return after, nil
+}
+
+// unassignedStock works out how much of a product's stock is not held at any warehouse.
+func unassignedStock(tx *gorm.DB, product *models.Product) (int, error) {
+	var located int
+	err := tx.Model(&models.WarehouseStock{}).
+		Where("product_id = ?", product.ID).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&located).Error
+	if err != nil {
+		return 0, err
+	}
+	return product.Stock - located, nil
+}
+
+// lockProduct reads a product, soft-deleted or not, and locks its row until
+// the transaction ends.
+func lockProduct(tx *gorm.DB, productID uint) (*models.Product, error) {
+	var product models.Product
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, err
+	}
+	return &product, nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InvoiceRenderer renders an invoice once its number has been assigned.
+type InvoiceRenderer func(inv *models.Invoice) []byte
+
+// invoicePrefixes maps document kinds to the prefix of their numbers.
+var invoicePrefixes = map[string]string{
+	models.InvoiceKindInvoice:    "INV",
+	models.InvoiceKindCreditNote: "CN",
+}
+
+// InvoiceRepository defines the methods for interacting with invoices in the database.
+// Invoices can only be issued and read, never changed.
+type InvoiceRepository interface {
+	IssueInvoice(inv *models.Invoice, render InvoiceRenderer) error
+	GetInvoiceByOrder(orderID uint) (*models.Invoice, error)
+	GetInvoiceByID(id uint) (*models.Invoice, error)
+	GetCreditNotesByOrder(orderID uint) ([]models.Invoice, error)
+}
+
+// invoiceRepository implements the InvoiceRepository interface.
+type invoiceRepository struct {
+	db *gorm.DB
+}
+
+// NewInvoiceRepository creates a new instance of InvoiceRepository.
+func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+// IssueInvoice numbers, renders and stores an invoice or credit note. Numbers
+// run per kind and year without gaps: the sequence row stays locked until the
+// invoice is stored, and a failed issue rolls the number back. If the order
+// already has its invoice, or the refund its credit note, that document is
+// loaded into inv instead.
+func (r *invoiceRepository) IssueInvoice(inv *models.Invoice, render InvoiceRenderer) error {
+	prefix, ok := invoicePrefixes[inv.Kind]
+	if !ok {
+		return errors.New("invalid invoice kind")
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Issuing documents for the same order one at a time prevents duplicates
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&order, inv.OrderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("order not found")
+			}
+			return err
+		}
+
+		existing := tx.Where("order_id = ? AND kind = ?", inv.OrderID, inv.Kind)
+		if inv.RefundID != nil {
+			existing = tx.Where("refund_id = ?", *inv.RefundID)
+		}
+		err := existing.First(inv).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		inv.Year = inv.IssuedAt.Year()
+		sequence := models.InvoiceSequence{Kind: inv.Kind, Year: inv.Year}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sequence).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("kind = ? AND year = ?", inv.Kind, inv.Year).First(&sequence).Error; err != nil {
+			return err
+		}
+		sequence.LastNumber++
+		if err := tx.Model(&sequence).Where("kind = ? AND year = ?", inv.Kind, inv.Year).
+			Update("last_number", sequence.LastNumber).Error; err != nil {
+			return err
+		}
+
+		inv.Sequence = sequence.LastNumber
+		inv.Number = fmt.Sprintf("%s-%d-%06d", prefix, inv.Year, inv.Sequence)
+		inv.PDF = render(inv)
+		return tx.Create(inv).Error
+	})
+}
+
+// GetInvoiceByOrder retrieves the invoice issued for an order.
+func (r *invoiceRepository) GetInvoiceByOrder(orderID uint) (*models.Invoice, error) {
+	var inv models.Invoice
+	err := r.db.Where("order_id = ? AND kind = ?", orderID, models.InvoiceKindInvoice).First(&inv).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invoice not found")
+		}
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// GetInvoiceByID retrieves an invoice or credit note by its ID.
+func (r *invoiceRepository) GetInvoiceByID(id uint) (*models.Invoice, error) {
+	var inv models.Invoice
+	if err := r.db.First(&inv, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invoice not found")
+		}
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// GetCreditNotesByOrder retrieves the credit notes issued for an order, oldest first.
+func (r *invoiceRepository) GetCreditNotesByOrder(orderID uint) ([]models.Invoice, error) {
+	var notes []models.Invoice
+	err := r.db.Omit("pdf").Where("order_id = ? AND kind = ?", orderID, models.InvoiceKindCreditNote).
+		Order("id").Find(&notes).Error
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// AdvisoryLocker runs work while holding a Postgres advisory lock, so that
+// only one replica of the service does it at a time.
+type AdvisoryLocker interface {
+	TryWithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error)
+}
+
+// advisoryLocker implements the AdvisoryLocker interface.
+type advisoryLocker struct {
+	db *gorm.DB
+}
+
+// NewAdvisoryLocker creates a new instance of AdvisoryLocker.
+func NewAdvisoryLocker(db *gorm.DB) AdvisoryLocker {
+	return &advisoryLocker{db: db}
+}
+
+// TryWithLock takes the advisory lock for key without waiting and runs fn
+// while holding it. It reports false, without running fn, if another session
+// holds the lock. The lock belongs to one database connection, which is kept
+// for the whole run.
+func (l *advisoryLocker) TryWithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error) {
+	acquired := false
+	err := l.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if !acquired {
+			return nil
+		}
+		// Unlock on a fresh context so a cancelled run still releases the lock
+		defer conn.WithContext(context.Background()).Exec("SELECT pg_advisory_unlock(?)", key)
+		return fn(ctx)
+	})
+	return acquired, err
+}
@@ -2,17 +2,79 @@ package repository
 
 import (
 	"ecommerce-api/internal/models"
+	"errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// PromotionLimitError is returned when a promotion ran out of uses while an order was being placed.
+type PromotionLimitError struct {
+	Code string
+}
+
+// Error implements the error interface.
+func (e *PromotionLimitError) Error() string {
+	return "promotion usage limit reached: " + e.Code
+}
+
+// OrderFilter narrows down, sorts and paginates an admin order listing.
+type OrderFilter struct {
+	Status    string
+	UserID    uint
+	ProductID uint
+	From      *time.Time
+	To        *time.Time
+	SortBy    string
+	SortDesc  bool
+	Page      int
+	PageSize  int
+	// IncludeDeleted also lists soft-deleted orders.
+	IncludeDeleted bool
+}
+
+// orderSortColumns maps the sort keys accepted by ListOrders to columns.
+var orderSortColumns = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"status":     "status",
+	"quantity":   "quantity",
+	"user_id":    "user_id",
+}
+
+// IsValidOrderSort reports whether the key can be used as OrderFilter.SortBy.
+func IsValidOrderSort(key string) bool {
+	_, ok := orderSortColumns[key]
+	return ok
+}
+
+// OrderTransitionError is returned when an order cannot move to the requested status.
+type OrderTransitionError struct {
+	From string
+	To   string
+}
+
+// Error implements the error interface.
+func (e *OrderTransitionError) Error() string {
+	return "order cannot move from " + e.From + " to " + e.To
+}
+
 // OrderRepositoryInterface defines the contract for the order repository.
 type OrderRepositoryInterface interface {
 	CreateOrder(order *models.Order) error
 	GetOrderByID(orderID uint) (*models.Order, error)
 	GetOrdersByUser(userID uint) ([]models.Order, error)
-	UpdateOrderStatus(orderID uint, status string) error
+	ListOrders(filter OrderFilter) ([]models.Order, int64, error)
+	UpdateOrderStatus(orderID uint, status string, expectedVersion int) error
+	CancelPendingOrder(orderID, actorID uint, reason string) error
+	ExpirePendingOrder(orderID uint, reason string) error
+	MarkOrderPaid(orderID uint, paidAt time.Time) error
+	GetExpiredPendingOrderIDs(cutoff time.Time, limit int) ([]uint, error)
 	DeleteOrder(orderID uint) error
+	RestoreOrder(orderID uint) error
+	PurgeDeletedOrders(cutoff time.Time) (int64, error)
 }
 
 // OrderRepository defines the methods for order-related database operations.
@@ -25,18 +87,69 @@ func NewOrderRepository(db *gorm.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
-// CreateOrder inserts a new order into the database using GORM.
+// CreateOrder inserts a new order into the database using GORM and reserves
+// its stock, recording a sale in the inventory ledger. Promotion redemptions
+// attached to the order are counted in the same transaction: each promotion
+// row is locked, its global and per-user limits are checked again and its
+// usage count is incremented, so concurrent orders cannot overspend it.
 func (r *OrderRepository) CreateOrder(order *models.Order) error {
-	if err := r.db.Create(order).Error; err != nil {
-		return err
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, redemption := range order.Redemptions {
+			var promotion models.Promotion
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promotion, redemption.PromotionID).Error; err != nil {
+				return err
+			}
+			if promotion.UsageLimit > 0 && promotion.UsageCount >= promotion.UsageLimit {
+				return &PromotionLimitError{Code: promotion.Code}
+			}
+			if promotion.PerUserLimit > 0 {
+				var used int64
+				err := tx.Model(&models.PromotionRedemption{}).
+					Where("promotion_id = ? AND user_id = ?", promotion.ID, order.UserID).
+					Count(&used).Error
+				if err != nil {
+					return err
+				}
+				if used >= int64(promotion.PerUserLimit) {
+					return &PromotionLimitError{Code: promotion.Code}
+				}
+			}
+			err := tx.Model(&promotion).UpdateColumn("usage_count", gorm.Expr("usage_count + 1")).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// Creating the order also inserts its redemptions
+		order.History = []models.OrderEvent{{ToStatus: order.Status, ActorID: order.UserID, Reason: "order placed"}}
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+
+		return applyStockChange(tx, &models.StockMovement{
+			ProductID:   order.ProductID,
+			Change:      -order.Quantity,
+			Reason:      models.StockReasonSale,
+			OrderID:     &order.ID,
+			WarehouseID: order.WarehouseID,
+			ActorID:     order.UserID,
+		})
+	})
+}
+
+// preloadOrderDetails loads the records shown together with an order.
+func preloadOrderDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Redemptions").Preload("TaxLines").Preload("Shipments.Items").Preload("Refunds").
+		Preload("History", func(db *gorm.DB) *gorm.DB { return db.Order("id") })
 }
 
 // GetOrderByID retrieves an order by its ID using GORM.
 func (r *OrderRepository) GetOrderByID(orderID uint) (*models.Order, error) {
 	var order models.Order
-	if err := r.db.First(&order, orderID).Error; err != nil {
+	if err := preloadOrderDetails(r.db).First(&order, orderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("order not found")
+		}
 		return nil, err
 	}
 	return &order, nil
@@ -45,24 +158,314 @@ func (r *OrderRepository) GetOrderByID(orderID uint) (*models.Order, error) {
 // GetOrdersByUser retrieves all orders for a specific user using GORM.
 func (r *OrderRepository) GetOrdersByUser(userID uint) ([]models.Order, error) {
 	var orders []models.Order
-	if err := r.db.Where("user_id = ?", userID).Find(&orders).Error; err != nil {
+	if err := preloadOrderDetails(r.db).Where("user_id = ?", userID).Find(&orders).Error; err != nil {
 		return nil, err
 	}
 	return orders, nil
 }
 
-// UpdateOrderStatus updates the status of an existing order using GORM.
-func (r *OrderRepository) UpdateOrderStatus(orderID uint, status string) error {
-	if err := r.db.Model(&models.Order{}).Where("id = ?", orderID).Update("status", status).Error; err != nil {
+// ListOrders retrieves a page of orders matching the filter, along with the
+// total number of matching orders.
+func (r *OrderRepository) ListOrders(filter OrderFilter) ([]models.Order, int64, error) {
+	query := r.db.Model(&models.Order{})
+	if filter.IncludeDeleted {
+		query = r.db.Unscoped().Model(&models.Order{})
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ProductID != 0 {
+		query = query.Where("product_id = ?", filter.ProductID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := orderSortColumns[filter.SortBy]
+	if !ok {
+		column = "created_at"
+	}
+	direction := " ASC"
+	if filter.SortDesc {
+		direction = " DESC"
+	}
+
+	var orders []models.Order
+	err := preloadOrderDetails(query).
+		Order(column + direction).Order("id" + direction).
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&orders).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+// UpdateOrderStatus updates the status of an existing order using GORM and
+// records the change in the order history. Only the moves allowed by
+// models.CanTransitionOrder are made. Cancelling a pending order
+// releases its reserved stock. When expectedVersion is not 0 the order must
+// still be at that version.
+func (r *OrderRepository) UpdateOrderStatus(orderID uint, status string, expectedVersion int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != 0 && order.Version != expectedVersion {
+			return &VersionConflictError{Resource: "order", CurrentVersion: order.Version}
+		}
+		return changeOrderStatus(tx, order, status, 0, "status updated by admin")
+	})
+}
+
+// CancelPendingOrder cancels an order that is still pending, releases its
+// reserved stock and promotion uses and records the reason in the order history.
+func (r *OrderRepository) CancelPendingOrder(orderID, actorID uint, reason string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.Status != models.OrderStatusPending {
+			return errors.New("order cannot be canceled as it is not in Pending status")
+		}
+		return changeOrderStatus(tx, order, models.OrderStatusCancelled, actorID, reason)
+	})
+}
+
+// ExpirePendingOrder cancels an order that is still pending and was never
+// paid, releasing its reserved stock and promotion uses.
+func (r *OrderRepository) ExpirePendingOrder(orderID uint, reason string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.Status != models.OrderStatusPending {
+			return errors.New("order cannot be canceled as it is not in Pending status")
+		}
+		if order.PaidAt != nil {
+			return errors.New("order is already paid")
+		}
+		return changeOrderStatus(tx, order, models.OrderStatusCancelled, 0, reason)
+	})
+}
+
+// MarkOrderPaid records that payment for an order was received, so it no
+// longer expires. Cancelled orders cannot be paid.
+func (r *OrderRepository) MarkOrderPaid(orderID uint, paidAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.Status == models.OrderStatusCancelled {
+			return errors.New("cancelled orders cannot be paid")
+		}
+		if order.PaidAt != nil {
+			return errors.New("order is already paid")
+		}
+		return tx.Model(order).UpdateColumns(map[string]interface{}{
+			"paid_at": paidAt,
+			"version": gorm.Expr("version + 1"),
+		}).Error
+	})
+}
+
+// GetExpiredPendingOrderIDs returns up to limit IDs of orders placed before
+// cutoff that are still pending and unpaid, oldest first.
+func (r *OrderRepository) GetExpiredPendingOrderIDs(cutoff time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Order{}).
+		Where("status = ? AND paid_at IS NULL AND created_at < ?", models.OrderStatusPending, cutoff).
+		Order("created_at").Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// lockOrder reads an order and locks its row until the transaction ends.
+func lockOrder(tx *gorm.DB, orderID uint) (*models.Order, error) {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// changeOrderStatus moves a locked order to a new status, if
+// models.CanTransitionOrder allows it, and records the change in its history.
+// Stock reserved by a pending order is released when it is cancelled, and a
+// cancelled order gives back the promotion uses it redeemed.
+func changeOrderStatus(tx *gorm.DB, order *models.Order, status string, actorID uint, reason string) error {
+	if order.Status == status {
+		return nil
+	}
+	if !models.CanTransitionOrder(order.Status, status) {
+		return &OrderTransitionError{From: order.Status, To: status}
+	}
+	if status == models.OrderStatusCancelled {
+		if err := releasePromotions(tx, order.ID); err != nil {
+			return err
+		}
+	}
+	if order.Status == models.OrderStatusPending && status == models.OrderStatusCancelled {
+		err := applyStockChange(tx, &models.StockMovement{
+			ProductID:   order.ProductID,
+			Change:      order.Quantity,
+			Reason:      models.StockReasonCancel,
+			OrderID:     &order.ID,
+			WarehouseID: order.WarehouseID,
+			ActorID:     actorID,
+			Note:        reason,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	event := models.OrderEvent{
+		OrderID:    order.ID,
+		FromStatus: order.Status,
+		ToStatus:   status,
+		ActorID:    actorID,
+		Reason:     reason,
+	}
+	if err := tx.Create(&event).Error; err != nil {
 		return err
 	}
+	result := tx.Model(order).Where("version = ?", order.Version).
+		Updates(map[string]interface{}{"status": status, "version": order.Version + 1})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var current models.Order
+		if err := tx.Select("version").First(&current, order.ID).Error; err != nil {
+			return err
+		}
+		return &VersionConflictError{Resource: "order", CurrentVersion: current.Version}
+	}
+	order.Status = status
+	order.Version++
 	return nil
 }
 
-// DeleteOrder removes an order from the database using GORM.
-func (r *OrderRepository) DeleteOrder(orderID uint) error {
-	if err := r.db.Delete(&models.Order{}, orderID).Error; err != nil {
+// releasePromotions gives back the uses of the promotions redeemed on an
+// order, decrementing their usage counts and deleting the redemptions so
+// they no longer count towards per-user limits either.
+func releasePromotions(tx *gorm.DB, orderID uint) error {
+	var redemptions []models.PromotionRedemption
+	if err := tx.Where("order_id = ?", orderID).Find(&redemptions).Error; err != nil {
 		return err
 	}
+	if len(redemptions) == 0 {
+		return nil
+	}
+	for _, redemption := range redemptions {
+		err := tx.Model(&models.Promotion{}).
+			Where("id = ? AND usage_count > 0", redemption.PromotionID).
+			UpdateColumn("usage_count", gorm.Expr("usage_count - 1")).Error
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Where("order_id = ?", orderID).Delete(&models.PromotionRedemption{}).Error
+}
+
+// DeleteOrder soft-deletes an order. Open orders cannot be deleted.
+func (r *OrderRepository) DeleteOrder(orderID uint) error {
+	result := r.db.Where("status NOT IN ?", models.OpenOrderStatuses).Delete(&models.Order{}, orderID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetOrderByID(orderID); err != nil {
+			return err
+		}
+		return errors.New("order cannot be deleted while it is open")
+	}
 	return nil
 }
+
+// RestoreOrder brings back a soft-deleted order.
+func (r *OrderRepository) RestoreOrder(orderID uint) error {
+	result := r.db.Unscoped().Model(&models.Order{}).
+		Where("id = ? AND deleted_at IS NOT NULL", orderID).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("order not found")
+	}
+	return nil
+}
+
+// PurgeDeletedOrders permanently removes orders soft-deleted before the
+// cutoff together with their shipments, returns and history. Orders that
+// were invoiced or refunded are kept, since those are financial records.
+// Stock movements keep the ID of the purged order.
+func (r *OrderRepository) PurgeDeletedOrders(cutoff time.Time) (int64, error) {
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		err := tx.Unscoped().Model(&models.Order{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM invoices WHERE invoices.order_id = orders.id)").
+			Where("NOT EXISTS (SELECT 1 FROM refunds WHERE refunds.order_id = orders.id)").
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		shipments := tx.Model(&models.Shipment{}).Select("id").Where("order_id IN ?", ids)
+		if err := tx.Where("shipment_id IN (?)", shipments).Delete(&models.ShipmentItem{}).Error; err != nil {
+			return err
+		}
+		returns := tx.Model(&models.ReturnRequest{}).Select("id").Where("order_id IN ?", ids)
+		if err := tx.Where("return_request_id IN (?)", returns).Delete(&models.ReturnItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("return_request_id IN (?)", returns).Delete(&models.ReturnEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_id IN ?", ids).Delete(&models.ReturnRequest{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_id IN ?", ids).Delete(&models.Shipment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_id IN ?", ids).Delete(&models.PromotionRedemption{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_id IN ?", ids).Delete(&models.OrderTaxLine{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_id IN ?", ids).Delete(&models.OrderEvent{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Delete(&models.Order{}, ids)
+		purged = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
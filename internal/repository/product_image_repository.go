@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ProductImageRepository defines the methods for interacting with product images in the database.
+type ProductImageRepository interface {
+	CreateImage(image *models.ProductImage) error
+	GetImagesByProduct(productID uint) ([]models.ProductImage, error)
+	GetImageByID(id uint) (*models.ProductImage, error)
+	UpdateImage(image *models.ProductImage) error
+	DeleteImage(id uint) error
+	NextSortOrder(productID uint) (int, error)
+}
+
+// productImageRepository implements the ProductImageRepository interface.
+type productImageRepository struct {
+	db *gorm.DB
+}
+
+// NewProductImageRepository creates a new instance of ProductImageRepository.
+func NewProductImageRepository(db *gorm.DB) ProductImageRepository {
+	return &productImageRepository{db: db}
+}
+
+// CreateImage inserts a new product image into the database.
+func (r *productImageRepository) CreateImage(image *models.ProductImage) error {
+	return r.db.Create(image).Error
+}
+
+// GetImagesByProduct retrieves a product's images in display order.
+func (r *productImageRepository) GetImagesByProduct(productID uint) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+	if err := r.db.Where("product_id = ?", productID).Order("sort_order, id").Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// GetImageByID retrieves a product image by its ID.
+func (r *productImageRepository) GetImageByID(id uint) (*models.ProductImage, error) {
+	var image models.ProductImage
+	if err := r.db.First(&image, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("image not found")
+		}
+		return nil, err
+	}
+	return &image, nil
+}
+
+// UpdateImage saves the alt text and sort order of a product image.
+func (r *productImageRepository) UpdateImage(image *models.ProductImage) error {
+	result := r.db.Model(image).Select("alt_text", "sort_order").Updates(image)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("image not found")
+	}
+	return nil
+}
+
+// DeleteImage removes a product image from the database.
+func (r *productImageRepository) DeleteImage(id uint) error {
+	result := r.db.Delete(&models.ProductImage{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("image not found")
+	}
+	return nil
+}
+
+// NextSortOrder returns the sort order that places a new image after a product's existing ones.
+func (r *productImageRepository) NextSortOrder(productID uint) (int, error) {
+	var next int
+	err := r.db.Model(&models.ProductImage{}).
+		Where("product_id = ?", productID).
+		Select("COALESCE(MAX(sort_order) + 1, 0)").
+		Scan(&next).Error
+	return next, err
+}
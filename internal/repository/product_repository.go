@@ -4,17 +4,63 @@ import (
 	"ecommerce-api/internal/models"
 	"errors"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ProductRepository defines the methods for interacting with the products in the database.
 type ProductRepository interface {
 	CreateProduct(product *models.Product) error
 	DeleteProduct(id uint) error
-	GetAllProducts() ([]models.Product, error)
+	RestoreProduct(id uint) error
+	PurgeDeletedProducts(cutoff time.Time) ([]models.ProductImage, int64, error)
+	GetAllProducts(includeDeleted bool) ([]models.Product, error)
 	GetProductByID(id uint) (*models.Product, error)
-	UpdateProduct(updatedProduct *models.Product) (*models.Product, error)
+	GetProductIncludingDeleted(id uint) (*models.Product, error)
+	UpdateProduct(updatedProduct *models.Product, reorderThreshold *int, stock *StockLevel, expectedVersion int) (*models.Product, error)
+	PatchProduct(product *models.Product, changes map[string]interface{}, stock *StockLevel) error
+	UpsertProducts(upserts []ProductUpsert, actorID uint, dryRun bool) (created, updated int, rowErrs map[int]error, err error)
+	EachProduct(batchSize int, fn func(products []models.Product) error) error
+}
+
+// ProductUpsert is a product to create or update by its SKU. When Stock is
+// set, the product's stock is set to it through the inventory ledger.
+// Updating an existing product only writes the Columns given.
+type ProductUpsert struct {
+	Product models.Product
+	Stock   *int
+	Columns []string
+}
+
+// StockLevel sets a product's total stock to Level through the inventory
+// ledger as part of a product update. Movement describes the adjustment and
+// is filled in with what was recorded.
+type StockLevel struct {
+	Level    int
+	Movement models.StockMovement
+}
+
+// VersionConflictError is returned when a row was changed by another request
+// after the caller read it, so the caller's update was not applied.
+type VersionConflictError struct {
+	Resource       string
+	CurrentVersion int
+}
+
+// Error implements the error interface.
+func (e *VersionConflictError) Error() string {
+	return e.Resource + " was modified by another request"
+}
+
+// errDryRun rolls back a dry-run transaction once it has done all its work.
+var errDryRun = errors.New("dry run")
+
+// upsertColumns are the product columns an upsert may overwrite.
+var upsertColumns = map[string]bool{
+	"name": true, "description": true, "category": true, "tax_class": true, "price": true,
+	"reorder_threshold": true, "weight": true, "length": true, "width": true, "height": true,
 }
 
 // productRepository implements the ProductRepository interface.
@@ -27,12 +73,29 @@ func NewProductRepository(db *gorm.DB) ProductRepository {
 	return &productRepository{db: db}
 }
 
-// CreateProduct inserts a new product into the database.
+// CreateProduct inserts a new product into the database. Its initial stock
+// is recorded in the inventory ledger.
 func (r *productRepository) CreateProduct(product *models.Product) error {
-	if err := r.db.Create(product).Error; err != nil {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		stock := product.Stock
+		product.Stock = 0
+		product.Version = 0
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if stock == 0 {
+			return nil
+		}
+
+		err := applyStockChange(tx, &models.StockMovement{
+			ProductID: product.ID,
+			Change:    stock,
+			Reason:    models.StockReasonRestock,
+			Note:      "initial stock",
+		})
+		product.Stock = stock
 		return err
-	}
-	return nil
+	})
 }
 
 // GetProductByID retrieves a product by its ID.
@@ -44,55 +107,341 @@ func (r *productRepository) GetProductByID(id uint) (*models.Product, error) {
 	return &product, nil
 }
 
+// GetProductIncludingDeleted retrieves a product by its ID even when it has
+// been soft-deleted, for order history and admin views.
+func (r *productRepository) GetProductIncludingDeleted(id uint) (*models.Product, error) {
+	var product models.Product
+	if err := r.db.Unscoped().First(&product, id).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
 // UpdateProduct updates a product in the database based on the provided updated product fields.
-func (r *productRepository) UpdateProduct(updatedProduct *models.Product) (*models.Product, error) {
-	// Find the existing product by ID
+// Stock only changes when stock is not nil, through the inventory ledger in
+// the same transaction. A non-nil reorderThreshold is set even when it is
+// zero. The update only applies if the product is still at expectedVersion,
+// or at the version read here when expectedVersion is 0, and it bumps the version.
+func (r *productRepository) UpdateProduct(updatedProduct *models.Product, reorderThreshold *int, stock *StockLevel, expectedVersion int) (*models.Product, error) {
 	var existingProduct models.Product
-	if err := r.db.First(&existingProduct, updatedProduct.ID).Error; err != nil {
-		fmt.Println("Product not found: ", err)
-		return nil, fmt.Errorf("product not found")
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Find the existing product by ID
+		if err := tx.First(&existingProduct, updatedProduct.ID).Error; err != nil {
+			fmt.Println("Product not found: ", err)
+			return fmt.Errorf("product not found")
+		}
+		if expectedVersion != 0 && existingProduct.Version != expectedVersion {
+			return &VersionConflictError{Resource: "product", CurrentVersion: existingProduct.Version}
+		}
+
+		// Update fields only if they are provided (i.e., non-zero values)
+		changes := map[string]interface{}{}
+		if updatedProduct.Name != "" {
+			fmt.Println("Updating Name: ", updatedProduct.Name)
+			changes["name"] = updatedProduct.Name
+		}
+		if updatedProduct.Description != "" {
+			fmt.Println("Updating Description: ", updatedProduct.Description)
+			changes["description"] = updatedProduct.Description
+		}
+		if updatedProduct.Price != 0 {
+			fmt.Println("Updating Price: ", updatedProduct.Price)
+			changes["price"] = updatedProduct.Price
+		}
+		if updatedProduct.SKU != "" {
+			changes["sku"] = updatedProduct.SKU
+		}
+		if updatedProduct.Category != "" {
+			changes["category"] = updatedProduct.Category
+		}
+		if updatedProduct.TaxClass != "" {
+			changes["tax_class"] = updatedProduct.TaxClass
+		}
+		if updatedProduct.Weight != 0 {
+			changes["weight"] = updatedProduct.Weight
+		}
+		if updatedProduct.Length != 0 {
+			changes["length"] = updatedProduct.Length
+		}
+		if updatedProduct.Width != 0 {
+			changes["width"] = updatedProduct.Width
+		}
+		if updatedProduct.Height != 0 {
+			changes["height"] = updatedProduct.Height
+		}
+		if reorderThreshold != nil {
+			changes["reorder_threshold"] = *reorderThreshold
+		}
+
+		// Save the changed columns back to the database. Stock only changes
+		// through the inventory ledger.
+		if err := updateProductVersioned(tx, &existingProduct, changes); err != nil {
+			fmt.Println("Error saving updated product: ", err)
+			return err
+		}
+		return setProductStock(tx, &existingProduct, stock)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Update fields only if they are provided (i.e., non-zero values)
-	if updatedProduct.Name != "" {
-		fmt.Println("Updating Name: ", updatedProduct.Name)
-		existingProduct.Name = updatedProduct.Name
+	return &existingProduct, nil
+}
+
+// PatchProduct writes only the given columns of a product, and sets its
+// stock when stock is not nil, as long as it is still at the version it was
+// read with, and reloads it. Both happen in one transaction.
+func (r *productRepository) PatchProduct(product *models.Product, changes map[string]interface{}, stock *StockLevel) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := updateProductVersioned(tx, product, changes); err != nil {
+			return err
+		}
+		return setProductStock(tx, product, stock)
+	})
+}
+
+// setProductStock sets a product's stock inside a product update's
+// transaction and updates product with the new level.
+func setProductStock(tx *gorm.DB, product *models.Product, stock *StockLevel) error {
+	if stock == nil {
+		return nil
 	}
-	if updatedProduct.Description != "" {
-		fmt.Println("Updating Description: ", updatedProduct.Description)
-		existingProduct.Description = updatedProduct.Description
+	stock.Movement.ProductID = product.ID
+	if err := setStockLevel(tx, &stock.Movement, stock.Level); err != nil {
+		return err
 	}
-	if updatedProduct.Price != 0 {
-		fmt.Println("Updating Price: ", updatedProduct.Price)
-		existingProduct.Price = updatedProduct.Price
+	product.Stock = stock.Movement.StockAfter
+	return nil
+}
+
+// updateProductVersioned writes the changed columns of a product as long as
+// no other update has bumped its version since it was read, and reloads it.
+func updateProductVersioned(db *gorm.DB, product *models.Product, changes map[string]interface{}) error {
+	changes["version"] = product.Version + 1
+	result := db.Model(&models.Product{}).
+		Where("id = ? AND version = ?", product.ID, product.Version).
+		Updates(changes)
+	if result.Error != nil {
+		return result.Error
 	}
-	if updatedProduct.Stock != 0 {
-		fmt.Println("Updating Stock: ", updatedProduct.Stock)
-		existingProduct.Stock = updatedProduct.Stock
+	if result.RowsAffected == 0 {
+		var current models.Product
+		if err := db.First(&current, product.ID).Error; err != nil {
+			return errors.New("product not found")
+		}
+		return &VersionConflictError{Resource: "product", CurrentVersion: current.Version}
 	}
+	return db.First(product, product.ID).Error
+}
 
-	// Save the updated product back to the database
-	if err := r.db.Save(&existingProduct).Error; err != nil {
-		fmt.Println("Error saving updated product: ", err)
-		return nil, err
+// UpsertProducts creates or updates products by SKU in a single transaction
+// and reports how many were created and updated. Stock changes go through
+// the inventory ledger as restocks for new products and adjustments for
+// existing ones, made by actorID. Existing products keep the columns an
+// upsert does not give. Every row is written under its own savepoint, so a
+// row the database rejects is rolled back and returned in rowErrs, keyed by
+// its index in upserts, while the other rows are still written. A dry run
+// does all the work and then rolls it back, so it catches the same errors as
+// a real import.
+func (r *productRepository) UpsertProducts(upserts []ProductUpsert, actorID uint, dryRun bool) (int, int, map[int]error, error) {
+	var created, updated int
+	rowErrs := make(map[int]error)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		skus := make([]string, len(upserts))
+		for i, upsert := range upserts {
+			skus[i] = upsert.Product.SKU
+		}
+		var existing []models.Product
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("sku IN ?", skus).Find(&existing).Error; err != nil {
+			return err
+		}
+		bySKU := make(map[string]models.Product, len(existing))
+		for _, product := range existing {
+			bySKU[product.SKU] = product
+		}
+
+		for i, upsert := range upserts {
+			current, exists := bySKU[upsert.Product.SKU]
+			if exists && current.DeletedAt.Valid {
+				rowErrs[i] = errors.New("sku belongs to a deleted product")
+				continue
+			}
+
+			if err := tx.SavePoint("product_upsert").Error; err != nil {
+				return err
+			}
+			if err := upsertProduct(tx, upsert, current, exists, actorID); err != nil {
+				if err := tx.RollbackTo("product_upsert").Error; err != nil {
+					return err
+				}
+				rowErrs[i] = err
+				continue
+			}
+			if exists {
+				updated++
+			} else {
+				created++
+			}
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return 0, 0, nil, err
 	}
+	return created, updated, rowErrs, nil
+}
 
-	return &existingProduct, nil
+// upsertProduct writes one row of UpsertProducts. An existing product is
+// only updated while it is still at the version it was read with.
+func upsertProduct(tx *gorm.DB, upsert ProductUpsert, current models.Product, exists bool, actorID uint) error {
+	product := upsert.Product
+	movement := &models.StockMovement{ActorID: actorID, Note: "product import"}
+	if exists {
+		product.ID = current.ID
+		product.Version = current.Version + 1
+		columns := []string{"version", "updated_at"}
+		for _, column := range upsert.Columns {
+			if upsertColumns[column] {
+				columns = append(columns, column)
+			}
+		}
+		result := tx.Model(&product).Where("version = ?", current.Version).Select(columns).Updates(&product)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &VersionConflictError{Resource: "product", CurrentVersion: current.Version}
+		}
+		movement.Reason = models.StockReasonAdjustment
+		if upsert.Stock != nil {
+			movement.Change = *upsert.Stock - current.Stock
+		}
+	} else {
+		product.ID = 0
+		product.Stock = 0
+		product.Version = 0
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		movement.Reason = models.StockReasonRestock
+		if upsert.Stock != nil {
+			movement.Change = *upsert.Stock
+		}
+	}
+
+	if movement.Change == 0 {
+		return nil
+	}
+	movement.ProductID = product.ID
+	return applyStockChange(tx, movement)
+}
+
+// EachProduct walks the catalog in ID order, batchSize products at a time.
+func (r *productRepository) EachProduct(batchSize int, fn func(products []models.Product) error) error {
+	var batch []models.Product
+	return r.db.FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
 }
 
-// DeleteProduct removes a product from the database.
+// DeleteProduct soft-deletes a product. Products with open orders cannot be
+// deleted, so the row is locked while those orders are counted.
 func (r *productRepository) DeleteProduct(id uint) error {
-	result := r.db.Delete(&models.Product{}, id)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("product not found")
+			}
+			return err
+		}
+
+		var open int64
+		err := tx.Model(&models.Order{}).
+			Where("product_id = ? AND status IN ?", id, models.OpenOrderStatuses).
+			Count(&open).Error
+		if err != nil {
+			return err
+		}
+		if open > 0 {
+			return errors.New("product has open orders")
+		}
+		return tx.Delete(&product).Error
+	})
+}
+
+// RestoreProduct brings back a soft-deleted product.
+func (r *productRepository) RestoreProduct(id uint) error {
+	result := r.db.Unscoped().Model(&models.Product{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
 	if result.RowsAffected == 0 {
 		return errors.New("product not found")
 	}
-	return result.Error
+	return nil
+}
+
+// PurgeDeletedProducts permanently removes products soft-deleted before the
+// cutoff together with their stock records, wishlist entries and image rows,
+// and returns the removed images so their files can be deleted. Products
+// still referenced by an order, deleted or not, are kept for the order history.
+func (r *productRepository) PurgeDeletedProducts(cutoff time.Time) ([]models.ProductImage, int64, error) {
+	var images []models.ProductImage
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		err := tx.Unscoped().Model(&models.Product{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM orders WHERE orders.product_id = products.id)").
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		if err := tx.Where("product_id IN ?", ids).Find(&images).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id IN ?", ids).Delete(&models.ProductImage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id IN ?", ids).Delete(&models.WarehouseStock{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id IN ?", ids).Delete(&models.StockMovement{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id IN ?", ids).Delete(&models.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id IN ?", ids).Delete(&models.WishlistNotification{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Delete(&models.Product{}, ids)
+		purged = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return images, purged, nil
 }
 
-// GetAllProducts retrieves all products from the database.
-func (r *productRepository) GetAllProducts() ([]models.Product, error) {
+// GetAllProducts retrieves all products from the database. Soft-deleted
+// products are only included when includeDeleted is set.
+func (r *productRepository) GetAllProducts(includeDeleted bool) ([]models.Product, error) {
+	query := r.db
+	if includeDeleted {
+		query = query.Unscoped()
+	}
 	var products []models.Product
-	if err := r.db.Find(&products).Error; err != nil {
+	if err := query.Find(&products).Error; err != nil {
 		return nil, err
 	}
 	return products, nil
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// PromotionRepository defines the methods for interacting with promotions in the database.
+type PromotionRepository interface {
+	CreatePromotion(promotion *models.Promotion) error
+	GetAllPromotions() ([]models.Promotion, error)
+	GetPromotionByID(id uint) (*models.Promotion, error)
+	GetPromotionByCode(code string) (*models.Promotion, error)
+	UpdatePromotion(promotion *models.Promotion) error
+	DeletePromotion(id uint) error
+	CountRedemptionsByUser(promotionID, userID uint) (int64, error)
+}
+
+// promotionRepository implements the PromotionRepository interface.
+type promotionRepository struct {
+	db *gorm.DB
+}
+
+// NewPromotionRepository creates a new instance of PromotionRepository.
+func NewPromotionRepository(db *gorm.DB) PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+// CreatePromotion inserts a new promotion into the database. GORM leaves
+// false out of the insert because the active column has a default, so an
+// inactive promotion has the flag saved after it is created.
+func (r *promotionRepository) CreatePromotion(promotion *models.Promotion) error {
+	active := promotion.Active
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(promotion).Error; err != nil {
+			return err
+		}
+		if active {
+			return nil
+		}
+		promotion.Active = false
+		return tx.Model(promotion).Update("active", false).Error
+	})
+}
+
+// GetAllPromotions retrieves all promotions, newest first.
+func (r *promotionRepository) GetAllPromotions() ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	if err := r.db.Order("id DESC").Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// GetPromotionByID retrieves a promotion by its ID.
+func (r *promotionRepository) GetPromotionByID(id uint) (*models.Promotion, error) {
+	var promotion models.Promotion
+	if err := r.db.First(&promotion, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("promotion not found")
+		}
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// GetPromotionByCode retrieves a promotion by its code, ignoring case.
+func (r *promotionRepository) GetPromotionByCode(code string) (*models.Promotion, error) {
+	var promotion models.Promotion
+	if err := r.db.Where("UPPER(code) = UPPER(?)", code).First(&promotion).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("promotion not found")
+		}
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// UpdatePromotion saves every field of the promotion except its usage count,
+// which is only ever changed when an order redeems it.
+func (r *promotionRepository) UpdatePromotion(promotion *models.Promotion) error {
+	result := r.db.Model(promotion).Select("*").Omit("usage_count", "created_at").Updates(promotion)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("promotion not found")
+	}
+	return nil
+}
+
+// DeletePromotion removes a promotion from the database.
+func (r *promotionRepository) DeletePromotion(id uint) error {
+	result := r.db.Delete(&models.Promotion{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("promotion not found")
+	}
+	return nil
+}
+
+// CountRedemptionsByUser returns how many times a user has redeemed a promotion.
+func (r *promotionRepository) CountRedemptionsByUser(promotionID, userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.PromotionRedemption{}).
+		Where("promotion_id = ? AND user_id = ?", promotionID, userID).
+		Count(&count).Error
+	return count, err
+}
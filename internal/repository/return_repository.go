@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReturnTransitionError is returned when a return request cannot move to the requested status.
+type ReturnTransitionError struct {
+	From string
+	To   string
+}
+
+// Error implements the error interface.
+func (e *ReturnTransitionError) Error() string {
+	return "return cannot move from " + e.From + " to " + e.To
+}
+
+// ReturnRepository defines the methods for interacting with return requests in the database.
+type ReturnRepository interface {
+	CreateReturn(rma *models.ReturnRequest) error
+	GetReturnByID(id uint) (*models.ReturnRequest, error)
+	GetReturnsByOrder(orderID uint) ([]models.ReturnRequest, error)
+	ListReturns(status string) ([]models.ReturnRequest, error)
+	TransitionReturn(id uint, to string, actorID uint, note string) (*models.ReturnRequest, error)
+	ReceiveReturn(id uint, actorID uint, restock bool, note string) (*models.ReturnRequest, error)
+	BeginRefund(id uint, amount float64) (*models.Refund, error)
+	CompleteRefund(refundID uint, reference string, actorID uint, note string) (*models.ReturnRequest, error)
+	FailRefund(refundID uint, reason string) error
+}
+
+// returnRepository implements the ReturnRepository interface.
+type returnRepository struct {
+	db *gorm.DB
+}
+
+// NewReturnRepository creates a new instance of ReturnRepository.
+func NewReturnRepository(db *gorm.DB) ReturnRepository {
+	return &returnRepository{db: db}
+}
+
+// CreateReturn inserts a return request with its first history entry. The
+// order row is locked so that concurrent requests cannot return more items
+// than were ordered; rejected requests do not count.
+func (r *returnRepository) CreateReturn(rma *models.ReturnRequest) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, rma.OrderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("order not found")
+			}
+			return err
+		}
+
+		var returned int64
+		err := tx.Model(&models.ReturnItem{}).
+			Joins("JOIN return_requests ON return_requests.id = return_items.return_request_id").
+			Where("return_requests.order_id = ? AND return_requests.status <> ?", order.ID, models.ReturnStatusRejected).
+			Select("COALESCE(SUM(return_items.quantity), 0)").
+			Scan(&returned).Error
+		if err != nil {
+			return err
+		}
+		for _, item := range rma.Items {
+			returned += int64(item.Quantity)
+		}
+		if returned > int64(order.Quantity) {
+			return errors.New("return exceeds the returnable quantity")
+		}
+
+		rma.Status = models.ReturnStatusRequested
+		rma.History = []models.ReturnEvent{{ToStatus: models.ReturnStatusRequested, ActorID: rma.UserID, Note: rma.Reason}}
+		return tx.Create(rma).Error
+	})
+}
+
+// GetReturnByID retrieves a return request with its items and history.
+func (r *returnRepository) GetReturnByID(id uint) (*models.ReturnRequest, error) {
+	var rma models.ReturnRequest
+	if err := preloadReturnDetails(r.db).First(&rma, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("return not found")
+		}
+		return nil, err
+	}
+	return &rma, nil
+}
+
+// GetReturnsByOrder retrieves the return requests of an order, oldest first.
+func (r *returnRepository) GetReturnsByOrder(orderID uint) ([]models.ReturnRequest, error) {
+	var returns []models.ReturnRequest
+	if err := preloadReturnDetails(r.db).Where("order_id = ?", orderID).Order("id").Find(&returns).Error; err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
+
+// ListReturns retrieves all return requests, optionally in one status, newest first.
+func (r *returnRepository) ListReturns(status string) ([]models.ReturnRequest, error) {
+	query := preloadReturnDetails(r.db)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var returns []models.ReturnRequest
+	if err := query.Order("id DESC").Find(&returns).Error; err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
+
+// TransitionReturn moves a return request to another status and records it in its history.
+func (r *returnRepository) TransitionReturn(id uint, to string, actorID uint, note string) (*models.ReturnRequest, error) {
+	return r.transition(id, to, actorID, note, nil)
+}
+
+// ReceiveReturn marks the goods of a return request as received and, if asked,
+// puts them back into stock.
+func (r *returnRepository) ReceiveReturn(id uint, actorID uint, restock bool, note string) (*models.ReturnRequest, error) {
+	return r.transition(id, models.ReturnStatusReceived, actorID, note, func(tx *gorm.DB, rma *models.ReturnRequest) error {
+		if !restock {
+			return nil
+		}
+		// Returned goods go back to the warehouse the order shipped from
+		var order models.Order
+		if err := tx.Select("id", "warehouse_id").First(&order, rma.OrderID).Error; err != nil {
+			return err
+		}
+		for _, item := range rma.Items {
+			err := applyStockChange(tx, &models.StockMovement{
+				ProductID:   item.ProductID,
+				Change:      item.Quantity,
+				Reason:      models.StockReasonReturn,
+				OrderID:     &rma.OrderID,
+				WarehouseID: order.WarehouseID,
+				ActorID:     actorID,
+				Note:        fmt.Sprintf("return #%d", rma.ID),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Model(rma).Update("restocked", true).Error
+	})
+}
+
+// BeginRefund records a pending refund for a received return request and
+// reserves its amount on the order, before any money moves. The order row is
+// locked so the refunds of an order, pending ones included, can never add up
+// to more than its grand total.
+func (r *returnRepository) BeginRefund(id uint, amount float64) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var rma models.ReturnRequest
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rma, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("return not found")
+			}
+			return err
+		}
+		if !models.CanTransitionReturn(rma.Status, models.ReturnStatusRefunded) {
+			return &ReturnTransitionError{From: rma.Status, To: models.ReturnStatusRefunded}
+		}
+
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, rma.OrderID).Error; err != nil {
+			return err
+		}
+		if amount > order.GrandTotal-order.RefundedTotal+0.005 {
+			return fmt.Errorf("refund exceeds the refundable amount of %.2f", order.GrandTotal-order.RefundedTotal)
+		}
+
+		refund = models.Refund{
+			OrderID:         order.ID,
+			ReturnRequestID: &rma.ID,
+			Amount:          amount,
+			Status:          models.RefundStatusPending,
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			return err
+		}
+		return tx.Model(&order).UpdateColumns(map[string]interface{}{
+			"refunded_total": gorm.Expr("refunded_total + ?", amount),
+			"version":        gorm.Expr("version + 1"),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// CompleteRefund marks a pending refund as paid out under the provider's
+// reference, adds it to the return request and moves the request to refunded.
+func (r *returnRepository) CompleteRefund(refundID uint, reference string, actorID uint, note string) (*models.ReturnRequest, error) {
+	var refund models.Refund
+	if err := r.db.First(&refund, refundID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("refund not found")
+		}
+		return nil, err
+	}
+	if refund.ReturnRequestID == nil {
+		return nil, errors.New("refund does not belong to a return")
+	}
+
+	return r.transition(*refund.ReturnRequestID, models.ReturnStatusRefunded, actorID, note, func(tx *gorm.DB, rma *models.ReturnRequest) error {
+		result := tx.Model(&models.Refund{}).
+			Where("id = ? AND status = ?", refundID, models.RefundStatusPending).
+			Updates(map[string]interface{}{"status": models.RefundStatusCompleted, "provider_reference": reference})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("refund is not pending")
+		}
+		return tx.Model(rma).UpdateColumn("refunded_amount", gorm.Expr("refunded_amount + ?", refund.Amount)).Error
+	})
+}
+
+// FailRefund marks a pending refund that the provider did not pay out as
+// failed and releases its amount on the order, so it can be tried again.
+func (r *returnRepository) FailRefund(refundID uint, reason string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var refund models.Refund
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&refund, refundID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("refund not found")
+			}
+			return err
+		}
+		if refund.Status != models.RefundStatusPending {
+			return errors.New("refund is not pending")
+		}
+
+		err := tx.Model(&refund).Updates(map[string]interface{}{
+			"status":         models.RefundStatusFailed,
+			"failure_reason": reason,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Order{}).Where("id = ?", refund.OrderID).UpdateColumns(map[string]interface{}{
+			"refunded_total": gorm.Expr("refunded_total - ?", refund.Amount),
+			"version":        gorm.Expr("version + 1"),
+		}).Error
+	})
+}
+
+// transition locks a return request, checks that it may move to the new
+// status, runs apply and records the change in the history.
+func (r *returnRepository) transition(id uint, to string, actorID uint, note string, apply func(tx *gorm.DB, rma *models.ReturnRequest) error) (*models.ReturnRequest, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var rma models.ReturnRequest
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&rma, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("return not found")
+			}
+			return err
+		}
+		if !models.CanTransitionReturn(rma.Status, to) {
+			return &ReturnTransitionError{From: rma.Status, To: to}
+		}
+
+		if apply != nil {
+			if err := apply(tx, &rma); err != nil {
+				return err
+			}
+		}
+
+		event := models.ReturnEvent{
+			ReturnRequestID: rma.ID,
+			FromStatus:      rma.Status,
+			ToStatus:        to,
+			ActorID:         actorID,
+			Note:            note,
+		}
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+		return tx.Model(&rma).Update("status", to).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetReturnByID(id)
+}
+
+// preloadReturnDetails loads the records shown together with a return request.
+func preloadReturnDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items").Preload("History", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	})
+}
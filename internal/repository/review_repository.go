@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReviewFilter narrows down, sorts and paginates a review listing.
+type ReviewFilter struct {
+	ProductID uint
+	UserID    uint
+	Status    string
+	SortBy    string
+	Page      int
+	PageSize  int
+}
+
+// reviewSortOrders maps the sort keys accepted by ListReviews to orderings.
+var reviewSortOrders = map[string]string{
+	"newest":      "created_at DESC, id DESC",
+	"oldest":      "created_at ASC, id ASC",
+	"helpful":     "helpful_count DESC, id DESC",
+	"rating_high": "rating DESC, id DESC",
+	"rating_low":  "rating ASC, id DESC",
+}
+
+// IsValidReviewSort reports whether the key can be used as ReviewFilter.SortBy.
+func IsValidReviewSort(key string) bool {
+	_, ok := reviewSortOrders[key]
+	return ok
+}
+
+// ReviewRepository defines the methods for interacting with product reviews in the database.
+type ReviewRepository interface {
+	CreateReview(review *models.Review) error
+	GetReviewByID(id uint) (*models.Review, error)
+	ListReviews(filter ReviewFilter) ([]models.Review, int64, error)
+	UpdateReview(review *models.Review) error
+	SetReviewStatus(id uint, status string, moderatorID uint, note string) (*models.Review, error)
+	DeleteReview(id uint) error
+	AddHelpfulVote(reviewID, userID uint) error
+	RemoveHelpfulVote(reviewID, userID uint) error
+	GetProductRating(productID uint) (*models.ProductRating, error)
+	GetCompletedOrderID(userID, productID uint) (uint, error)
+}
+
+// reviewRepository implements the ReviewRepository interface.
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+// NewReviewRepository creates a new instance of ReviewRepository.
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+// CreateReview inserts a new review. A user can only review a product once.
+func (r *reviewRepository) CreateReview(review *models.Review) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		err := tx.Model(&models.Review{}).
+			Where("product_id = ? AND user_id = ?", review.ProductID, review.UserID).
+			Count(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errors.New("product already reviewed")
+		}
+		return tx.Create(review).Error
+	})
+}
+
+// GetReviewByID retrieves a review by its ID.
+func (r *reviewRepository) GetReviewByID(id uint) (*models.Review, error) {
+	var review models.Review
+	if err := r.db.First(&review, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("review not found")
+		}
+		return nil, err
+	}
+	return &review, nil
+}
+
+// ListReviews retrieves a filtered, sorted page of reviews and the total
+// number of matching reviews.
+func (r *reviewRepository) ListReviews(filter ReviewFilter) ([]models.Review, int64, error) {
+	query := r.db.Model(&models.Review{})
+	if filter.ProductID != 0 {
+		query = query.Where("product_id = ?", filter.ProductID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order, ok := reviewSortOrders[filter.SortBy]
+	if !ok {
+		order = reviewSortOrders["newest"]
+	}
+	var reviews []models.Review
+	err := query.Order(order).
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&reviews).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+// UpdateReview saves a review's rating, title, body and status, and
+// refreshes the product's rating summary in the same transaction.
+func (r *reviewRepository) UpdateReview(review *models.Review) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockProduct(tx, review.ProductID); err != nil {
+			return err
+		}
+		err := tx.Model(review).Select("rating", "title", "body", "status", "moderator_id", "moderation_note", "moderated_at").
+			Updates(review).Error
+		if err != nil {
+			return err
+		}
+		return refreshProductRating(tx, review.ProductID)
+	})
+}
+
+// SetReviewStatus moves a review to a moderation state on behalf of a
+// moderator and refreshes the product's rating summary in the same transaction.
+func (r *reviewRepository) SetReviewStatus(id uint, status string, moderatorID uint, note string) (*models.Review, error) {
+	var review models.Review
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&review, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("review not found")
+			}
+			return err
+		}
+		if _, err := lockProduct(tx, review.ProductID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		review.Status = status
+		review.ModeratorID = &moderatorID
+		review.ModerationNote = note
+		review.ModeratedAt = &now
+		err := tx.Model(&review).Select("status", "moderator_id", "moderation_note", "moderated_at").
+			Updates(&review).Error
+		if err != nil {
+			return err
+		}
+		return refreshProductRating(tx, review.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// DeleteReview removes a review with its votes and refreshes the product's
+// rating summary in the same transaction.
+func (r *reviewRepository) DeleteReview(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.First(&review, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("review not found")
+			}
+			return err
+		}
+		if _, err := lockProduct(tx, review.ProductID); err != nil {
+			return err
+		}
+		if err := tx.Where("review_id = ?", id).Delete(&models.ReviewVote{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&review).Error; err != nil {
+			return err
+		}
+		return refreshProductRating(tx, review.ProductID)
+	})
+}
+
+// AddHelpfulVote records that a user found a review helpful. Each user can
+// vote for a review once.
+func (r *reviewRepository) AddHelpfulVote(reviewID, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, reviewID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("review not found")
+			}
+			return err
+		}
+		var existing int64
+		if err := tx.Model(&models.ReviewVote{}).Where("review_id = ? AND user_id = ?", reviewID, userID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errors.New("review already voted helpful")
+		}
+		if err := tx.Create(&models.ReviewVote{ReviewID: reviewID, UserID: userID}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&review).UpdateColumn("helpful_count", gorm.Expr("helpful_count + 1")).Error
+	})
+}
+
+// RemoveHelpfulVote takes back a user's helpful vote for a review.
+func (r *reviewRepository) RemoveHelpfulVote(reviewID, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("review_id = ? AND user_id = ?", reviewID, userID).Delete(&models.ReviewVote{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("vote not found")
+		}
+		return tx.Model(&models.Review{}).Where("id = ?", reviewID).
+			UpdateColumn("helpful_count", gorm.Expr("helpful_count - 1")).Error
+	})
+}
+
+// GetProductRating retrieves the rating summary of a product. Products
+// without approved reviews get an empty summary.
+func (r *reviewRepository) GetProductRating(productID uint) (*models.ProductRating, error) {
+	rating := models.ProductRating{ProductID: productID}
+	if err := r.db.Where("product_id = ?", productID).Limit(1).Find(&rating).Error; err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
+
+// GetCompletedOrderID returns the ID of the user's most recent completed
+// order for the product, or 0 when they have none.
+func (r *reviewRepository) GetCompletedOrderID(userID, productID uint) (uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Order{}).
+		Where("user_id = ? AND product_id = ? AND status = ?", userID, productID, models.OrderStatusCompleted).
+		Order("id DESC").Limit(1).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// refreshProductRating recounts a product's approved reviews and saves its
+// rating summary. Callers lock the product row first so that concurrent
+// changes to its reviews are counted one after the other.
+func refreshProductRating(tx *gorm.DB, productID uint) error {
+	var counts []struct {
+		Rating int
+		Count  int
+	}
+	err := tx.Model(&models.Review{}).
+		Select("rating, COUNT(*) AS count").
+		Where("product_id = ? AND status = ?", productID, models.ReviewStatusApproved).
+		Group("rating").
+		Scan(&counts).Error
+	if err != nil {
+		return err
+	}
+
+	rating := models.ProductRating{ProductID: productID}
+	stars := map[int]*int{1: &rating.Stars1, 2: &rating.Stars2, 3: &rating.Stars3, 4: &rating.Stars4, 5: &rating.Stars5}
+	sum := 0
+	for _, row := range counts {
+		if star, ok := stars[row.Rating]; ok {
+			*star = row.Count
+			rating.Count += row.Count
+			sum += row.Rating * row.Count
+		}
+	}
+	if rating.Count > 0 {
+		rating.Average = math.Round(float64(sum)/float64(rating.Count)*100) / 100
+	}
+	return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rating).Error
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lastSeenResolution limits how often a session's last seen time is written.
+const lastSeenResolution = time.Minute
+
+// SessionRepository defines the methods for interacting with login sessions in the database.
+type SessionRepository interface {
+	CreateSession(session *models.Session) error
+	GetActiveSessionsByUser(userID uint) ([]models.Session, error)
+	TouchSession(sessionID string) (bool, error)
+	RevokeSession(userID, sessionID uint) error
+	RevokeOtherSessions(userID, keepSessionID uint) (int64, error)
+}
+
+// sessionRepository implements the SessionRepository interface.
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new instance of SessionRepository.
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// CreateSession inserts a new session into the database.
+func (r *sessionRepository) CreateSession(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+// GetActiveSessionsByUser retrieves the sessions of a user that are neither revoked nor expired.
+func (r *sessionRepository) GetActiveSessionsByUser(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// TouchSession reports whether a session is still active and refreshes its last seen time.
+func (r *sessionRepository) TouchSession(sessionID string) (bool, error) {
+	id, err := strconv.ParseUint(sessionID, 10, 32)
+	if err != nil {
+		return false, nil
+	}
+
+	var session models.Session
+	if err := r.db.First(&session, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	now := time.Now()
+	if !session.IsActive(now) {
+		return false, nil
+	}
+
+	if now.Sub(session.LastSeenAt) >= lastSeenResolution {
+		if err := r.db.Model(&session).Update("last_seen_at", now).Error; err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// RevokeSession revokes one of the user's sessions.
+func (r *sessionRepository) RevokeSession(userID, sessionID uint) error {
+	result := r.db.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("session not found")
+	}
+	return nil
+}
+
+// RevokeOtherSessions revokes every active session of the user except the given one.
+func (r *sessionRepository) RevokeOtherSessions(userID, keepSessionID uint) (int64, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ShipmentRepository defines the methods for interacting with shipments in the database.
+type ShipmentRepository interface {
+	CreateShipment(shipment *models.Shipment) error
+	GetShipmentByID(id uint) (*models.Shipment, error)
+	GetShipmentsByOrder(orderID uint) ([]models.Shipment, error)
+	MarkDelivered(id uint, deliveredAt time.Time) (*models.Shipment, error)
+}
+
+// shipmentRepository implements the ShipmentRepository interface.
+type shipmentRepository struct {
+	db *gorm.DB
+}
+
+// NewShipmentRepository creates a new instance of ShipmentRepository.
+func NewShipmentRepository(db *gorm.DB) ShipmentRepository {
+	return &shipmentRepository{db: db}
+}
+
+// CreateShipment inserts a shipment and moves its order to the matching
+// fulfillment status. The order row is locked so that concurrent shipments
+// cannot ship more than was ordered.
+func (r *shipmentRepository) CreateShipment(shipment *models.Shipment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, shipment.OrderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("order not found")
+			}
+			return err
+		}
+		if order.Status == models.OrderStatusCancelled {
+			return errors.New("cancelled orders cannot be shipped")
+		}
+
+		var shipments []models.Shipment
+		if err := tx.Preload("Items").Where("order_id = ?", order.ID).Find(&shipments).Error; err != nil {
+			return err
+		}
+
+		shipped := 0
+		for _, existing := range shipments {
+			for _, item := range existing.Items {
+				shipped += item.Quantity
+			}
+		}
+		for _, item := range shipment.Items {
+			if item.ProductID != order.ProductID {
+				return errors.New("shipment contains a product that is not in the order")
+			}
+			shipped += item.Quantity
+		}
+		if shipped > order.Quantity {
+			return errors.New("shipment exceeds the unshipped quantity")
+		}
+
+		if err := tx.Create(shipment).Error; err != nil {
+			return err
+		}
+		return updateFulfillmentStatus(tx, &order, append(shipments, *shipment))
+	})
+}
+
+// GetShipmentByID retrieves a shipment and its items by the shipment ID.
+func (r *shipmentRepository) GetShipmentByID(id uint) (*models.Shipment, error) {
+	var shipment models.Shipment
+	if err := r.db.Preload("Items").First(&shipment, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("shipment not found")
+		}
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+// GetShipmentsByOrder retrieves the shipments of an order, oldest first.
+func (r *shipmentRepository) GetShipmentsByOrder(orderID uint) ([]models.Shipment, error) {
+	var shipments []models.Shipment
+	if err := r.db.Preload("Items").Where("order_id = ?", orderID).Order("id").Find(&shipments).Error; err != nil {
+		return nil, err
+	}
+	return shipments, nil
+}
+
+// MarkDelivered records when a shipment was delivered and moves its order to
+// the matching fulfillment status.
+func (r *shipmentRepository) MarkDelivered(id uint, deliveredAt time.Time) (*models.Shipment, error) {
+	var shipment models.Shipment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&shipment, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("shipment not found")
+			}
+			return err
+		}
+
+		// Read the shipment again once its order is locked so that two
+		// deliveries of the same shipment cannot both succeed
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, shipment.OrderID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&shipment, id).Error; err != nil {
+			return err
+		}
+
+		if shipment.DeliveredAt != nil {
+			return errors.New("shipment has already been delivered")
+		}
+		if deliveredAt.Before(shipment.ShippedAt) {
+			return errors.New("delivery time cannot be before the shipping time")
+		}
+		if err := tx.Model(&shipment).Update("delivered_at", deliveredAt).Error; err != nil {
+			return err
+		}
+
+		var shipments []models.Shipment
+		if err := tx.Preload("Items").Where("order_id = ?", order.ID).Find(&shipments).Error; err != nil {
+			return err
+		}
+		return updateFulfillmentStatus(tx, &order, shipments)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetShipmentByID(id)
+}
+
+// updateFulfillmentStatus saves the order status implied by its shipments.
+// Shipments only move an order forward; an order an admin already moved
+// further along keeps its status.
+func updateFulfillmentStatus(tx *gorm.DB, order *models.Order, shipments []models.Shipment) error {
+	status := models.FulfillmentStatus(order, shipments)
+	if !models.CanTransitionOrder(order.Status, status) {
+		return nil
+	}
+	return changeOrderStatus(tx, order, status, 0, "shipment update")
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ShippingRepository defines the methods for interacting with shipping zones and methods in the database.
+type ShippingRepository interface {
+	CreateZone(zone *models.ShippingZone) error
+	GetAllZones() ([]models.ShippingZone, error)
+	GetZoneByID(id uint) (*models.ShippingZone, error)
+	UpdateZone(zone *models.ShippingZone) error
+	DeleteZone(id uint) error
+	CreateMethod(method *models.ShippingMethod) error
+	GetMethodByID(id uint) (*models.ShippingMethod, error)
+	UpdateMethod(method *models.ShippingMethod) error
+	DeleteMethod(id uint) error
+}
+
+// shippingRepository implements the ShippingRepository interface.
+type shippingRepository struct {
+	db *gorm.DB
+}
+
+// NewShippingRepository creates a new instance of ShippingRepository.
+func NewShippingRepository(db *gorm.DB) ShippingRepository {
+	return &shippingRepository{db: db}
+}
+
+// CreateZone inserts a new shipping zone into the database.
+func (r *shippingRepository) CreateZone(zone *models.ShippingZone) error {
+	return r.db.Omit("Methods").Create(zone).Error
+}
+
+// GetAllZones retrieves every shipping zone with its methods.
+func (r *shippingRepository) GetAllZones() ([]models.ShippingZone, error) {
+	var zones []models.ShippingZone
+	if err := r.db.Preload("Methods", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Order("id").Find(&zones).Error; err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+// GetZoneByID retrieves a shipping zone and its methods by the zone ID.
+func (r *shippingRepository) GetZoneByID(id uint) (*models.ShippingZone, error) {
+	var zone models.ShippingZone
+	if err := r.db.Preload("Methods", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&zone, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("shipping zone not found")
+		}
+		return nil, err
+	}
+	return &zone, nil
+}
+
+// UpdateZone saves the name and destinations of a shipping zone. Its methods
+// are managed separately.
+func (r *shippingRepository) UpdateZone(zone *models.ShippingZone) error {
+	result := r.db.Model(zone).Select("name", "countries", "regions").Updates(zone)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("shipping zone not found")
+	}
+	return nil
+}
+
+// DeleteZone removes a shipping zone together with its methods.
+func (r *shippingRepository) DeleteZone(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("zone_id = ?", id).Delete(&models.ShippingMethod{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.ShippingZone{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("shipping zone not found")
+		}
+		return nil
+	})
+}
+
+// CreateMethod inserts a new shipping method into the database. GORM leaves
+// false out of the insert because the active column has a default, so an
+// inactive method has the flag saved after it is created.
+func (r *shippingRepository) CreateMethod(method *models.ShippingMethod) error {
+	active := method.Active
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(method).Error; err != nil {
+			return err
+		}
+		if active {
+			return nil
+		}
+		method.Active = false
+		return tx.Model(method).Update("active", false).Error
+	})
+}
+
+// GetMethodByID retrieves a shipping method by its ID.
+func (r *shippingRepository) GetMethodByID(id uint) (*models.ShippingMethod, error) {
+	var method models.ShippingMethod
+	if err := r.db.First(&method, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("shipping method not found")
+		}
+		return nil, err
+	}
+	return &method, nil
+}
+
+// UpdateMethod saves every field of a shipping method except its zone.
+func (r *shippingRepository) UpdateMethod(method *models.ShippingMethod) error {
+	result := r.db.Model(method).Select("*").Omit("zone_id", "created_at").Updates(method)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("shipping method not found")
+	}
+	return nil
+}
+
+// DeleteMethod removes a shipping method from the database.
+func (r *shippingRepository) DeleteMethod(id uint) error {
+	result := r.db.Delete(&models.ShippingMethod{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("shipping method not found")
+	}
+	return nil
+}
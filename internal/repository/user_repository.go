@@ -4,6 +4,7 @@ import (
 	"ecommerce-api/internal/models"
 	"errors"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -55,6 +56,19 @@ func (r *UserRepository) GetUserByID(userID string) (*models.User, error) {
 	return &user, nil
 }
 
+// GetUserWithPasswordByID retrieves a user by their ID, keeping the password hash
+// so that credentials can be checked or changed.
+func (r *UserRepository) GetUserWithPasswordByID(userID uint) (*models.User, error) {
+	var user models.User
+	if err := r.DB.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // User not found
+		}
+		return nil, fmt.Errorf("could not get user: %w", err)
+	}
+	return &user, nil
+}
+
 // UpdateUser updates the user's information in the database.
 func (r *UserRepository) UpdateUser(user *models.User) error {
 	// Using GORM Save method to update the user's details
@@ -64,11 +78,226 @@ func (r *UserRepository) UpdateUser(user *models.User) error {
 	return nil
 }
 
-// DeleteUser removes a user from the database.
+// UpdatePasswordHash replaces the stored password hash of a user.
+func (r *UserRepository) UpdatePasswordHash(userID uint, hash string) error {
+	if err := r.DB.Model(&models.User{ID: userID}).Update("password", hash).Error; err != nil {
+		return fmt.Errorf("could not update password: %w", err)
+	}
+	return nil
+}
+
+// SetTaxExempt sets the tax exemption of a user.
+func (r *UserRepository) SetTaxExempt(id uint, exempt bool) error {
+	result := r.DB.Model(&models.User{}).Where("id = ?", id).Update("tax_exempt", exempt)
+	if result.Error != nil {
+		return fmt.Errorf("could not update tax exemption: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// DeleteUser soft-deletes a user. The row is kept so the user's orders
+// still point to it.
 func (r *UserRepository) DeleteUser(id uint) error {
-	// Using GORM Delete method to remove the user by their ID
-	if err := r.DB.Delete(&models.User{}, id).Error; err != nil {
-		return fmt.Errorf("could not delete user: %w", err)
+	result := r.DB.Delete(&models.User{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("could not delete user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("user not found")
 	}
 	return nil
 }
+
+// GetDeletedUsers retrieves the soft-deleted users, most recently deleted first.
+func (r *UserRepository) GetDeletedUsers() ([]models.User, error) {
+	var users []models.User
+	if err := r.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("could not get deleted users: %w", err)
+	}
+	for i := range users {
+		users[i].Password = ""
+	}
+	return users, nil
+}
+
+// RestoreUser brings back a soft-deleted user.
+func (r *UserRepository) RestoreUser(id uint) error {
+	result := r.DB.Unscoped().Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return fmt.Errorf("could not restore user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// PurgeDeletedUsers permanently removes users soft-deleted before the cutoff
+// together with their addresses, sessions, tokens, requests and wishlists.
+// Users still referenced by an order, deleted or not, are kept for the order
+// history.
+func (r *UserRepository) PurgeDeletedUsers(cutoff time.Time) (int64, error) {
+	var purged int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		err := tx.Unscoped().Model(&models.User{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id)").
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.Address{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.ErasureRequest{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.WishlistNotification{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Delete(&models.User{}, ids)
+		purged = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not purge deleted users: %w", err)
+	}
+	return purged, nil
+}
+
+// CreateErasureRequest records a right-to-be-forgotten request for a user.
+// An existing pending request is returned instead of creating a duplicate.
+func (r *UserRepository) CreateErasureRequest(userID uint) (*models.ErasureRequest, error) {
+	var request models.ErasureRequest
+	err := r.DB.Where("user_id = ? AND status = ?", userID, models.ErasureStatusPending).First(&request).Error
+	if err == nil {
+		return &request, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("could not get erasure request: %w", err)
+	}
+
+	request = models.ErasureRequest{UserID: userID, Status: models.ErasureStatusPending}
+	if err := r.DB.Create(&request).Error; err != nil {
+		return nil, fmt.Errorf("could not create erasure request: %w", err)
+	}
+	return &request, nil
+}
+
+// GetPendingErasureRequests retrieves erasure requests that have not been processed yet.
+func (r *UserRepository) GetPendingErasureRequests(limit int) ([]models.ErasureRequest, error) {
+	var requests []models.ErasureRequest
+	if err := r.DB.Where("status = ?", models.ErasureStatusPending).Order("id").Limit(limit).Find(&requests).Error; err != nil {
+		return nil, fmt.Errorf("could not get erasure requests: %w", err)
+	}
+	return requests, nil
+}
+
+// UpdateErasureRequest saves the outcome of an erasure request.
+func (r *UserRepository) UpdateErasureRequest(request *models.ErasureRequest) error {
+	if err := r.DB.Save(request).Error; err != nil {
+		return fmt.Errorf("could not update erasure request: %w", err)
+	}
+	return nil
+}
+
+// AnonymizeUser replaces the user's personal data with placeholders, removes
+// their saved addresses and wishlist, strips the street address from their orders and ends
+// their sessions. Orders are kept so financial records stay intact. Soft-deleted
+// users and orders are erased too.
+func (r *UserRepository) AnonymizeUser(userID uint, erasedAt time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// Updating through a column map skips the plain-text guard in BeforeSave, so
+		// the placeholder is stored verbatim and can never verify against a hash.
+		result := tx.Unscoped().Model(&models.User{ID: userID}).Updates(map[string]interface{}{
+			"email":     fmt.Sprintf("erased-%d@erased.invalid", userID),
+			"name":      "",
+			"password":  "!",
+			"erased_at": erasedAt,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("could not anonymize user: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("user not found")
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Address{}).Error; err != nil {
+			return fmt.Errorf("could not delete addresses: %w", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.WishlistItem{}).Error; err != nil {
+			return fmt.Errorf("could not delete wishlist: %w", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.WishlistNotification{}).Error; err != nil {
+			return fmt.Errorf("could not delete wishlist notifications: %w", err)
+		}
+
+		// Country and region stay on orders because tax records depend on them
+		err := tx.Unscoped().Model(&models.Order{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"shipping_name":        "",
+			"shipping_line1":       "",
+			"shipping_line2":       "",
+			"shipping_city":        "",
+			"shipping_postal_code": "",
+			"version":              gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return fmt.Errorf("could not anonymize order addresses: %w", err)
+		}
+
+		if err := tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", erasedAt).Error; err != nil {
+			return fmt.Errorf("could not revoke sessions: %w", err)
+		}
+		return nil
+	})
+}
+
+// CreatePasswordResetToken stores a new password reset token.
+func (r *UserRepository) CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	if err := r.DB.Create(token).Error; err != nil {
+		return fmt.Errorf("could not create password reset token: %w", err)
+	}
+	return nil
+}
+
+// ConsumePasswordResetToken marks an unused, unexpired token as used and returns it.
+// The update is conditional so a token can only ever be consumed once.
+func (r *UserRepository) ConsumePasswordResetToken(tokenHash string, now time.Time) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := r.DB.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid or expired reset token")
+		}
+		return nil, fmt.Errorf("could not get password reset token: %w", err)
+	}
+
+	result := r.DB.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", token.ID, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, fmt.Errorf("could not consume password reset token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("invalid or expired reset token")
+	}
+	return &token, nil
+}
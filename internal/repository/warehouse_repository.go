@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// WarehouseRepository defines the methods for interacting with warehouses in the database.
+// Warehouses are deactivated rather than deleted, since orders and the
+// inventory ledger keep referring to them.
+type WarehouseRepository interface {
+	CreateWarehouse(warehouse *models.Warehouse) error
+	GetAllWarehouses() ([]models.Warehouse, error)
+	GetWarehouseByID(id uint) (*models.Warehouse, error)
+	UpdateWarehouse(warehouse *models.Warehouse) error
+}
+
+// warehouseRepository implements the WarehouseRepository interface.
+type warehouseRepository struct {
+	db *gorm.DB
+}
+
+// NewWarehouseRepository creates a new instance of WarehouseRepository.
+func NewWarehouseRepository(db *gorm.DB) WarehouseRepository {
+	return &warehouseRepository{db: db}
+}
+
+// CreateWarehouse inserts a new warehouse into the database. GORM leaves
+// false out of the insert because the active column has a default, so an
+// inactive warehouse has the flag saved after it is created.
+func (r *warehouseRepository) CreateWarehouse(warehouse *models.Warehouse) error {
+	active := warehouse.Active
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(warehouse).Error; err != nil {
+			return err
+		}
+		if active {
+			return nil
+		}
+		warehouse.Active = false
+		return tx.Model(warehouse).Update("active", false).Error
+	})
+}
+
+// GetAllWarehouses retrieves every warehouse.
+func (r *warehouseRepository) GetAllWarehouses() ([]models.Warehouse, error) {
+	var warehouses []models.Warehouse
+	if err := r.db.Order("id").Find(&warehouses).Error; err != nil {
+		return nil, err
+	}
+	return warehouses, nil
+}
+
+// GetWarehouseByID retrieves a warehouse by its ID.
+func (r *warehouseRepository) GetWarehouseByID(id uint) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	if err := r.db.First(&warehouse, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("warehouse not found")
+		}
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+// UpdateWarehouse saves every field of a warehouse.
+func (r *warehouseRepository) UpdateWarehouse(warehouse *models.Warehouse) error {
+	result := r.db.Model(warehouse).Select("*").Omit("created_at").Updates(warehouse)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("warehouse not found")
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"ecommerce-api/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WishlistRepository defines the methods for interacting with wishlists and
+// their pending notifications in the database.
+type WishlistRepository interface {
+	GetWishlist(userID uint) ([]models.WishlistItem, error)
+	AddItem(item *models.WishlistItem) error
+	SetNotify(userID, productID uint, notify bool) (*models.WishlistItem, error)
+	RemoveItem(userID, productID uint) error
+	GetItemByToken(token string) (*models.WishlistItem, error)
+	UnsubscribeByToken(token string) (*models.WishlistItem, error)
+	QueueNotifications(productID uint, kind string, oldPrice, newPrice float64) (int64, error)
+	GetUsersWithPendingNotifications(lastSentBefore time.Time, limit int) ([]uint, error)
+	GetPendingNotifications(userID uint) ([]models.WishlistNotification, error)
+	MarkNotificationsSent(ids []uint, sentAt time.Time) error
+	DeleteNotifications(ids []uint) error
+	DeleteSentNotifications(cutoff time.Time) (int64, error)
+}
+
+// wishlistRepository implements the WishlistRepository interface.
+type wishlistRepository struct {
+	db *gorm.DB
+}
+
+// NewWishlistRepository creates a new instance of WishlistRepository.
+func NewWishlistRepository(db *gorm.DB) WishlistRepository {
+	return &wishlistRepository{db: db}
+}
+
+// GetWishlist retrieves a user's wishlist, most recently added first, with
+// the products. Soft-deleted products are left out.
+func (r *wishlistRepository) GetWishlist(userID uint) ([]models.WishlistItem, error) {
+	var items []models.WishlistItem
+	err := r.db.Preload("Product").
+		Where("user_id = ?", userID).
+		Where("EXISTS (SELECT 1 FROM products WHERE products.id = wishlist_items.product_id AND products.deleted_at IS NULL)").
+		Order("id DESC").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// AddItem saves a product to a user's wishlist. Each product can be saved once.
+func (r *wishlistRepository) AddItem(item *models.WishlistItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		err := tx.Model(&models.WishlistItem{}).
+			Where("user_id = ? AND product_id = ?", item.UserID, item.ProductID).
+			Count(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errors.New("product already in wishlist")
+		}
+		return tx.Create(item).Error
+	})
+}
+
+// SetNotify turns notifications about a wishlisted product on or off.
+func (r *wishlistRepository) SetNotify(userID, productID uint, notify bool) (*models.WishlistItem, error) {
+	var item models.WishlistItem
+	if err := r.db.Where("user_id = ? AND product_id = ?", userID, productID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("wishlist item not found")
+		}
+		return nil, err
+	}
+	if err := r.setNotify(&item, notify); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// RemoveItem removes a product from a user's wishlist along with the
+// notifications about it still waiting to be sent.
+func (r *wishlistRepository) RemoveItem(userID, productID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND product_id = ?", userID, productID).Delete(&models.WishlistItem{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("wishlist item not found")
+		}
+		return tx.Where("user_id = ? AND product_id = ? AND sent_at IS NULL", userID, productID).
+			Delete(&models.WishlistNotification{}).Error
+	})
+}
+
+// GetItemByToken retrieves the wishlist item an unsubscribe link was made
+// for, with its product.
+func (r *wishlistRepository) GetItemByToken(token string) (*models.WishlistItem, error) {
+	var item models.WishlistItem
+	if err := r.db.Preload("Product").Where("unsubscribe_token = ?", token).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid unsubscribe link")
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// UnsubscribeByToken turns off notifications for the wishlist item an
+// unsubscribe link was made for.
+func (r *wishlistRepository) UnsubscribeByToken(token string) (*models.WishlistItem, error) {
+	var item models.WishlistItem
+	if err := r.db.Where("unsubscribe_token = ?", token).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid unsubscribe link")
+		}
+		return nil, err
+	}
+	if err := r.setNotify(&item, false); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// setNotify saves the notify flag of a wishlist item. Turning it off also
+// drops the item's notifications still waiting to be sent.
+func (r *wishlistRepository) setNotify(item *models.WishlistItem, notify bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(item).Update("notify", notify).Error; err != nil {
+			return err
+		}
+		if notify {
+			return nil
+		}
+		return tx.Where("user_id = ? AND product_id = ? AND sent_at IS NULL", item.UserID, item.ProductID).
+			Delete(&models.WishlistNotification{}).Error
+	})
+}
+
+// QueueNotifications queues a notification about a product change for every
+// user subscribed to the product. A user with a notification of the same
+// kind still pending gets that one updated instead, so repeated changes
+// between two digests are reported once. It returns how many users were notified.
+func (r *wishlistRepository) QueueNotifications(productID uint, kind string, oldPrice, newPrice float64) (int64, error) {
+	var queued int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var userIDs []uint
+		err := tx.Model(&models.WishlistItem{}).
+			Where("product_id = ? AND notify = ?", productID, true).
+			Pluck("user_id", &userIDs).Error
+		if err != nil || len(userIDs) == 0 {
+			return err
+		}
+
+		var pending []models.WishlistNotification
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ? AND kind = ? AND sent_at IS NULL AND user_id IN ?", productID, kind, userIDs).
+			Find(&pending).Error
+		if err != nil {
+			return err
+		}
+		alreadyPending := make(map[uint]bool, len(pending))
+		for _, notification := range pending {
+			alreadyPending[notification.UserID] = true
+		}
+		if len(pending) > 0 {
+			ids := make([]uint, len(pending))
+			for i, notification := range pending {
+				ids[i] = notification.ID
+			}
+			if err := tx.Model(&models.WishlistNotification{}).Where("id IN ?", ids).Update("new_price", newPrice).Error; err != nil {
+				return err
+			}
+		}
+
+		var notifications []models.WishlistNotification
+		for _, userID := range userIDs {
+			if !alreadyPending[userID] {
+				notifications = append(notifications, models.WishlistNotification{
+					UserID:    userID,
+					ProductID: productID,
+					Kind:      kind,
+					OldPrice:  oldPrice,
+					NewPrice:  newPrice,
+				})
+			}
+		}
+		if len(notifications) > 0 {
+			if err := tx.CreateInBatches(notifications, 500).Error; err != nil {
+				return err
+			}
+		}
+		queued = int64(len(userIDs))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return queued, nil
+}
+
+// GetUsersWithPendingNotifications retrieves users with notifications
+// waiting to be sent who were last sent a digest before lastSentBefore, or
+// never.
+func (r *wishlistRepository) GetUsersWithPendingNotifications(lastSentBefore time.Time, limit int) ([]uint, error) {
+	var userIDs []uint
+	recentlyNotified := r.db.Model(&models.WishlistNotification{}).
+		Select("user_id").
+		Where("sent_at >= ?", lastSentBefore)
+	err := r.db.Model(&models.WishlistNotification{}).
+		Distinct("user_id").
+		Where("sent_at IS NULL").
+		Where("user_id NOT IN (?)", recentlyNotified).
+		Order("user_id").
+		Limit(limit).
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// GetPendingNotifications retrieves a user's notifications waiting to be sent.
+func (r *wishlistRepository) GetPendingNotifications(userID uint) ([]models.WishlistNotification, error) {
+	var notifications []models.WishlistNotification
+	if err := r.db.Where("user_id = ? AND sent_at IS NULL", userID).Order("id").Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// MarkNotificationsSent records that notifications went out in a digest.
+func (r *wishlistRepository) MarkNotificationsSent(ids []uint, sentAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.WishlistNotification{}).Where("id IN ?", ids).Update("sent_at", sentAt).Error
+}
+
+// DeleteNotifications drops notifications that will not be sent.
+func (r *wishlistRepository) DeleteNotifications(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Delete(&models.WishlistNotification{}, ids).Error
+}
+
+// DeleteSentNotifications removes notifications sent before the cutoff.
+func (r *wishlistRepository) DeleteSentNotifications(cutoff time.Time) (int64, error) {
+	result := r.db.Where("sent_at < ?", cutoff).Delete(&models.WishlistNotification{})
+	return result.RowsAffected, result.Error
+}
@@ -4,6 +4,8 @@ import (
 	_ "ecommerce-api/docs"
 	"ecommerce-api/internal/auth"
 	"ecommerce-api/internal/controllers"
+	"ecommerce-api/internal/middleware"
+	"expvar"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -16,6 +18,20 @@ func SetupRoutes(
 	userController *controllers.UserController,
 	productController *controllers.ProductController,
 	orderController *controllers.OrderController,
+	privacyController *controllers.PrivacyController,
+	impersonationController *controllers.ImpersonationController,
+	promotionController *controllers.PromotionController,
+	shippingController *controllers.ShippingController,
+	shipmentController *controllers.ShipmentController,
+	returnController *controllers.ReturnController,
+	invoiceController *controllers.InvoiceController,
+	inventoryController *controllers.InventoryController,
+	productImageController *controllers.ProductImageController,
+	reviewController *controllers.ReviewController,
+	wishlistController *controllers.WishlistController,
+	sessionChecker auth.SessionChecker,
+	auditWriter auth.AuditWriter,
+	idempotencyStore middleware.IdempotencyStore,
 ) {
 	// Swagger documentation route
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -24,10 +40,29 @@ func SetupRoutes(
 	router.POST("/api/users/login", userController.LoginUser)
 	router.POST("/api/users/logout", userController.LogoutUser)
 	router.POST("/api/users/register", userController.RegisterUser)
+	router.POST("/api/users/password-reset", userController.RequestPasswordReset)
+	router.POST("/api/users/password-reset/confirm", userController.ResetPassword)
+
+	// Product image files are public so storefronts and CDNs can fetch them
+	router.GET("/api/images/:imageId/:variant", productImageController.ServeImage)
+
+	// Approved reviews are public
+	router.GET("/api/products/:id/reviews", reviewController.GetProductReviews)
+
+	// Unsubscribe links in wishlist notifications work without logging in.
+	// Following a link only confirms it; the POST unsubscribes
+	router.GET("/api/wishlist/unsubscribe/:token", wishlistController.ConfirmUnsubscribe)
+	router.POST("/api/wishlist/unsubscribe/:token", wishlistController.Unsubscribe)
 
 	// Protected routes
 	authorized := router.Group("/")
-	authorized.Use(auth.JWTMiddleware())
+	authorized.Use(auth.JWTMiddleware(sessionChecker), auth.ImpersonationAudit(auditWriter))
+
+	// Sensitive actions that an impersonating admin may not perform
+	noImpersonation := auth.DenyImpersonation()
+
+	// Retry-safe endpoints honouring the Idempotency-Key header
+	idempotent := middleware.Idempotency(idempotencyStore)
 
 	// Product routes (admin only)
 	authorizedAdmin := authorized.Group("/")
@@ -35,13 +70,98 @@ func SetupRoutes(
 	authorizedAdmin.GET("/api/products", productController.GetProducts)
 	authorizedAdmin.POST("/api/products", productController.CreateProduct)
 	authorizedAdmin.PUT("/api/products/:id", productController.UpdateProduct)
+	authorizedAdmin.PATCH("/api/products/:id", productController.PatchProduct)
 	authorizedAdmin.GET("/api/products/:id", productController.GetProductByID)
 	authorizedAdmin.DELETE("/api/products/:id", productController.DeleteProduct)
+	authorizedAdmin.POST("/api/admin/products/:id/restore", productController.RestoreProduct)
+	authorizedAdmin.GET("/api/products/:id/images", productImageController.GetImages)
+	authorizedAdmin.POST("/api/admin/products/:id/images", productImageController.UploadImage)
+	authorizedAdmin.PUT("/api/admin/products/:id/images/:imageId", productImageController.UpdateImage)
+	authorizedAdmin.DELETE("/api/admin/products/:id/images/:imageId", productImageController.DeleteImage)
+	authorizedAdmin.POST("/api/admin/products/import", productController.ImportProducts)
+	authorizedAdmin.GET("/api/admin/products/export", productController.ExportProducts)
 	authorizedAdmin.PUT("/api/orders/:id/status", orderController.UpdateOrderStatus)
+	authorizedAdmin.GET("/api/admin/orders", orderController.AdminListOrders)
+	authorizedAdmin.PUT("/api/admin/orders/:id/cancel", orderController.AdminCancelOrder)
+	authorizedAdmin.PUT("/api/admin/orders/:id/paid", orderController.AdminMarkOrderPaid)
+	authorizedAdmin.DELETE("/api/admin/orders/:id", orderController.AdminDeleteOrder)
+	authorizedAdmin.POST("/api/admin/orders/:id/restore", orderController.AdminRestoreOrder)
+	authorizedAdmin.GET("/api/admin/orders/:id/shipments", shipmentController.GetShipments)
+	authorizedAdmin.POST("/api/admin/orders/:id/shipments", shipmentController.CreateShipment)
+	authorizedAdmin.PUT("/api/admin/shipments/:id/delivered", shipmentController.MarkDelivered)
+	authorizedAdmin.GET("/api/admin/returns", returnController.AdminListReturns)
+	authorizedAdmin.GET("/api/admin/returns/:id", returnController.AdminGetReturn)
+	authorizedAdmin.PUT("/api/admin/returns/:id/approve", returnController.ApproveReturn)
+	authorizedAdmin.PUT("/api/admin/returns/:id/reject", returnController.RejectReturn)
+	authorizedAdmin.PUT("/api/admin/returns/:id/receive", returnController.ReceiveReturn)
+	authorizedAdmin.POST("/api/admin/returns/:id/refund", noImpersonation, returnController.RefundReturn)
+	authorizedAdmin.GET("/api/admin/reviews", reviewController.AdminListReviews)
+	authorizedAdmin.PUT("/api/admin/reviews/:id/approve", reviewController.ApproveReview)
+	authorizedAdmin.PUT("/api/admin/reviews/:id/reject", reviewController.RejectReview)
+	authorizedAdmin.DELETE("/api/admin/reviews/:id", reviewController.AdminDeleteReview)
+	authorizedAdmin.POST("/api/admin/inventory/adjust", inventoryController.AdjustStock)
+	authorizedAdmin.POST("/api/admin/inventory/transfers", inventoryController.TransferStock)
+	authorizedAdmin.GET("/api/admin/inventory/reorder-report", inventoryController.GetReorderReport)
+	authorizedAdmin.GET("/api/admin/inventory/products/:id/movements", inventoryController.GetMovements)
+	authorizedAdmin.GET("/api/admin/inventory/products/:id/stock", inventoryController.GetProductStock)
+	authorizedAdmin.GET("/api/admin/warehouses", inventoryController.GetWarehouses)
+	authorizedAdmin.POST("/api/admin/warehouses", inventoryController.CreateWarehouse)
+	authorizedAdmin.GET("/api/admin/warehouses/:id", inventoryController.GetWarehouseByID)
+	authorizedAdmin.PUT("/api/admin/warehouses/:id", inventoryController.UpdateWarehouse)
+	authorizedAdmin.GET("/api/admin/promotions", promotionController.GetPromotions)
+	authorizedAdmin.POST("/api/admin/promotions", promotionController.CreatePromotion)
+	authorizedAdmin.GET("/api/admin/promotions/:id", promotionController.GetPromotionByID)
+	authorizedAdmin.PUT("/api/admin/promotions/:id", promotionController.UpdatePromotion)
+	authorizedAdmin.DELETE("/api/admin/promotions/:id", promotionController.DeletePromotion)
+	authorizedAdmin.GET("/api/admin/shipping/zones", shippingController.GetZones)
+	authorizedAdmin.POST("/api/admin/shipping/zones", shippingController.CreateZone)
+	authorizedAdmin.GET("/api/admin/shipping/zones/:id", shippingController.GetZoneByID)
+	authorizedAdmin.PUT("/api/admin/shipping/zones/:id", shippingController.UpdateZone)
+	authorizedAdmin.DELETE("/api/admin/shipping/zones/:id", shippingController.DeleteZone)
+	authorizedAdmin.POST("/api/admin/shipping/zones/:id/methods", shippingController.CreateMethod)
+	authorizedAdmin.PUT("/api/admin/shipping/methods/:id", shippingController.UpdateMethod)
+	authorizedAdmin.DELETE("/api/admin/shipping/methods/:id", shippingController.DeleteMethod)
+	authorizedAdmin.POST("/api/admin/users/:id/impersonate", impersonationController.ImpersonateUser)
+	authorizedAdmin.GET("/api/admin/users/deleted", userController.GetDeletedUsers)
+	authorizedAdmin.DELETE("/api/admin/users/:id", userController.DeleteUser)
+	authorizedAdmin.POST("/api/admin/users/:id/restore", userController.RestoreUser)
+	authorizedAdmin.PUT("/api/admin/users/:id/tax-exempt", userController.SetTaxExempt)
+	authorizedAdmin.GET("/api/admin/metrics", gin.WrapH(expvar.Handler()))
 
-	// Order routes
+	// User routes
 	authorized.GET("/api/users", userController.GetUser)
+	authorized.PUT("/api/users/me/password", noImpersonation, userController.ChangePassword)
+	authorized.GET("/api/users/me/addresses", userController.ListAddresses)
+	authorized.POST("/api/users/me/addresses", userController.AddAddress)
+	authorized.DELETE("/api/users/me/addresses/:id", userController.DeleteAddress)
+	authorized.GET("/api/users/me/sessions", userController.ListSessions)
+	authorized.DELETE("/api/users/me/sessions/:id", noImpersonation, userController.RevokeSession)
+	authorized.POST("/api/users/me/sessions/revoke-others", noImpersonation, userController.RevokeOtherSessions)
+	authorized.GET("/api/users/me/export", noImpersonation, privacyController.ExportUserData)
+	authorized.POST("/api/users/me/erasure", noImpersonation, privacyController.RequestErasure)
+	authorized.GET("/api/users/me/wishlist", wishlistController.GetWishlist)
+	authorized.POST("/api/users/me/wishlist", wishlistController.AddToWishlist)
+	authorized.PUT("/api/users/me/wishlist/:productId", wishlistController.UpdateWishlistItem)
+	authorized.DELETE("/api/users/me/wishlist/:productId", wishlistController.RemoveFromWishlist)
+
+	// Order routes
 	authorized.GET("/api/orders", orderController.ListOrders)
-	authorized.POST("/api/orders", orderController.PlaceOrder)
+	authorized.GET("/api/orders/:id", orderController.GetOrder)
+	authorized.POST("/api/orders", noImpersonation, idempotent, orderController.PlaceOrder)
 	authorized.PUT("/api/orders/:id/cancel", orderController.CancelOrder)
+	authorized.GET("/api/orders/:id/returns", returnController.GetOrderReturns)
+	authorized.GET("/api/orders/:id/invoice.pdf", invoiceController.GetInvoicePDF)
+	authorized.GET("/api/orders/:id/credit-notes", invoiceController.ListCreditNotes)
+	authorized.GET("/api/orders/:id/credit-notes/:noteId/pdf", invoiceController.GetCreditNotePDF)
+	authorized.POST("/api/orders/:id/returns", returnController.RequestReturn)
+
+	// Review routes
+	authorized.POST("/api/products/:id/reviews", reviewController.CreateReview)
+	authorized.PUT("/api/reviews/:id", reviewController.UpdateReview)
+	authorized.DELETE("/api/reviews/:id", reviewController.DeleteReview)
+	authorized.POST("/api/reviews/:id/helpful", reviewController.VoteHelpful)
+	authorized.DELETE("/api/reviews/:id/helpful", reviewController.RemoveHelpfulVote)
+
+	// Checkout routes
+	authorized.POST("/api/checkout/shipping-rates", shippingController.GetShippingRates)
 }
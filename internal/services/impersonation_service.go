@@ -0,0 +1,91 @@
+package services
+
+import (
+	"ecommerce-api/internal/auth"
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/repository"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ImpersonationService lets admins act as a customer to reproduce their problems.
+type ImpersonationService struct {
+	userRepo    *repository.UserRepository
+	sessionRepo repository.SessionRepository
+	auditRepo   repository.AuditRepository
+}
+
+// NewImpersonationService creates a new ImpersonationService instance.
+func NewImpersonationService(
+	userRepo *repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	auditRepo repository.AuditRepository,
+) *ImpersonationService {
+	return &ImpersonationService{userRepo: userRepo, sessionRepo: sessionRepo, auditRepo: auditRepo}
+}
+
+// ImpersonationGrant is a short-lived token that lets an admin act as a user.
+type ImpersonationGrant struct {
+	Token     string    `json:"token"`
+	UserID    uint      `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Impersonate opens a short-lived session on behalf of the target user and
+// records who opened it and why.
+func (s *ImpersonationService) Impersonate(adminID, userID uint, reason, userAgent, ip string) (*ImpersonationGrant, error) {
+	if adminID == userID {
+		return nil, errors.New("admins cannot impersonate themselves")
+	}
+
+	user, err := s.userRepo.GetUserByID(strconv.Itoa(int(userID)))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.ErasedAt != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Role == "admin" {
+		return nil, errors.New("admins cannot be impersonated")
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		UserID:         user.ID,
+		UserAgent:      userAgent,
+		IP:             ip,
+		LastSeenAt:     now,
+		ExpiresAt:      now.Add(auth.ImpersonationTokenTTL),
+		ImpersonatorID: &adminID,
+	}
+	if err := s.sessionRepo.CreateSession(session); err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateImpersonationToken(
+		strconv.Itoa(int(user.ID)),
+		user.Role,
+		strconv.Itoa(int(session.ID)),
+		strconv.Itoa(int(adminID)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.AuditLog{
+		ActorID:    adminID,
+		Action:     models.AuditActionImpersonationStarted,
+		TargetType: "user",
+		TargetID:   user.ID,
+		Details:    fmt.Sprintf("session %d from %s: %s", session.ID, ip, reason),
+	}
+	if err := s.auditRepo.CreateEntry(entry); err != nil {
+		// Impersonation without an audit trail is not allowed
+		_ = s.sessionRepo.RevokeSession(user.ID, session.ID)
+		return nil, err
+	}
+
+	return &ImpersonationGrant{Token: token, UserID: user.ID, ExpiresAt: session.ExpiresAt}, nil
+}
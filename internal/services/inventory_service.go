@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"ecommerce-api/internal/inventory"
+	"ecommerce-api/internal/logger"
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/notify"
+	"ecommerce-api/internal/repository"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// lowStockAlertTimeout bounds how long delivering a low-stock alert may take.
+const lowStockAlertTimeout = 30 * time.Second
+
+// InventorySettings configures warehouse allocation and low-stock alerts.
+type InventorySettings struct {
+	// AllocationStrategy picks the warehouse an order ships from.
+	AllocationStrategy string
+	// LowStockNotifier receives an alert when stock falls below a product's
+	// reorder threshold, addressed to LowStockRecipient.
+	LowStockNotifier  notify.Notifier
+	LowStockRecipient string
+}
+
+// InventoryService manages warehouses and product stock through the inventory ledger.
+type InventoryService struct {
+	repo          repository.InventoryRepository
+	productRepo   repository.ProductRepository
+	warehouseRepo repository.WarehouseRepository
+	settings      InventorySettings
+}
+
+// NewInventoryService creates a new InventoryService instance.
+func NewInventoryService(
+	repo repository.InventoryRepository,
+	productRepo repository.ProductRepository,
+	warehouseRepo repository.WarehouseRepository,
+	settings InventorySettings,
+) *InventoryService {
+	return &InventoryService{repo: repo, productRepo: productRepo, warehouseRepo: warehouseRepo, settings: settings}
+}
+
+// ReorderSuggestion is a line of the reorder report: how much of a product
+// to order so that its stock covers the expected sales.
+type ReorderSuggestion struct {
+	ProductID        uint    `json:"product_id"`
+	Name             string  `json:"name"`
+	Stock            int     `json:"stock"`
+	ReorderThreshold int     `json:"reorder_threshold"`
+	Sold             int     `json:"sold"`
+	DailySales       float64 `json:"daily_sales"`
+	Suggested        int     `json:"suggested_quantity"`
+}
+
+// StockAdjustment describes a manual stock change. Exactly one of Change and
+// SetTo must be given: Change moves stock by a relative amount, SetTo sets it
+// to an absolute level such as the result of a stock count. With a
+// WarehouseID the change applies to that warehouse's stock, and SetTo is its
+// level there. Without one SetTo is the product's total stock, and the
+// difference is taken from or added to stock not assigned to a warehouse.
+type StockAdjustment struct {
+	ProductID   uint
+	WarehouseID *uint
+	Change      *int
+	SetTo       *int
+	Reason      string
+	Note        string
+	ActorID     uint
+}
+
+// AdjustStock applies a manual stock change (admin privilege) and returns the
+// recorded movement. Only restocks and adjustments can be made by hand; the
+// other reasons are recorded by the order and return workflows.
+func (s *InventoryService) AdjustStock(adjustment StockAdjustment) (*models.StockMovement, error) {
+	if adjustment.Reason == "" {
+		adjustment.Reason = models.StockReasonAdjustment
+	}
+	if adjustment.Reason != models.StockReasonAdjustment && adjustment.Reason != models.StockReasonRestock {
+		return nil, errors.New("reason must be adjustment or restock")
+	}
+	if (adjustment.Change == nil) == (adjustment.SetTo == nil) {
+		return nil, errors.New("exactly one of change and set_to is required")
+	}
+
+	if adjustment.WarehouseID != nil {
+		if _, err := s.warehouseRepo.GetWarehouseByID(*adjustment.WarehouseID); err != nil {
+			return nil, err
+		}
+	}
+
+	movement := &models.StockMovement{
+		ProductID:   adjustment.ProductID,
+		WarehouseID: adjustment.WarehouseID,
+		Reason:      adjustment.Reason,
+		ActorID:     adjustment.ActorID,
+		Note:        strings.TrimSpace(adjustment.Note),
+	}
+
+	if adjustment.SetTo != nil {
+		if *adjustment.SetTo < 0 {
+			return nil, errors.New("stock cannot be negative")
+		}
+		if err := s.repo.SetStock(movement, *adjustment.SetTo); err != nil {
+			return nil, err
+		}
+		s.checkLowStock(movement)
+		return movement, nil
+	}
+
+	if *adjustment.Change == 0 {
+		return nil, errors.New("change must not be zero")
+	}
+	if adjustment.Reason == models.StockReasonRestock && *adjustment.Change < 0 {
+		return nil, errors.New("a restock must add stock")
+	}
+	movement.Change = *adjustment.Change
+	if err := s.repo.AdjustStock(movement); err != nil {
+		return nil, err
+	}
+	s.checkLowStock(movement)
+	return movement, nil
+}
+
+// GetMovements retrieves a page of a product's stock movements, newest
+// first. The history of deleted products stays available. The page and page
+// size are brought into range in place, so the caller can report the ones used.
+func (s *InventoryService) GetMovements(productID uint, page, pageSize *int) ([]models.StockMovement, int64, error) {
+	if _, err := s.productRepo.GetProductIncludingDeleted(productID); err != nil {
+		return nil, 0, errors.New("product not found")
+	}
+	if *page < 1 {
+		*page = 1
+	}
+	if *pageSize < 1 {
+		*pageSize = defaultPageSize
+	}
+	if *pageSize > maxPageSize {
+		*pageSize = maxPageSize
+	}
+	return s.repo.GetMovementsByProduct(productID, *page, *pageSize)
+}
+
+// GetProductStock breaks a product's stock down by warehouse (admin privilege).
+func (s *InventoryService) GetProductStock(productID uint) (*models.ProductStock, error) {
+	return s.repo.GetProductStock(productID)
+}
+
+// TransferStock moves stock of a product between warehouses. Without a
+// source warehouse, stock not yet assigned to one is moved.
+func (s *InventoryService) TransferStock(productID uint, fromWarehouseID *uint, toWarehouseID uint, quantity int, actorID uint, note string) ([]models.StockMovement, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be greater than zero")
+	}
+	if fromWarehouseID != nil && *fromWarehouseID == toWarehouseID {
+		return nil, errors.New("source and destination warehouses must differ")
+	}
+	if _, err := s.warehouseRepo.GetWarehouseByID(toWarehouseID); err != nil {
+		return nil, err
+	}
+	if fromWarehouseID != nil {
+		if _, err := s.warehouseRepo.GetWarehouseByID(*fromWarehouseID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.TransferStock(productID, fromWarehouseID, toWarehouseID, quantity, actorID, strings.TrimSpace(note))
+}
+
+// AllocateWarehouse picks the warehouse an order ships from using the
+// configured strategy and sets it on the order. Orders that no single
+// warehouse can fill are served from stock not assigned to a warehouse, if
+// there is enough of it.
+func (s *InventoryService) AllocateWarehouse(order *models.Order) error {
+	stock, err := s.repo.GetProductStock(order.ProductID)
+	if err != nil {
+		return err
+	}
+
+	level := inventory.Allocate(s.settings.AllocationStrategy, stock.Locations, order.Quantity, order.ShippingCountry, order.ShippingRegion)
+	if level != nil {
+		warehouseID := level.WarehouseID
+		order.WarehouseID = &warehouseID
+		return nil
+	}
+	if stock.Unassigned < order.Quantity {
+		return errors.New("insufficient stock")
+	}
+	order.WarehouseID = nil
+	return nil
+}
+
+// CreateWarehouse validates and creates a new warehouse.
+func (s *InventoryService) CreateWarehouse(warehouse *models.Warehouse) error {
+	if err := s.validateWarehouse(warehouse); err != nil {
+		return err
+	}
+	return s.warehouseRepo.CreateWarehouse(warehouse)
+}
+
+// GetWarehouses retrieves all warehouses.
+func (s *InventoryService) GetWarehouses() ([]models.Warehouse, error) {
+	return s.warehouseRepo.GetAllWarehouses()
+}
+
+// GetWarehouseByID retrieves a warehouse by its ID.
+func (s *InventoryService) GetWarehouseByID(id uint) (*models.Warehouse, error) {
+	return s.warehouseRepo.GetWarehouseByID(id)
+}
+
+// UpdateWarehouse validates and replaces a warehouse's details. An inactive
+// warehouse keeps its stock but no longer ships new orders.
+func (s *InventoryService) UpdateWarehouse(warehouse *models.Warehouse) error {
+	if err := s.validateWarehouse(warehouse); err != nil {
+		return err
+	}
+	return s.warehouseRepo.UpdateWarehouse(warehouse)
+}
+
+// validateWarehouse normalises a warehouse and checks that its code is unique.
+func (s *InventoryService) validateWarehouse(warehouse *models.Warehouse) error {
+	warehouse.Name = strings.TrimSpace(warehouse.Name)
+	warehouse.Code = strings.ToUpper(strings.TrimSpace(warehouse.Code))
+	warehouse.Country = strings.ToUpper(strings.TrimSpace(warehouse.Country))
+	warehouse.Region = strings.TrimSpace(warehouse.Region)
+	if warehouse.Name == "" {
+		return errors.New("warehouse name is required")
+	}
+	if warehouse.Code == "" {
+		return errors.New("warehouse code is required")
+	}
+	if warehouse.Country == "" {
+		return errors.New("warehouse country is required")
+	}
+
+	warehouses, err := s.warehouseRepo.GetAllWarehouses()
+	if err != nil {
+		return err
+	}
+	for _, existing := range warehouses {
+		if existing.Code == warehouse.Code && existing.ID != warehouse.ID {
+			return errors.New("warehouse code already in use")
+		}
+	}
+	return nil
+}
+
+// RecordSale checks whether an order just placed took its product's stock
+// below the reorder threshold.
+func (s *InventoryService) RecordSale(order *models.Order) {
+	movements, err := s.repo.GetMovementsByOrder(order.ID)
+	if err != nil {
+		logger.Error(fmt.Sprintf("could not check stock of product %d after order %d: %s", order.ProductID, order.ID, err.Error()))
+		return
+	}
+	for i := range movements {
+		if movements[i].Reason == models.StockReasonSale {
+			s.checkLowStock(&movements[i])
+		}
+	}
+}
+
+// checkLowStock raises a low-stock alert when a movement took the product's
+// total stock from at or above its reorder threshold to below it. Alerts are
+// sent in the background so a slow notifier does not hold up the request.
+func (s *InventoryService) checkLowStock(movement *models.StockMovement) {
+	if movement.ID == 0 || movement.Change >= 0 || s.settings.LowStockNotifier == nil {
+		return
+	}
+	product, err := s.productRepo.GetProductByID(movement.ProductID)
+	if err != nil || product.ReorderThreshold == 0 {
+		return
+	}
+	before := movement.StockAfter - movement.Change
+	if movement.StockAfter >= product.ReorderThreshold || before < product.ReorderThreshold {
+		return
+	}
+
+	msg := notify.Message{
+		To:      s.settings.LowStockRecipient,
+		Subject: fmt.Sprintf("Low stock: %s", product.Name),
+		Body: fmt.Sprintf("Stock of %s (product %d) fell to %d after a %s, below its reorder threshold of %d.",
+			product.Name, product.ID, movement.StockAfter, movement.Reason, product.ReorderThreshold),
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), lowStockAlertTimeout)
+		defer cancel()
+		if err := s.settings.LowStockNotifier.Notify(ctx, msg); err != nil {
+			logger.Error(fmt.Sprintf("could not send low-stock alert for product %d: %s", product.ID, err.Error()))
+		}
+	}()
+}
+
+// ReorderReport suggests how much of each product to order. Average daily
+// sales over the last days are projected over coverDays, and the suggestion
+// tops stock up to that demand plus the reorder threshold. Only products
+// that need reordering are listed, those furthest below their threshold
+// first.
+func (s *InventoryService) ReorderReport(days, coverDays int) ([]ReorderSuggestion, error) {
+	if days < 1 || coverDays < 1 {
+		return nil, errors.New("days and cover_days must be at least 1")
+	}
+
+	sales, err := s.repo.GetSalesSince(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+	products, err := s.productRepo.GetAllProducts(false)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := []ReorderSuggestion{}
+	for _, product := range products {
+		sold := sales[product.ID]
+		daily := float64(sold) / float64(days)
+		target := int(math.Ceil(daily*float64(coverDays))) + product.ReorderThreshold
+		if target <= product.Stock {
+			continue
+		}
+		suggestions = append(suggestions, ReorderSuggestion{
+			ProductID:        product.ID,
+			Name:             product.Name,
+			Stock:            product.Stock,
+			ReorderThreshold: product.ReorderThreshold,
+			Sold:             sold,
+			DailySales:       math.Round(daily*100) / 100,
+			Suggested:        target - product.Stock,
+		})
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Stock-a.ReorderThreshold != b.Stock-b.ReorderThreshold {
+			return a.Stock-a.ReorderThreshold < b.Stock-b.ReorderThreshold
+		}
+		return a.ProductID < b.ProductID
+	})
+	return suggestions, nil
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"ecommerce-api/internal/invoice"
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/repository"
+	"ecommerce-api/internal/utils"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SellerDetails are the seller's details printed on invoices.
+type SellerDetails struct {
+	Name    string
+	Address string
+	TaxID   string
+}
+
+// InvoiceService issues invoices for orders and credit notes for refunds.
+type InvoiceService struct {
+	repo        repository.InvoiceRepository
+	orderRepo   repository.OrderRepositoryInterface
+	productRepo repository.ProductRepository
+	userRepo    *repository.UserRepository
+	seller      SellerDetails
+}
+
+// NewInvoiceService creates a new InvoiceService instance.
+func NewInvoiceService(
+	repo repository.InvoiceRepository,
+	orderRepo repository.OrderRepositoryInterface,
+	productRepo repository.ProductRepository,
+	userRepo *repository.UserRepository,
+	seller SellerDetails,
+) *InvoiceService {
+	return &InvoiceService{
+		repo:        repo,
+		orderRepo:   orderRepo,
+		productRepo: productRepo,
+		userRepo:    userRepo,
+		seller:      seller,
+	}
+}
+
+// GetInvoice retrieves the invoice of an order for its owner, issuing it on
+// first request. Admins may retrieve the invoice of any order.
+func (s *InvoiceService) GetInvoice(orderID, userID uint, isAdmin bool) (*models.Invoice, error) {
+	order, err := s.getOrder(orderID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	return s.ensureInvoice(order)
+}
+
+// GetCreditNotes retrieves the credit notes of an order for its owner. Any
+// refund still without a credit note gets one first.
+func (s *InvoiceService) GetCreditNotes(orderID, userID uint, isAdmin bool) ([]models.Invoice, error) {
+	order, err := s.getOrder(orderID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.issueCreditNotes(order); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCreditNotesByOrder(order.ID)
+}
+
+// GetCreditNote retrieves one credit note of an order for its owner.
+func (s *InvoiceService) GetCreditNote(orderID, creditNoteID, userID uint, isAdmin bool) (*models.Invoice, error) {
+	if _, err := s.getOrder(orderID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	note, err := s.repo.GetInvoiceByID(creditNoteID)
+	if err != nil {
+		return nil, err
+	}
+	if note.OrderID != orderID || note.Kind != models.InvoiceKindCreditNote {
+		return nil, errors.New("invoice not found")
+	}
+	return note, nil
+}
+
+// IssueCreditNotes issues a credit note for every refund of the order that does not have one yet.
+func (s *InvoiceService) IssueCreditNotes(orderID uint) error {
+	order, err := s.orderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return err
+	}
+	return s.issueCreditNotes(order)
+}
+
+// getOrder retrieves an order, hiding other users' orders from non-admins.
+func (s *InvoiceService) getOrder(orderID, userID uint, isAdmin bool) (*models.Order, error) {
+	order, err := s.orderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && order.UserID != userID {
+		return nil, errors.New("order not found")
+	}
+	return order, nil
+}
+
+// ensureInvoice returns the invoice of an order, issuing it if needed. Orders
+// are invoiced once they have shipped.
+func (s *InvoiceService) ensureInvoice(order *models.Order) (*models.Invoice, error) {
+	inv, err := s.repo.GetInvoiceByOrder(order.ID)
+	if err == nil {
+		return inv, nil
+	}
+	if err.Error() != "invoice not found" {
+		return nil, err
+	}
+
+	if order.Status == models.OrderStatusPending || order.Status == models.OrderStatusCancelled {
+		return nil, errors.New("invoice is not available until the order has shipped")
+	}
+
+	inv, err = s.newDocument(order, models.InvoiceKindInvoice)
+	if err != nil {
+		return nil, err
+	}
+
+	productName := "Product #" + strconv.Itoa(int(order.ProductID))
+	if product, err := s.productRepo.GetProductIncludingDeleted(order.ProductID); err == nil {
+		productName = product.Name
+	}
+	inv.Lines = []models.InvoiceLine{{
+		Description: productName,
+		Quantity:    order.Quantity,
+		UnitPrice:   order.UnitPrice,
+		Amount:      order.Subtotal,
+	}}
+	if order.DiscountTotal > 0 {
+		codes := make([]string, 0, len(order.Redemptions))
+		for _, redemption := range order.Redemptions {
+			codes = append(codes, redemption.Code)
+		}
+		inv.Lines = append(inv.Lines, models.InvoiceLine{
+			Description: "Discount (" + strings.Join(codes, ", ") + ")",
+			Amount:      -order.DiscountTotal,
+		})
+	}
+	if order.ShippingTotal > 0 {
+		inv.Lines = append(inv.Lines, models.InvoiceLine{
+			Description: "Shipping: " + order.ShippingMethodName,
+			Amount:      order.ShippingTotal,
+		})
+	}
+
+	inv.TaxBreakdown = taxBreakdown(order.TaxLines, 1)
+	inv.Subtotal = order.Subtotal
+	inv.DiscountTotal = order.DiscountTotal
+	inv.ShippingTotal = order.ShippingTotal
+	inv.TaxTotal = order.TaxTotal
+	inv.GrandTotal = order.GrandTotal
+
+	if err := s.repo.IssueInvoice(inv, invoice.Render); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// issueCreditNotes issues a credit note for every completed refund of the
+// order that does not have one yet. Tax is credited in proportion to the refund.
+func (s *InvoiceService) issueCreditNotes(order *models.Order) error {
+	if len(order.Refunds) == 0 {
+		return nil
+	}
+	existing, err := s.repo.GetCreditNotesByOrder(order.ID)
+	if err != nil {
+		return err
+	}
+	credited := make(map[uint]bool)
+	for _, note := range existing {
+		if note.RefundID != nil {
+			credited[*note.RefundID] = true
+		}
+	}
+
+	var original *models.Invoice
+	for i := range order.Refunds {
+		refund := &order.Refunds[i]
+		if credited[refund.ID] || refund.Status != models.RefundStatusCompleted {
+			continue
+		}
+		if original == nil {
+			if original, err = s.ensureInvoice(order); err != nil {
+				return err
+			}
+		}
+
+		note, err := s.newDocument(order, models.InvoiceKindCreditNote)
+		if err != nil {
+			return err
+		}
+		note.RefundID = &refund.ID
+		note.CreditedInvoiceNumber = original.Number
+
+		share := 0.0
+		if order.GrandTotal > 0 {
+			share = refund.Amount / order.GrandTotal
+		}
+		note.TaxBreakdown = taxBreakdown(order.TaxLines, share)
+		note.TaxTotal = utils.RoundMoney(order.TaxTotal * share)
+		note.GrandTotal = refund.Amount
+		note.Subtotal = refund.Amount
+		if !order.PricesIncludeTax {
+			note.Subtotal = utils.RoundMoney(refund.Amount - note.TaxTotal)
+		}
+
+		description := "Refund"
+		if refund.ReturnRequestID != nil {
+			description = fmt.Sprintf("Refund for return #%d", *refund.ReturnRequestID)
+		}
+		note.Lines = []models.InvoiceLine{{Description: description, Amount: note.Subtotal}}
+
+		if err := s.repo.IssueInvoice(note, invoice.Render); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newDocument starts an invoice or credit note with the seller and buyer details.
+func (s *InvoiceService) newDocument(order *models.Order, kind string) (*models.Invoice, error) {
+	user, err := s.userRepo.GetUserByID(strconv.Itoa(int(order.UserID)))
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &models.Invoice{
+		Kind:             kind,
+		OrderID:          order.ID,
+		IssuedAt:         time.Now().UTC(),
+		SellerName:       s.seller.Name,
+		SellerAddress:    s.seller.Address,
+		SellerTaxID:      s.seller.TaxID,
+		BuyerName:        order.ShippingName,
+		BuyerAddress:     buyerAddress(order),
+		PricesIncludeTax: order.PricesIncludeTax,
+	}
+	if user != nil {
+		inv.BuyerEmail = user.Email
+		if inv.BuyerName == "" {
+			inv.BuyerName = user.Name
+		}
+	}
+	return inv, nil
+}
+
+// buyerAddress formats the order's shipping address, one part per line.
+func buyerAddress(order *models.Order) string {
+	cityLine := strings.TrimSpace(strings.Join([]string{order.ShippingPostalCode, order.ShippingCity}, " "))
+	parts := []string{order.ShippingLine1, order.ShippingLine2, cityLine, order.ShippingRegion, order.ShippingCountry}
+	var lines []string
+	for _, part := range parts {
+		if part != "" {
+			lines = append(lines, part)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// taxBreakdown adds up the order's tax lines by rate, scaled by share.
+func taxBreakdown(lines []models.OrderTaxLine, share float64) []models.InvoiceTax {
+	var breakdown []models.InvoiceTax
+	index := make(map[string]int)
+	for _, line := range lines {
+		key := line.RateName + "|" + strconv.FormatFloat(line.Rate, 'f', -1, 64)
+		i, ok := index[key]
+		if !ok {
+			i = len(breakdown)
+			index[key] = i
+			breakdown = append(breakdown, models.InvoiceTax{Name: line.RateName, Rate: line.Rate})
+		}
+		breakdown[i].TaxableAmount += line.TaxableAmount * share
+		breakdown[i].TaxAmount += line.TaxAmount * share
+	}
+	for i := range breakdown {
+		breakdown[i].TaxableAmount = utils.RoundMoney(breakdown[i].TaxableAmount)
+		breakdown[i].TaxAmount = utils.RoundMoney(breakdown[i].TaxAmount)
+	}
+	return breakdown
+}
@@ -1,27 +1,272 @@
 package services
 
 import (
+	"context"
 	"ecommerce-api/internal/models"
 	"ecommerce-api/internal/repository"
+	"ecommerce-api/internal/tax"
+	"ecommerce-api/internal/utils"
 	"errors"
+	"strconv"
+	"time"
 )
 
+// Pagination limits for order listings.
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// expiryBatchSize is how many expired orders are looked up at a time.
+const expiryBatchSize = 100
+
+// TaxSettings configures how tax is charged at checkout.
+type TaxSettings struct {
+	Calculator       tax.TaxCalculator
+	PricesIncludeTax bool
+	// OriginCountry and OriginRegion are used for orders without a shipping address.
+	OriginCountry string
+	OriginRegion  string
+}
+
+// PlaceOrderOptions carries the checkout choices that go with a new order.
+type PlaceOrderOptions struct {
+	PromotionCodes []string
+	// AddressID is one of the user's saved addresses to ship to.
+	AddressID uint
+	// ShippingMethodID is the chosen shipping method. It needs an address.
+	// Orders with an address but without a method get the cheapest one.
+	ShippingMethodID uint
+}
+
 // OrderService handles business logic related to orders.
 type OrderService struct {
-	orderRepo repository.OrderRepositoryInterface
+	orderRepo        repository.OrderRepositoryInterface
+	productRepo      repository.ProductRepository
+	userRepo         *repository.UserRepository
+	addressRepo      repository.AddressRepository
+	promotionService *PromotionService
+	shippingService  *ShippingService
+	inventoryService *InventoryService
+	taxSettings      TaxSettings
 }
 
 // NewOrderService creates a new OrderService instance.
-func NewOrderService(orderRepo repository.OrderRepositoryInterface) *OrderService {
-	return &OrderService{orderRepo: orderRepo}
+func NewOrderService(
+	orderRepo repository.OrderRepositoryInterface,
+	productRepo repository.ProductRepository,
+	userRepo *repository.UserRepository,
+	addressRepo repository.AddressRepository,
+	promotionService *PromotionService,
+	shippingService *ShippingService,
+	inventoryService *InventoryService,
+	taxSettings TaxSettings,
+) *OrderService {
+	return &OrderService{
+		orderRepo:        orderRepo,
+		productRepo:      productRepo,
+		userRepo:         userRepo,
+		addressRepo:      addressRepo,
+		promotionService: promotionService,
+		shippingService:  shippingService,
+		inventoryService: inventoryService,
+		taxSettings:      taxSettings,
+	}
 }
 
-// PlaceOrder processes a new order and saves it to the database.
-func (s *OrderService) PlaceOrder(order *models.Order) error {
+// PlaceOrder prices a new order from the current catalog, picks the
+// warehouse it ships from, adds shipping, applies promotion codes and tax,
+// and saves it to the database, reserving its stock until it is cancelled or
+// expires. Any amounts already set on the
+// order are overwritten.
+func (s *OrderService) PlaceOrder(order *models.Order, options PlaceOrderOptions) error {
 	if err := validateOrder(order); err != nil {
 		return err
 	}
-	return s.orderRepo.CreateOrder(order)
+
+	product, err := s.productRepo.GetProductByID(order.ProductID)
+	if err != nil {
+		return errors.New("product not found")
+	}
+	if product.Stock < order.Quantity {
+		return errors.New("insufficient stock")
+	}
+
+	if options.AddressID != 0 {
+		address, err := s.addressRepo.GetAddressByID(order.UserID, options.AddressID)
+		if err != nil {
+			return err
+		}
+		setShippingAddress(order, address)
+	}
+
+	if err := s.inventoryService.AllocateWarehouse(order); err != nil {
+		return err
+	}
+
+	order.Status = models.OrderStatusPending
+	priceOrder(order, product)
+
+	if options.ShippingMethodID != 0 || order.ShippingCountry != "" {
+		if err := s.shippingService.ApplyShipping(order, product, options.ShippingMethodID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.promotionService.ApplyPromotions(order, product, options.PromotionCodes, time.Now()); err != nil {
+		return err
+	}
+
+	if err := s.applyTax(order, product); err != nil {
+		return err
+	}
+	order.GrandTotal = orderGrandTotal(order)
+
+	if err := s.orderRepo.CreateOrder(order); err != nil {
+		// Another order used up the promotion after it was checked
+		var limitErr *repository.PromotionLimitError
+		if errors.As(err, &limitErr) {
+			return &PromotionError{Rejections: []CodeRejection{{Code: limitErr.Code, Reason: "promotion usage limit reached"}}}
+		}
+		return err
+	}
+	s.inventoryService.RecordSale(order)
+	return nil
+}
+
+// priceOrder snapshots the product price on the order and works out its totals.
+func priceOrder(order *models.Order, product *models.Product) {
+	order.UnitPrice = utils.RoundMoney(product.Price)
+	order.Subtotal = utils.RoundMoney(order.UnitPrice * float64(order.Quantity))
+	order.DiscountTotal = 0
+	order.TaxTotal = 0
+	order.ShippingTotal = 0
+	order.GrandTotal = orderGrandTotal(order)
+}
+
+// orderGrandTotal adds up the stored amounts of an order. Tax already
+// contained in the prices is not added a second time.
+func orderGrandTotal(order *models.Order) float64 {
+	total := order.Subtotal - order.DiscountTotal + order.ShippingTotal
+	if !order.PricesIncludeTax {
+		total += order.TaxTotal
+	}
+	return utils.RoundMoney(total)
+}
+
+// setShippingAddress copies the address onto the order.
+func setShippingAddress(order *models.Order, address *models.Address) {
+	order.ShippingName = address.FullName
+	order.ShippingLine1 = address.Line1
+	order.ShippingLine2 = address.Line2
+	order.ShippingCity = address.City
+	order.ShippingRegion = address.Region
+	order.ShippingPostalCode = address.PostalCode
+	order.ShippingCountry = address.Country
+}
+
+// applyTax works out the tax on the discounted product line and on shipping
+// for the order's destination, and stores it per line on the order. Tax
+// contained in the prices is deducted for tax-exempt customers.
+func (s *OrderService) applyTax(order *models.Order, product *models.Product) error {
+	order.PricesIncludeTax = s.taxSettings.PricesIncludeTax
+	order.TaxTotal = 0
+	order.TaxLines = nil
+	if s.taxSettings.Calculator == nil {
+		return nil
+	}
+
+	user, err := s.userRepo.GetUserByID(strconv.Itoa(int(order.UserID)))
+	if err != nil {
+		return err
+	}
+	exempt := user != nil && user.TaxExempt
+
+	country, region := order.ShippingCountry, order.ShippingRegion
+	if country == "" {
+		country, region = s.taxSettings.OriginCountry, s.taxSettings.OriginRegion
+	}
+
+	productDiscount, shippingDiscount := lineDiscounts(order)
+	result, err := s.taxSettings.Calculator.Calculate(tax.Request{
+		Country:          country,
+		Region:           region,
+		Lines:            orderTaxLines(order, product, productDiscount, shippingDiscount),
+		PricesIncludeTax: order.PricesIncludeTax,
+		Exempt:           exempt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if exempt && order.PricesIncludeTax {
+		deductIncludedTax(order, result.Lines, productDiscount, shippingDiscount)
+	}
+
+	for _, line := range result.Lines {
+		order.TaxLines = append(order.TaxLines, models.OrderTaxLine{
+			Line:          line.Reference,
+			TaxClass:      line.TaxClass,
+			RateName:      line.RateName,
+			Rate:          line.Rate,
+			TaxableAmount: line.NetAmount,
+			TaxAmount:     line.TaxAmount,
+		})
+	}
+	order.TaxTotal = result.TotalTax
+	return nil
+}
+
+// lineDiscounts adds up the promotion discounts on the product line and on
+// shipping, so a free shipping code does not lower the product's tax.
+func lineDiscounts(order *models.Order) (productDiscount, shippingDiscount float64) {
+	for _, redemption := range order.Redemptions {
+		if redemption.Line == models.OrderLineShipping {
+			shippingDiscount += redemption.Discount
+		} else {
+			productDiscount += redemption.Discount
+		}
+	}
+	return productDiscount, shippingDiscount
+}
+
+// orderTaxLines returns the taxable lines of the order after discounts.
+func orderTaxLines(order *models.Order, product *models.Product, productDiscount, shippingDiscount float64) []tax.Line {
+	lines := []tax.Line{{
+		Reference: models.OrderLineProduct,
+		TaxClass:  product.TaxClass,
+		Amount:    order.Subtotal - productDiscount,
+	}}
+	if order.ShippingTotal > 0 {
+		lines = append(lines, tax.Line{
+			Reference: models.OrderLineShipping,
+			TaxClass:  models.OrderLineShipping,
+			Amount:    order.ShippingTotal - shippingDiscount,
+		})
+	}
+	return lines
+}
+
+// deductIncludedTax takes the tax contained in the prices off the amounts
+// of a tax-exempt customer's order, so they pay the net prices. The order is
+// then stored with prices excluding tax.
+func deductIncludedTax(order *models.Order, lines []tax.LineResult, productDiscount, shippingDiscount float64) {
+	for _, line := range lines {
+		net := func(amount float64) float64 {
+			return utils.RoundMoney(amount / (1 + line.DeductedRate))
+		}
+		switch line.Reference {
+		case models.OrderLineProduct:
+			order.UnitPrice = net(order.UnitPrice)
+			order.Subtotal = net(order.Subtotal)
+			productDiscount = net(productDiscount)
+		case models.OrderLineShipping:
+			order.ShippingTotal = net(order.ShippingTotal)
+			shippingDiscount = net(shippingDiscount)
+		}
+	}
+	order.DiscountTotal = utils.RoundMoney(productDiscount + shippingDiscount)
+	order.PricesIncludeTax = false
 }
 
 // GetOrders retrieves all orders for a specific user.
@@ -29,21 +274,103 @@ func (s *OrderService) GetOrdersByUser(userID uint) ([]models.Order, error) {
 	return s.orderRepo.GetOrdersByUser(userID)
 }
 
-// CancelOrder cancels an order if it is still in the Pending status.
-func (s *OrderService) CancelOrder(orderID uint) error {
+// GetOrder retrieves an order for its owner. Admins may retrieve any order.
+// Other users get "order not found" so that order IDs cannot be probed.
+func (s *OrderService) GetOrder(orderID, userID uint, isAdmin bool) (*models.Order, error) {
 	order, err := s.orderRepo.GetOrderByID(orderID)
 	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && order.UserID != userID {
+		return nil, errors.New("order not found")
+	}
+	return order, nil
+}
+
+// ListOrders retrieves a filtered, sorted page of all orders (admin privilege).
+// The page and page size are brought into range on the filter itself, so the
+// caller can report the ones used.
+func (s *OrderService) ListOrders(filter *repository.OrderFilter) ([]models.Order, int64, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultPageSize
+	}
+	if filter.PageSize > maxPageSize {
+		filter.PageSize = maxPageSize
+	}
+	return s.orderRepo.ListOrders(*filter)
+}
+
+// CancelOrder cancels one of the user's orders if it is still in the Pending status.
+func (s *OrderService) CancelOrder(orderID, userID uint) error {
+	if _, err := s.GetOrder(orderID, userID, false); err != nil {
 		return err
 	}
-	if order.Status != "Pending" {
-		return errors.New("order cannot be canceled as it is not in Pending status")
+	return s.orderRepo.CancelPendingOrder(orderID, userID, "cancelled by customer")
+}
+
+// AdminCancelOrder cancels any pending order regardless of who placed it (admin privilege).
+func (s *OrderService) AdminCancelOrder(orderID, adminID uint) error {
+	return s.orderRepo.CancelPendingOrder(orderID, adminID, "cancelled by admin")
+}
+
+// DeleteOrder soft-deletes a completed or cancelled order (admin privilege).
+// It disappears from the customer's order list but is kept until purged.
+func (s *OrderService) DeleteOrder(orderID uint) error {
+	return s.orderRepo.DeleteOrder(orderID)
+}
+
+// RestoreOrder brings back a soft-deleted order (admin privilege).
+func (s *OrderService) RestoreOrder(orderID uint) error {
+	return s.orderRepo.RestoreOrder(orderID)
+}
+
+// MarkOrderPaid records that payment for an order was received (admin
+// privilege). Paid orders are not expired.
+func (s *OrderService) MarkOrderPaid(orderID uint) error {
+	return s.orderRepo.MarkOrderPaid(orderID, time.Now())
+}
+
+// ExpirePendingOrders cancels orders that are still pending and unpaid after
+// the timeout and releases their stock. It returns how many orders it
+// cancelled. Orders that move on or are paid while the job runs are left alone.
+func (s *OrderService) ExpirePendingOrders(ctx context.Context, timeout time.Duration) (int, error) {
+	reason := "expired: not paid within " + timeout.String()
+	expired := 0
+	for {
+		ids, err := s.orderRepo.GetExpiredPendingOrderIDs(time.Now().Add(-timeout), expiryBatchSize)
+		if err != nil {
+			return expired, err
+		}
+
+		cancelled := 0
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return expired, err
+			}
+			err := s.orderRepo.ExpirePendingOrder(id, reason)
+			if err != nil && err.Error() != "order cannot be canceled as it is not in Pending status" &&
+				err.Error() != "order is already paid" {
+				return expired, err
+			}
+			if err == nil {
+				cancelled++
+			}
+		}
+		expired += cancelled
+
+		if len(ids) < expiryBatchSize || cancelled == 0 {
+			return expired, nil
+		}
 	}
-	return s.orderRepo.UpdateOrderStatus(orderID, models.OrderStatusCancelled)
 }
 
-// UpdateOrderStatus updates the status of an order (admin privilege).
-func (s *OrderService) UpdateOrderStatus(orderID uint, status string) error {
-	return s.orderRepo.UpdateOrderStatus(orderID, status)
+// UpdateOrderStatus updates the status of an order (admin privilege). When
+// ifVersion is not 0 the order must still be at that version.
+func (s *OrderService) UpdateOrderStatus(orderID uint, status string, ifVersion int) error {
+	return s.orderRepo.UpdateOrderStatus(orderID, status, ifVersion)
 }
 
 // validateOrder checks if the order data is valid.
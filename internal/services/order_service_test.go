@@ -0,0 +1,79 @@
+package services
+
+import (
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/repository"
+	"ecommerce-api/internal/tax"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakePromotionRepository finds promotions by code and has no redemptions.
+type fakePromotionRepository struct {
+	repository.PromotionRepository
+	promotions map[string]*models.Promotion
+}
+
+func (r fakePromotionRepository) GetPromotionByCode(code string) (*models.Promotion, error) {
+	promotion, ok := r.promotions[code]
+	if !ok {
+		return nil, errors.New("promotion not found")
+	}
+	return promotion, nil
+}
+
+func (fakePromotionRepository) CountRedemptionsByUser(promotionID, userID uint) (int64, error) {
+	return 0, nil
+}
+
+func TestFreeShippingDoesNotLowerProductTax(t *testing.T) {
+	promotions := NewPromotionService(fakePromotionRepository{promotions: map[string]*models.Promotion{
+		"FREESHIP": {ID: 1, Code: "FREESHIP", Type: models.PromotionTypeFreeShipping, Active: true, Stackable: true},
+		"TENOFF":   {ID: 2, Code: "TENOFF", Type: models.PromotionTypeFixedAmount, Value: 10, Active: true, Stackable: true},
+	}})
+	calculator, err := tax.NewRuleBasedCalculator([]tax.Rate{
+		{Country: "DE", Rate: 0.19, Name: "standard"},
+		{Country: "DE", TaxClass: models.OrderLineShipping, Rate: 0.07, Name: "shipping"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	product := &models.Product{ID: 1, Price: 50}
+
+	tests := map[string]struct {
+		codes                  []string
+		productNet, productTax float64
+		shippingNet            float64
+	}{
+		"free shipping":              {[]string{"FREESHIP"}, 100, 19, 0},
+		"free shipping and discount": {[]string{"FREESHIP", "TENOFF"}, 90, 17.1, 0},
+		"discount only":              {[]string{"TENOFF"}, 90, 17.1, 20},
+	}
+	for name, tc := range tests {
+		order := &models.Order{ProductID: 1, Quantity: 2, Subtotal: 100, ShippingTotal: 20}
+		if err := promotions.ApplyPromotions(order, product, tc.codes, time.Now()); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		productDiscount, shippingDiscount := lineDiscounts(order)
+		result, err := calculator.Calculate(tax.Request{
+			Country: "DE",
+			Lines:   orderTaxLines(order, product, productDiscount, shippingDiscount),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := map[string]tax.LineResult{}
+		for _, line := range result.Lines {
+			lines[line.Reference] = line
+		}
+		if got := lines[models.OrderLineProduct]; got.NetAmount != tc.productNet || got.TaxAmount != tc.productTax {
+			t.Errorf("%s: product taxed %.2f on %.2f, want %.2f on %.2f", name, got.TaxAmount, got.NetAmount, tc.productTax, tc.productNet)
+		}
+		if got := lines[models.OrderLineShipping]; got.NetAmount != tc.shippingNet || got.Rate != 0.07 {
+			t.Errorf("%s: shipping taxable %.2f at %.2f, want %.2f at 0.07", name, got.NetAmount, got.Rate, tc.shippingNet)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"ecommerce-api/internal/logger"
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/repository"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// erasureBatchSize caps how many erasure requests a single job run processes.
+const erasureBatchSize = 50
+
+// UserDataExport is the machine-readable copy of everything stored about a user.
+type UserDataExport struct {
+	ExportedAt time.Time        `json:"exported_at"`
+	Profile    *ExportProfile   `json:"profile"`
+	Addresses  []models.Address `json:"addresses"`
+	Orders     []models.Order   `json:"orders"`
+}
+
+// ExportProfile is the account part of a user data export. It is built
+// field by field so credentials such as the password hash never leave the
+// service.
+type ExportProfile struct {
+	ID        uint      `json:"id"`
+	Role      string    `json:"role"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	TaxExempt bool      `json:"tax_exempt"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// newExportProfile copies the exportable account fields of a user.
+func newExportProfile(user *models.User) *ExportProfile {
+	return &ExportProfile{
+		ID:        user.ID,
+		Role:      user.Role,
+		Email:     user.Email,
+		Name:      user.Name,
+		TaxExempt: user.TaxExempt,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+// PrivacyService handles data export and right-to-be-forgotten requests.
+type PrivacyService struct {
+	userRepo    *repository.UserRepository
+	orderRepo   repository.OrderRepositoryInterface
+	addressRepo repository.AddressRepository
+	auditRepo   repository.AuditRepository
+}
+
+// NewPrivacyService creates a new PrivacyService instance.
+func NewPrivacyService(
+	userRepo *repository.UserRepository,
+	orderRepo repository.OrderRepositoryInterface,
+	addressRepo repository.AddressRepository,
+	auditRepo repository.AuditRepository,
+) *PrivacyService {
+	return &PrivacyService{
+		userRepo:    userRepo,
+		orderRepo:   orderRepo,
+		addressRepo: addressRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+// ExportUserData collects the profile, addresses and orders of a user.
+func (s *PrivacyService) ExportUserData(userID uint) (*UserDataExport, error) {
+	user, err := s.userRepo.GetUserByID(strconv.Itoa(int(userID)))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	addresses, err := s.addressRepo.GetAddressesByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := s.orderRepo.GetOrdersByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserDataExport{
+		ExportedAt: time.Now().UTC(),
+		Profile:    newExportProfile(user),
+		Addresses:  addresses,
+		Orders:     orders,
+	}, nil
+}
+
+// ExportUserDataZip packages the export as a ZIP archive with one JSON file per section.
+func (s *PrivacyService) ExportUserDataZip(userID uint) ([]byte, error) {
+	export, err := s.ExportUserData(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"addresses.json", export.Addresses},
+		{"orders.json", export.Orders},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RequestErasure queues the anonymisation of a user's personal data. The work
+// itself is done by ProcessErasureRequests in the background.
+func (s *PrivacyService) RequestErasure(userID uint) (*models.ErasureRequest, error) {
+	request, err := s.userRepo.CreateErasureRequest(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, models.AuditActionUserErasureRequested, userID, fmt.Sprintf("erasure request %d", request.ID))
+	return request, nil
+}
+
+// ProcessErasureRequests anonymises every pending erasure request. It is meant
+// to be run periodically by the job scheduler.
+func (s *PrivacyService) ProcessErasureRequests(ctx context.Context) error {
+	requests, err := s.userRepo.GetPendingErasureRequests(erasureBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range requests {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		request := &requests[i]
+		now := time.Now().UTC()
+		if err := s.userRepo.AnonymizeUser(request.UserID, now); err != nil {
+			request.Status = models.ErasureStatusFailed
+			request.Error = err.Error()
+		} else {
+			request.Status = models.ErasureStatusCompleted
+			request.CompletedAt = &now
+			s.audit(request.UserID, models.AuditActionUserErased, request.UserID, fmt.Sprintf("erasure request %d", request.ID))
+		}
+
+		if err := s.userRepo.UpdateErasureRequest(request); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// audit writes an audit entry about a user. Failures are not fatal to the
+// action being audited, so they are only logged.
+func (s *PrivacyService) audit(actorID uint, action string, userID uint, details string) {
+	entry := &models.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   userID,
+		Details:    details,
+	}
+	if err := s.auditRepo.CreateEntry(entry); err != nil {
+		logger.Error("could not write audit entry " + action + ": " + err.Error())
+	}
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"ecommerce-api/internal/models"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExportProfileLeavesOutCredentials(t *testing.T) {
+	user := &models.User{
+		ID:       3,
+		Email:    "ada@example.com",
+		Name:     "Ada",
+		Password: "$2a$12$abcdefghijklmnopqrstuuJ1y0zHfx5rW1nq5q3uXfQ7m6I8wqKf2",
+	}
+
+	data, err := json.Marshal(newExportProfile(user))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "password") || strings.Contains(string(data), user.Password) {
+		t.Errorf("profile export contains credentials: %s", data)
+	}
+	if !strings.Contains(string(data), user.Email) {
+		t.Errorf("profile export is missing the email: %s", data)
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"ecommerce-api/internal/blob"
+	"ecommerce-api/internal/imaging"
+	"ecommerce-api/internal/logger"
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/repository"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// thumbnailSizes are the thumbnails made of every product image, by name,
+// with the longest side each is scaled down to.
+var thumbnailSizes = []struct {
+	Name string
+	Size int
+}{
+	{"small", 200},
+	{"medium", 600},
+}
+
+// ProductImageService handles product image uploads and serves the stored files.
+type ProductImageService struct {
+	repo        repository.ProductImageRepository
+	productRepo repository.ProductRepository
+	store       blob.BlobStore
+	// maxBytes is the largest image file accepted.
+	maxBytes int64
+}
+
+// NewProductImageService creates a new ProductImageService instance.
+func NewProductImageService(
+	repo repository.ProductImageRepository,
+	productRepo repository.ProductRepository,
+	store blob.BlobStore,
+	maxBytes int64,
+) *ProductImageService {
+	return &ProductImageService{repo: repo, productRepo: productRepo, store: store, maxBytes: maxBytes}
+}
+
+// MaxBytes returns the largest image file accepted.
+func (s *ProductImageService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// UploadImage checks an uploaded image, stores it with its thumbnails and
+// adds it to the product (admin privilege). The type is sniffed from the
+// content rather than trusted from the upload. Without a sort order the
+// image goes after the product's existing ones.
+func (s *ProductImageService) UploadImage(ctx context.Context, productID uint, data []byte, altText string, sortOrder *int) (*models.ProductImage, error) {
+	if _, err := s.productRepo.GetProductByID(productID); err != nil {
+		return nil, errors.New("product not found")
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, fmt.Errorf("image exceeds the maximum size of %d bytes", s.maxBytes)
+	}
+	contentType, ok := imaging.DetectType(data)
+	if !ok {
+		return nil, errors.New("image must be a JPEG, PNG or GIF")
+	}
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	prefix := fmt.Sprintf("products/%d/%s/", productID, hex.EncodeToString(id))
+
+	image := &models.ProductImage{
+		ProductID:   productID,
+		Key:         prefix + "original" + imageExtension(contentType),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		AltText:     strings.TrimSpace(altText),
+	}
+	if sortOrder != nil {
+		image.SortOrder = *sortOrder
+	} else if image.SortOrder, err = s.repo.NextSortOrder(productID); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Put(ctx, image.Key, data, contentType); err != nil {
+		return nil, err
+	}
+	for _, size := range thumbnailSizes {
+		thumb := imaging.Thumbnail(img, size.Size)
+		encoded, thumbType, err := imaging.Encode(thumb, contentType)
+		if err != nil {
+			s.removeFiles(image)
+			return nil, err
+		}
+		thumbnail := models.ImageThumbnail{
+			Name:        size.Name,
+			Key:         prefix + size.Name + imageExtension(thumbType),
+			ContentType: thumbType,
+			Width:       thumb.Bounds().Dx(),
+			Height:      thumb.Bounds().Dy(),
+		}
+		if err := s.store.Put(ctx, thumbnail.Key, encoded, thumbType); err != nil {
+			s.removeFiles(image)
+			return nil, err
+		}
+		image.Thumbnails = append(image.Thumbnails, thumbnail)
+	}
+
+	if err := s.repo.CreateImage(image); err != nil {
+		s.removeFiles(image)
+		return nil, err
+	}
+	return image, nil
+}
+
+// GetImages retrieves a product's images in display order.
+func (s *ProductImageService) GetImages(productID uint) ([]models.ProductImage, error) {
+	if _, err := s.productRepo.GetProductByID(productID); err != nil {
+		return nil, errors.New("product not found")
+	}
+	return s.repo.GetImagesByProduct(productID)
+}
+
+// UpdateImage changes the alt text and sort order of a product image (admin
+// privilege). Fields left nil are not changed.
+func (s *ProductImageService) UpdateImage(productID, imageID uint, altText *string, sortOrder *int) (*models.ProductImage, error) {
+	image, err := s.getProductImage(productID, imageID)
+	if err != nil {
+		return nil, err
+	}
+	if altText != nil {
+		image.AltText = strings.TrimSpace(*altText)
+	}
+	if sortOrder != nil {
+		image.SortOrder = *sortOrder
+	}
+	if err := s.repo.UpdateImage(image); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// DeleteImage removes a product image and its stored files (admin privilege).
+func (s *ProductImageService) DeleteImage(productID, imageID uint) error {
+	image, err := s.getProductImage(productID, imageID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteImage(image.ID); err != nil {
+		return err
+	}
+	s.removeFiles(image)
+	return nil
+}
+
+// OpenImage opens the stored file of an image, either the original or one
+// of its thumbnails by name. It also returns the file's content type and a
+// key that changes whenever the file does, for use as an ETag.
+func (s *ProductImageService) OpenImage(ctx context.Context, imageID uint, variant string) (io.ReadCloser, string, string, error) {
+	image, err := s.repo.GetImageByID(imageID)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	key, contentType := image.Key, image.ContentType
+	if variant != "original" {
+		key = ""
+		for _, thumbnail := range image.Thumbnails {
+			if thumbnail.Name == variant {
+				key, contentType = thumbnail.Key, thumbnail.ContentType
+			}
+		}
+		if key == "" {
+			return nil, "", "", errors.New("image not found")
+		}
+	}
+
+	file, err := s.store.Get(ctx, key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, "", "", errors.New("image not found")
+	}
+	if err != nil {
+		return nil, "", "", err
+	}
+	return file, contentType, key, nil
+}
+
+// getProductImage retrieves an image and checks that it belongs to the product.
+func (s *ProductImageService) getProductImage(productID, imageID uint) (*models.ProductImage, error) {
+	image, err := s.repo.GetImageByID(imageID)
+	if err != nil {
+		return nil, err
+	}
+	if image.ProductID != productID {
+		return nil, errors.New("image not found")
+	}
+	return image, nil
+}
+
+// removeFiles deletes an image's stored files. Failures are only logged,
+// since the database no longer points at the files.
+func (s *ProductImageService) removeFiles(image *models.ProductImage) {
+	keys := []string{image.Key}
+	for _, thumbnail := range image.Thumbnails {
+		keys = append(keys, thumbnail.Key)
+	}
+	for _, key := range keys {
+		if err := s.store.Delete(context.Background(), key); err != nil {
+			logger.Error("could not delete image file " + key + ": " + err.Error())
+		}
+	}
+}
+
+// imageExtension returns the file extension for an image type.
+func imageExtension(contentType string) string {
+	switch contentType {
+	case imaging.JPEG:
+		return ".jpg"
+	case imaging.PNG:
+		return ".png"
+	case imaging.GIF:
+		return ".gif"
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"ecommerce-api/internal/blob"
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/repository"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeProductRepository finds every product; the image service only looks
+// products up.
+type fakeProductRepository struct {
+	repository.ProductRepository
+}
+
+func (fakeProductRepository) GetProductByID(id uint) (*models.Product, error) {
+	return &models.Product{ID: id, Name: "Lamp"}, nil
+}
+
+// fakeImageRepository keeps images in memory. Thumbnails go through their
+// database form on every save and load, as with a real database.
+type fakeImageRepository struct {
+	rows   map[uint]models.ProductImage
+	stored map[uint]interface{}
+	nextID uint
+}
+
+func newFakeImageRepository() *fakeImageRepository {
+	return &fakeImageRepository{rows: map[uint]models.ProductImage{}, stored: map[uint]interface{}{}}
+}
+
+func (r *fakeImageRepository) CreateImage(image *models.ProductImage) error {
+	r.nextID++
+	image.ID = r.nextID
+	value, err := image.Thumbnails.Value()
+	if err != nil {
+		return err
+	}
+	row := *image
+	row.Thumbnails = nil
+	r.rows[image.ID] = row
+	r.stored[image.ID] = value
+	return nil
+}
+
+func (r *fakeImageRepository) GetImageByID(id uint) (*models.ProductImage, error) {
+	row, ok := r.rows[id]
+	if !ok {
+		return nil, errors.New("image not found")
+	}
+	if err := row.Thumbnails.Scan(r.stored[id]); err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+func (r *fakeImageRepository) GetImagesByProduct(productID uint) ([]models.ProductImage, error) {
+	return nil, nil
+}
+
+func (r *fakeImageRepository) UpdateImage(image *models.ProductImage) error { return nil }
+
+func (r *fakeImageRepository) DeleteImage(id uint) error {
+	delete(r.rows, id)
+	delete(r.stored, id)
+	return nil
+}
+
+func (r *fakeImageRepository) NextSortOrder(productID uint) (int, error) { return 0, nil }
+
+// newS3StandIn starts a minimal path-style S3 server keeping objects in memory.
+func newS3StandIn(t *testing.T) (*httptest.Server, map[string][]byte) {
+	t.Helper()
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/images/")
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			objects[key] = data
+		case http.MethodGet:
+			data, ok := objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		case http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, objects
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadImageServesEveryVariant(t *testing.T) {
+	server, objects := newS3StandIn(t)
+	local, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := map[string]struct {
+		store blob.BlobStore
+		count func() int
+	}{
+		"local": {local, nil},
+		"s3": {blob.NewS3Store(blob.S3Config{
+			Endpoint:        server.URL,
+			Region:          "us-east-1",
+			Bucket:          "images",
+			AccessKeyID:     "test-key",
+			SecretAccessKey: "test-secret",
+		}), func() int { return len(objects) }},
+	}
+
+	for name, tc := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeImageRepository()
+			service := NewProductImageService(repo, fakeProductRepository{}, tc.store, 5<<20)
+
+			uploaded, err := service.UploadImage(ctx, 7, testPNG(t, 800, 400), "A lamp", nil)
+			if err != nil {
+				t.Fatalf("upload: %v", err)
+			}
+
+			want := map[string][2]int{"original": {800, 400}, "small": {200, 100}, "medium": {600, 300}}
+			for variant, size := range want {
+				file, contentType, key, err := service.OpenImage(ctx, uploaded.ID, variant)
+				if err != nil {
+					t.Fatalf("open %s: %v", variant, err)
+				}
+				data, err := io.ReadAll(file)
+				file.Close()
+				if err != nil {
+					t.Fatalf("read %s: %v", variant, err)
+				}
+				if contentType != "image/png" || key == "" {
+					t.Errorf("%s: got content type %q and key %q", variant, contentType, key)
+				}
+				img, err := png.Decode(bytes.NewReader(data))
+				if err != nil {
+					t.Fatalf("decode %s: %v", variant, err)
+				}
+				if got := [2]int{img.Bounds().Dx(), img.Bounds().Dy()}; got != size {
+					t.Errorf("%s: got size %v, want %v", variant, got, size)
+				}
+			}
+
+			if _, _, _, err := service.OpenImage(ctx, uploaded.ID, "large"); err == nil || err.Error() != "image not found" {
+				t.Errorf("unknown variant: got error %v, want image not found", err)
+			}
+
+			if err := service.DeleteImage(7, uploaded.ID); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			for _, key := range []string{uploaded.Key, uploaded.Thumbnails[0].Key, uploaded.Thumbnails[1].Key} {
+				if _, err := tc.store.Get(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+					t.Errorf("file %s left behind after delete: %v", key, err)
+				}
+			}
+			if tc.count != nil && tc.count() != 0 {
+				t.Errorf("%d objects left in the bucket after delete", tc.count())
+			}
+		})
+	}
+}
@@ -1,20 +1,82 @@
 package services
 
 import (
+	"bytes"
+	"ecommerce-api/internal/catalog"
+	"ecommerce-api/internal/logger"
 	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/patch"
 	"ecommerce-api/internal/repository"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 )
 
+// ErrInvalidPatch wraps the reason a product patch was rejected: it could
+// not be applied, or the patched product is not valid.
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// Bulk import and export limits.
+const (
+	// importBatchSize is how many valid rows are written per transaction.
+	importBatchSize = 500
+	// maxImportErrors caps how many row errors an import reports back.
+	maxImportErrors = 1000
+	// exportBatchSize is how many products are read at a time for an export.
+	exportBatchSize = 500
+)
+
+// ImportRowError explains why a row of an import was not imported.
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	SKU   string `json:"sku,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportReport summarises a bulk product import.
+type ImportReport struct {
+	DryRun  bool `json:"dry_run"`
+	Rows    int  `json:"rows"`
+	Created int  `json:"created"`
+	Updated int  `json:"updated"`
+	Failed  int  `json:"failed"`
+	// Errors lists the failed rows, up to maxImportErrors of them.
+	Errors []ImportRowError `json:"errors"`
+}
+
+// fail records a row that was not imported.
+func (r *ImportReport) fail(line int, sku, reason string) {
+	r.Failed++
+	if len(r.Errors) < maxImportErrors {
+		r.Errors = append(r.Errors, ImportRowError{Line: line, SKU: sku, Error: reason})
+	}
+}
+
 // ProductService defines the service for managing products.
 type ProductService struct {
-	repo repository.ProductRepository
+	repo             repository.ProductRepository
+	inventoryService *InventoryService
+	wishlistService  *WishlistService
 }
 
 // NewProductService creates a new instance of ProductService.
-func NewProductService(repo repository.ProductRepository) *ProductService {
-	return &ProductService{repo: repo}
+func NewProductService(repo repository.ProductRepository, inventoryService *InventoryService, wishlistService *WishlistService) *ProductService {
+	return &ProductService{repo: repo, inventoryService: inventoryService, wishlistService: wishlistService}
+}
+
+// ProductUpdateOptions carries product fields whose zero value is meaningful
+// and so cannot be left out of models.Product to mean "unchanged".
+type ProductUpdateOptions struct {
+	// Stock sets the product's stock, recorded as an adjustment by ActorID.
+	Stock *int
+	// ReorderThreshold sets the low-stock alert threshold; 0 turns it off.
+	ReorderThreshold *int
+	ActorID          uint
+	// IfVersion makes the update fail with a repository.VersionConflictError
+	// unless the product is still at this version. 0 skips the check.
+	IfVersion int
 }
 
 // CreateProduct validates and creates a new product.
@@ -25,54 +87,314 @@ func (s *ProductService) CreateProduct(product *models.Product) error {
 	return s.repo.CreateProduct(product)
 }
 
-// GetProduct retrieves a product by its ID.
-func (s *ProductService) GetProductByID(id uint) (*models.Product, error) {
-	product, err := s.repo.GetProductByID(id)
+// GetProduct retrieves a product by its ID. Soft-deleted products are only
+// found when includeDeleted is set.
+func (s *ProductService) GetProductByID(id uint, includeDeleted bool) (*models.Product, error) {
+	getProduct := s.repo.GetProductByID
+	if includeDeleted {
+		getProduct = s.repo.GetProductIncludingDeleted
+	}
+	product, err := getProduct(id)
 	if err != nil {
 		return nil, err
 	}
 	return product, nil
 }
 
-// GetProducts retrieves all products.
-func (s *ProductService) GetProducts() ([]models.Product, error) {
-	products, err := s.repo.GetAllProducts()
+// GetProducts retrieves all products, soft-deleted ones too when includeDeleted is set.
+func (s *ProductService) GetProducts(includeDeleted bool) ([]models.Product, error) {
+	products, err := s.repo.GetAllProducts(includeDeleted)
 	if err != nil {
 		return nil, err
 	}
 	return products, nil
 }
 
-// UpdateProduct validates and updates an existing product.
-func (s *ProductService) UpdateProduct(product *models.Product) (*models.Product, error) {
+// UpdateProduct validates and updates an existing product. When a stock
+// level is given the product's stock is set to it, zero included, through
+// the inventory ledger, in the same transaction as the other fields.
+// Wishlist subscribers are notified when the product comes back in stock or
+// its price drops.
+func (s *ProductService) UpdateProduct(product *models.Product, options ProductUpdateOptions) (*models.Product, error) {
 	// Log the incoming product
 	fmt.Println("Received product for update: ", product)
 
+	if options.Stock != nil && *options.Stock < 0 {
+		return nil, errors.New("product stock cannot be negative")
+	}
+	if options.ReorderThreshold != nil && *options.ReorderThreshold < 0 {
+		return nil, errors.New("reorder threshold cannot be negative")
+	}
+
+	previous, err := s.repo.GetProductByID(product.ID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	var stock *repository.StockLevel
+	if options.Stock != nil {
+		stock = newStockLevel(*options.Stock, options.ActorID)
+	}
+
 	// Call repository to update the product
-	updatedProduct, err := s.repo.UpdateProduct(product)
+	updatedProduct, err := s.repo.UpdateProduct(product, options.ReorderThreshold, stock, options.IfVersion)
 	if err != nil {
 		fmt.Println("Error in repository update: ", err)
-		return nil, err
+		return nil, stockLevelError(err)
+	}
+	if stock != nil {
+		s.inventoryService.checkLowStock(&stock.Movement)
 	}
 
+	s.notifyWishlists(previous, updatedProduct)
 	return updatedProduct, nil
 }
 
-// DeleteProduct removes a product by its ID.
+// PatchProduct applies a JSON Merge Patch or JSON Patch, named by format, to
+// a product. Fields left out of the patch keep their value while fields set
+// to null are cleared, so zero values can be set too. The patched product is
+// validated and only the columns that changed are written. A version in the
+// patched product, or options.IfVersion, must match the current version.
+// A stock change is recorded as an inventory adjustment in the same
+// transaction, and wishlist subscribers are notified as for UpdateProduct.
+func (s *ProductService) PatchProduct(id uint, format string, patchDoc []byte, options ProductUpdateOptions) (*models.Product, error) {
+	current, err := s.repo.GetProductByID(id)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	previous := *current
+	if options.IfVersion != 0 && options.IfVersion != current.Version {
+		return nil, &repository.VersionConflictError{Resource: "product", CurrentVersion: current.Version}
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := patch.Apply(format, doc, patchDoc)
+	if err != nil {
+		if errors.Is(err, patch.ErrTestFailed) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	// Decoding into a fresh product turns removed and null fields into zero values
+	var product models.Product
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&product); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if product.Version != 0 && product.Version != current.Version {
+		return nil, &repository.VersionConflictError{Resource: "product", CurrentVersion: current.Version}
+	}
+	if product.ID != current.ID || !product.CreatedAt.Equal(current.CreatedAt) ||
+		!product.UpdatedAt.Equal(current.UpdatedAt) || product.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w: id, created_at, updated_at and deleted_at cannot be changed", ErrInvalidPatch)
+	}
+	if err := validateProduct(&product); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	changes := map[string]interface{}{}
+	before := productColumns(current)
+	for column, value := range productColumns(&product) {
+		if value != before[column] {
+			changes[column] = value
+		}
+	}
+	var stock *repository.StockLevel
+	if product.Stock != current.Stock {
+		stock = newStockLevel(product.Stock, options.ActorID)
+	}
+	if len(changes) > 0 || stock != nil {
+		if err := s.repo.PatchProduct(current, changes, stock); err != nil {
+			return nil, stockLevelError(err)
+		}
+	}
+	if stock != nil {
+		s.inventoryService.checkLowStock(&stock.Movement)
+	}
+
+	s.notifyWishlists(&previous, current)
+	return current, nil
+}
+
+// notifyWishlists queues wishlist notifications for a product update. The
+// update is already saved, so a failure is only logged.
+func (s *ProductService) notifyWishlists(before, after *models.Product) {
+	if err := s.wishlistService.ProductChanged(before, after); err != nil {
+		logger.Error(fmt.Sprintf("could not queue wishlist notifications for product %d: %s", after.ID, err.Error()))
+	}
+}
+
+// productColumns maps the product columns a patch can change to their values.
+// Stock is left out because it changes through the inventory ledger.
+func productColumns(product *models.Product) map[string]interface{} {
+	return map[string]interface{}{
+		"sku":               product.SKU,
+		"name":              product.Name,
+		"description":       product.Description,
+		"category":          product.Category,
+		"tax_class":         product.TaxClass,
+		"price":             product.Price,
+		"reorder_threshold": product.ReorderThreshold,
+		"weight":            product.Weight,
+		"length":            product.Length,
+		"width":             product.Width,
+		"height":            product.Height,
+	}
+}
+
+// newStockLevel sets a product's total stock through the inventory ledger as
+// an adjustment made by actorID within a product update.
+func newStockLevel(level int, actorID uint) *repository.StockLevel {
+	return &repository.StockLevel{
+		Level: level,
+		Movement: models.StockMovement{
+			Reason:  models.StockReasonAdjustment,
+			Note:    "product update",
+			ActorID: actorID,
+		},
+	}
+}
+
+// stockLevelError explains a product update failing because the stock held
+// at warehouses is more than the level asked for.
+func stockLevelError(err error) error {
+	if err.Error() == "insufficient stock" {
+		return errors.New("stock held at warehouses must be adjusted per warehouse")
+	}
+	return err
+}
+
+// ImportProducts creates or updates products by SKU from a bulk file (admin
+// privilege). Every row is checked with validateProduct, and valid rows are
+// written in batches, each in its own transaction. A row the database
+// rejects is reported on its own; a batch that fails as a whole is reported
+// against all of its rows. A dry run checks everything, including
+// against the database, without keeping any change.
+func (s *ProductService) ImportProducts(reader catalog.Reader, dryRun bool, actorID uint) (*ImportReport, error) {
+	report := &ImportReport{DryRun: dryRun, Errors: []ImportRowError{}}
+	seen := make(map[string]int)
+
+	var batch []repository.ProductUpsert
+	var lines []int
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		created, updated, rowErrs, err := s.repo.UpsertProducts(batch, actorID, dryRun)
+		if err != nil {
+			for i, upsert := range batch {
+				report.fail(lines[i], upsert.Product.SKU, "batch not imported: "+err.Error())
+			}
+		} else {
+			report.Created += created
+			report.Updated += updated
+			for i, upsert := range batch {
+				if rowErr, ok := rowErrs[i]; ok {
+					report.fail(lines[i], upsert.Product.SKU, rowErr.Error())
+				}
+			}
+		}
+		batch, lines = batch[:0], lines[:0]
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		report.Rows++
+
+		product := row.Product
+		if row.Err != nil {
+			report.fail(row.Line, product.SKU, row.Err.Error())
+			continue
+		}
+		product.SKU = strings.TrimSpace(product.SKU)
+		if product.SKU == "" {
+			report.fail(row.Line, "", "sku is required")
+			continue
+		}
+		if first, ok := seen[product.SKU]; ok {
+			report.fail(row.Line, product.SKU, fmt.Sprintf("duplicate sku, first seen on line %d", first))
+			continue
+		}
+		seen[product.SKU] = row.Line
+
+		// Only new products get the default; updates leave an absent tax
+		// class unchanged
+		if product.TaxClass == "" {
+			product.TaxClass = "standard"
+		}
+		if row.Stock != nil {
+			product.Stock = *row.Stock
+		}
+		if err := validateProduct(&product); err != nil {
+			report.fail(row.Line, product.SKU, err.Error())
+			continue
+		}
+
+		batch = append(batch, repository.ProductUpsert{Product: product, Stock: row.Stock, Columns: row.Columns})
+		lines = append(lines, row.Line)
+		if len(batch) == importBatchSize {
+			flush()
+		}
+	}
+	flush()
+	return report, nil
+}
+
+// ExportProducts writes the whole catalog, a batch at a time.
+func (s *ProductService) ExportProducts(writer catalog.Writer) error {
+	err := s.repo.EachProduct(exportBatchSize, func(products []models.Product) error {
+		for i := range products {
+			if err := writer.Write(&products[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// DeleteProduct soft-deletes a product by its ID. It disappears from the
+// catalog but stays available to order history until it is purged.
 func (s *ProductService) DeleteProduct(id uint) error {
 	return s.repo.DeleteProduct(id)
 }
 
+// RestoreProduct brings back a soft-deleted product (admin privilege).
+func (s *ProductService) RestoreProduct(id uint) error {
+	return s.repo.RestoreProduct(id)
+}
+
 // validateProduct checks if the product fields are valid.
 func validateProduct(product *models.Product) error {
+	product.SKU = strings.TrimSpace(product.SKU)
 	if product.Name == "" {
 		return errors.New("product name is required")
 	}
-	if product.Price <= 0 {
-		return errors.New("product price must be greater than zero")
+	if product.Price < 0 {
+		return errors.New("product price cannot be negative")
 	}
 	if product.Stock < 0 {
 		return errors.New("product stock cannot be negative")
 	}
+	if product.ReorderThreshold < 0 {
+		return errors.New("reorder threshold cannot be negative")
+	}
+	if product.Weight < 0 || product.Length < 0 || product.Width < 0 || product.Height < 0 {
+		return errors.New("product weight and dimensions cannot be negative")
+	}
 	return nil
 }
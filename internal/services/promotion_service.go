@@ -0,0 +1,261 @@
+package services
+
+import (
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/repository"
+	"ecommerce-api/internal/utils"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// CodeRejection explains why a promotion code could not be applied.
+type CodeRejection struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// PromotionError is returned when one or more promotion codes were rejected.
+type PromotionError struct {
+	Rejections []CodeRejection
+}
+
+// Error implements the error interface.
+func (e *PromotionError) Error() string {
+	reasons := make([]string, 0, len(e.Rejections))
+	for _, r := range e.Rejections {
+		reasons = append(reasons, r.Code+": "+r.Reason)
+	}
+	return "promotion code rejected (" + strings.Join(reasons, "; ") + ")"
+}
+
+// PromotionService handles promotions and applies discount codes to orders.
+type PromotionService struct {
+	repo repository.PromotionRepository
+}
+
+// NewPromotionService creates a new PromotionService instance.
+func NewPromotionService(repo repository.PromotionRepository) *PromotionService {
+	return &PromotionService{repo: repo}
+}
+
+// CreatePromotion validates and creates a new promotion.
+func (s *PromotionService) CreatePromotion(promotion *models.Promotion) error {
+	promotion.Code = strings.ToUpper(strings.TrimSpace(promotion.Code))
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+	promotion.UsageCount = 0
+	return s.repo.CreatePromotion(promotion)
+}
+
+// GetPromotions retrieves all promotions.
+func (s *PromotionService) GetPromotions() ([]models.Promotion, error) {
+	return s.repo.GetAllPromotions()
+}
+
+// GetPromotionByID retrieves a promotion by its ID.
+func (s *PromotionService) GetPromotionByID(id uint) (*models.Promotion, error) {
+	return s.repo.GetPromotionByID(id)
+}
+
+// UpdatePromotion validates and replaces an existing promotion.
+func (s *PromotionService) UpdatePromotion(promotion *models.Promotion) error {
+	promotion.Code = strings.ToUpper(strings.TrimSpace(promotion.Code))
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+	return s.repo.UpdatePromotion(promotion)
+}
+
+// DeletePromotion removes a promotion by its ID.
+func (s *PromotionService) DeletePromotion(id uint) error {
+	return s.repo.DeletePromotion(id)
+}
+
+// ApplyPromotions checks the codes against the priced order and sets its
+// discount and redemptions. It must run after the subtotal and shipping are
+// known. If any code is rejected, nothing is applied and a *PromotionError
+// explains why.
+func (s *PromotionService) ApplyPromotions(order *models.Order, product *models.Product, codes []string, now time.Time) error {
+	order.DiscountTotal = 0
+	order.Redemptions = nil
+
+	var rejections []CodeRejection
+	var accepted []*models.Promotion
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		promotion, err := s.repo.GetPromotionByCode(code)
+		if err != nil {
+			if err.Error() == "promotion not found" {
+				rejections = append(rejections, CodeRejection{Code: code, Reason: "code does not exist"})
+				continue
+			}
+			return err
+		}
+
+		reason, err := s.checkEligibility(promotion, order, product, now)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			rejections = append(rejections, CodeRejection{Code: code, Reason: reason})
+			continue
+		}
+		accepted = append(accepted, promotion)
+	}
+
+	// A promotion that does not stack can only be used on its own
+	if len(accepted) > 1 {
+		for _, promotion := range accepted {
+			if !promotion.Stackable {
+				rejections = append(rejections, CodeRejection{Code: promotion.Code, Reason: "cannot be combined with other codes"})
+			}
+		}
+	}
+
+	if len(rejections) > 0 {
+		return &PromotionError{Rejections: rejections}
+	}
+
+	// The discount on a line can never exceed what the customer would
+	// otherwise pay for it
+	remaining := map[string]float64{
+		models.OrderLineProduct:  order.Subtotal,
+		models.OrderLineShipping: order.ShippingTotal,
+	}
+	for _, promotion := range accepted {
+		line := promotionLine(promotion)
+		discount := math.Min(promotionDiscount(promotion, order), remaining[line])
+		discount = utils.RoundMoney(discount)
+		remaining[line] -= discount
+		order.DiscountTotal += discount
+		order.Redemptions = append(order.Redemptions, models.PromotionRedemption{
+			PromotionID: promotion.ID,
+			UserID:      order.UserID,
+			Code:        promotion.Code,
+			Discount:    discount,
+			Line:        line,
+		})
+	}
+	order.DiscountTotal = utils.RoundMoney(order.DiscountTotal)
+	return nil
+}
+
+// checkEligibility returns why the promotion cannot be used on the order, or
+// an empty string if it can.
+func (s *PromotionService) checkEligibility(promotion *models.Promotion, order *models.Order, product *models.Product, now time.Time) (string, error) {
+	switch {
+	case !promotion.Active:
+		return "promotion is not active", nil
+	case promotion.StartsAt != nil && now.Before(*promotion.StartsAt):
+		return "promotion has not started yet", nil
+	case promotion.EndsAt != nil && !now.Before(*promotion.EndsAt):
+		return "promotion has expired", nil
+	case promotion.UsageLimit > 0 && promotion.UsageCount >= promotion.UsageLimit:
+		return "promotion usage limit reached", nil
+	case order.Subtotal < promotion.MinOrderValue:
+		return fmt.Sprintf("order subtotal must be at least %.2f", promotion.MinOrderValue), nil
+	case !isProductEligible(promotion, product):
+		return "product is not eligible for this promotion", nil
+	}
+
+	if promotion.Type == models.PromotionTypeBuyXGetY && order.Quantity < promotion.BuyQuantity+promotion.GetQuantity {
+		return fmt.Sprintf("order at least %d items to get %d free", promotion.BuyQuantity+promotion.GetQuantity, promotion.GetQuantity), nil
+	}
+
+	if promotion.PerUserLimit > 0 {
+		used, err := s.repo.CountRedemptionsByUser(promotion.ID, order.UserID)
+		if err != nil {
+			return "", err
+		}
+		if used >= int64(promotion.PerUserLimit) {
+			return fmt.Sprintf("code can only be used %d time(s) per customer", promotion.PerUserLimit), nil
+		}
+	}
+	return "", nil
+}
+
+// isProductEligible reports whether the promotion applies to the product.
+func isProductEligible(promotion *models.Promotion, product *models.Product) bool {
+	if len(promotion.EligibleProductIDs) == 0 && len(promotion.EligibleCategories) == 0 {
+		return true
+	}
+	for _, id := range promotion.EligibleProductIDs {
+		if id == product.ID {
+			return true
+		}
+	}
+	for _, category := range promotion.EligibleCategories {
+		if product.Category != "" && strings.EqualFold(category, product.Category) {
+			return true
+		}
+	}
+	return false
+}
+
+// promotionLine returns the order line a promotion's discount comes off.
+func promotionLine(promotion *models.Promotion) string {
+	if promotion.Type == models.PromotionTypeFreeShipping {
+		return models.OrderLineShipping
+	}
+	return models.OrderLineProduct
+}
+
+// promotionDiscount works out the discount a promotion gives on the order.
+func promotionDiscount(promotion *models.Promotion, order *models.Order) float64 {
+	switch promotion.Type {
+	case models.PromotionTypePercentage:
+		return order.Subtotal * promotion.Value / 100
+	case models.PromotionTypeFixedAmount:
+		return math.Min(promotion.Value, order.Subtotal)
+	case models.PromotionTypeFreeShipping:
+		return order.ShippingTotal
+	case models.PromotionTypeBuyXGetY:
+		groups := order.Quantity / (promotion.BuyQuantity + promotion.GetQuantity)
+		return float64(groups*promotion.GetQuantity) * order.UnitPrice
+	}
+	return 0
+}
+
+// validatePromotion checks if the promotion fields are valid.
+func validatePromotion(promotion *models.Promotion) error {
+	if promotion.Code == "" {
+		return errors.New("promotion code is required")
+	}
+	switch promotion.Type {
+	case models.PromotionTypePercentage:
+		if promotion.Value <= 0 || promotion.Value > 100 {
+			return errors.New("percentage must be between 0 and 100")
+		}
+	case models.PromotionTypeFixedAmount:
+		if promotion.Value <= 0 {
+			return errors.New("fixed amount must be greater than zero")
+		}
+	case models.PromotionTypeFreeShipping:
+	case models.PromotionTypeBuyXGetY:
+		if promotion.BuyQuantity <= 0 || promotion.GetQuantity <= 0 {
+			return errors.New("buy and get quantities must be greater than zero")
+		}
+	default:
+		return errors.New("invalid promotion type. Valid values are: percentage, fixed_amount, free_shipping, buy_x_get_y")
+	}
+	if promotion.MinOrderValue < 0 {
+		return errors.New("minimum order value cannot be negative")
+	}
+	if promotion.UsageLimit < 0 || promotion.PerUserLimit < 0 {
+		return errors.New("usage limits cannot be negative")
+	}
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return errors.New("promotion must end after it starts")
+	}
+	return nil
+}
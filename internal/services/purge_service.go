@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"ecommerce-api/internal/blob"
+	"ecommerce-api/internal/logger"
+	"ecommerce-api/internal/repository"
+	"time"
+)
+
+// PurgeService permanently removes soft-deleted orders, users and products
+// once their retention period is over.
+type PurgeService struct {
+	orderRepo   repository.OrderRepositoryInterface
+	userRepo    *repository.UserRepository
+	productRepo repository.ProductRepository
+	store       blob.BlobStore
+}
+
+// NewPurgeService creates a new PurgeService instance.
+func NewPurgeService(
+	orderRepo repository.OrderRepositoryInterface,
+	userRepo *repository.UserRepository,
+	productRepo repository.ProductRepository,
+	store blob.BlobStore,
+) *PurgeService {
+	return &PurgeService{orderRepo: orderRepo, userRepo: userRepo, productRepo: productRepo, store: store}
+}
+
+// PurgeDeletedOrders permanently removes orders deleted before the cutoff.
+// Invoiced and refunded orders are kept.
+func (s *PurgeService) PurgeDeletedOrders(ctx context.Context, cutoff time.Time) (int64, error) {
+	return s.orderRepo.PurgeDeletedOrders(cutoff)
+}
+
+// PurgeDeletedUsers permanently removes users deleted before the cutoff that
+// no order refers to any more.
+func (s *PurgeService) PurgeDeletedUsers(ctx context.Context, cutoff time.Time) (int64, error) {
+	return s.userRepo.PurgeDeletedUsers(cutoff)
+}
+
+// PurgeDeletedProducts permanently removes products deleted before the
+// cutoff that no order refers to any more, along with their image files.
+// Files that cannot be deleted are logged and left behind.
+func (s *PurgeService) PurgeDeletedProducts(ctx context.Context, cutoff time.Time) (int64, error) {
+	images, purged, err := s.productRepo.PurgeDeletedProducts(cutoff)
+	if err != nil {
+		return 0, err
+	}
+	for _, image := range images {
+		keys := []string{image.Key}
+		for _, thumbnail := range image.Thumbnails {
+			keys = append(keys, thumbnail.Key)
+		}
+		for _, key := range keys {
+			if err := s.store.Delete(ctx, key); err != nil {
+				logger.Error("could not delete image file " + key + ": " + err.Error())
+			}
+		}
+	}
+	return purged, nil
+}
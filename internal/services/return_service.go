@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/payment"
+	"ecommerce-api/internal/repository"
+	"ecommerce-api/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// ReturnService handles return requests (RMAs) and the refunds issued for them.
+type ReturnService struct {
+	repo      repository.ReturnRepository
+	orderRepo repository.OrderRepositoryInterface
+	payments  payment.Provider
+	invoices  *InvoiceService
+}
+
+// NewReturnService creates a new ReturnService instance.
+func NewReturnService(
+	repo repository.ReturnRepository,
+	orderRepo repository.OrderRepositoryInterface,
+	payments payment.Provider,
+	invoices *InvoiceService,
+) *ReturnService {
+	return &ReturnService{repo: repo, orderRepo: orderRepo, payments: payments, invoices: invoices}
+}
+
+// RequestReturn opens a return request for items of one of the user's
+// completed orders.
+func (s *ReturnService) RequestReturn(rma *models.ReturnRequest) error {
+	rma.Reason = strings.TrimSpace(rma.Reason)
+	if rma.Reason == "" {
+		return errors.New("reason is required")
+	}
+	if len(rma.Items) == 0 {
+		return errors.New("at least one item is required")
+	}
+
+	order, err := s.orderRepo.GetOrderByID(rma.OrderID)
+	if err != nil {
+		return err
+	}
+	if order.UserID != rma.UserID {
+		return errors.New("order not found")
+	}
+	if order.Status != models.OrderStatusCompleted {
+		return errors.New("only completed orders can be returned")
+	}
+
+	for i := range rma.Items {
+		rma.Items[i].ID = 0
+		if rma.Items[i].ProductID != order.ProductID {
+			return errors.New("return contains a product that is not in the order")
+		}
+		if rma.Items[i].Quantity <= 0 {
+			return errors.New("quantity must be greater than zero")
+		}
+	}
+	return s.repo.CreateReturn(rma)
+}
+
+// GetReturnsByOrder retrieves the return requests of an order for its owner.
+// Admins may retrieve those of any order.
+func (s *ReturnService) GetReturnsByOrder(orderID, userID uint, isAdmin bool) ([]models.ReturnRequest, error) {
+	order, err := s.orderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && order.UserID != userID {
+		return nil, errors.New("order not found")
+	}
+	return s.repo.GetReturnsByOrder(orderID)
+}
+
+// ListReturns retrieves all return requests, optionally in one status (admin privilege).
+func (s *ReturnService) ListReturns(status string) ([]models.ReturnRequest, error) {
+	return s.repo.ListReturns(status)
+}
+
+// GetReturn retrieves a return request by its ID (admin privilege).
+func (s *ReturnService) GetReturn(id uint) (*models.ReturnRequest, error) {
+	return s.repo.GetReturnByID(id)
+}
+
+// ApproveReturn accepts a requested return so the customer can send the goods back.
+func (s *ReturnService) ApproveReturn(id, actorID uint, note string) (*models.ReturnRequest, error) {
+	return s.repo.TransitionReturn(id, models.ReturnStatusApproved, actorID, note)
+}
+
+// RejectReturn declines a requested return.
+func (s *ReturnService) RejectReturn(id, actorID uint, note string) (*models.ReturnRequest, error) {
+	if strings.TrimSpace(note) == "" {
+		return nil, errors.New("a note explaining the rejection is required")
+	}
+	return s.repo.TransitionReturn(id, models.ReturnStatusRejected, actorID, note)
+}
+
+// ReceiveReturn records that the returned goods arrived, optionally putting them back into stock.
+func (s *ReturnService) ReceiveReturn(id, actorID uint, restock bool, note string) (*models.ReturnRequest, error) {
+	return s.repo.ReceiveReturn(id, actorID, restock, note)
+}
+
+// RefundReturn refunds a received return through the payment provider and
+// issues a credit note for it. Without an amount the returned items are
+// refunded in full at the price paid, including their share of tax, shipping
+// and discounts, less what was already refunded; a given amount cannot be
+// more than that. A refunded return can be refunded again, and a refund the
+// provider rejects can be retried.
+func (s *ReturnService) RefundReturn(ctx context.Context, id, actorID uint, amount *float64, note string) (*models.ReturnRequest, error) {
+	rma, err := s.repo.GetReturnByID(id)
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.orderRepo.GetOrderByID(rma.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	refundAmount := fullRefundAmount(order, rma)
+	if refundAmount <= 0 {
+		return nil, errors.New("nothing left to refund")
+	}
+	if amount != nil {
+		if *amount <= 0 {
+			return nil, errors.New("refund amount must be greater than zero")
+		}
+		// A partial refund cannot pay out more than the returned items' share
+		if utils.RoundMoney(*amount) > refundAmount {
+			return nil, fmt.Errorf("refund exceeds the return's refundable amount of %.2f", refundAmount)
+		}
+		refundAmount = utils.RoundMoney(*amount)
+	}
+
+	// The refund is recorded before the provider is called and settled after,
+	// so money is never paid out without a record of it.
+	refund, err := s.repo.BeginRefund(id, refundAmount)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.payments.Refund(ctx, payment.RefundRequest{
+		OrderID:   rma.OrderID,
+		Amount:    refundAmount,
+		Reference: fmt.Sprintf("rma-%d-refund-%d", rma.ID, refund.ID),
+		Reason:    rma.Reason,
+	})
+	if err != nil {
+		if failErr := s.repo.FailRefund(refund.ID, err.Error()); failErr != nil {
+			log.Printf("Could not record failed refund %d: %v", refund.ID, failErr)
+		}
+		return nil, err
+	}
+	refunded, err := s.repo.CompleteRefund(refund.ID, result.ProviderReference, actorID, note)
+	if err != nil {
+		// The refund stays pending, keeping its amount reserved, until it
+		// is reconciled with the provider's reference.
+		log.Printf("Refund %d was paid out as %s but could not be recorded: %v", refund.ID, result.ProviderReference, err)
+		return nil, err
+	}
+
+	// The money has been paid out, so a failure here must not fail the
+	// request; the credit note is issued again when it is next requested.
+	if err := s.invoices.IssueCreditNotes(refunded.OrderID); err != nil {
+		log.Printf("Could not issue credit note for return %d: %v", refunded.ID, err)
+	}
+	return refunded, nil
+}
+
+// fullRefundAmount is what the customer paid for the returned items, less
+// what was refunded for them before, limited to what has not been refunded yet.
+func fullRefundAmount(order *models.Order, rma *models.ReturnRequest) float64 {
+	quantity := 0
+	for _, item := range rma.Items {
+		quantity += item.Quantity
+	}
+	amount := order.GrandTotal*float64(quantity)/float64(order.Quantity) - rma.RefundedAmount
+	return utils.RoundMoney(math.Min(amount, order.GrandTotal-order.RefundedTotal))
+}
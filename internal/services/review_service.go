@@ -0,0 +1,188 @@
+package services
+
+import (
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/repository"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxReviewTitleLength = 200
+	maxReviewBodyLength  = 5000
+)
+
+// ReviewService handles product reviews, their moderation and helpful votes.
+type ReviewService struct {
+	repo        repository.ReviewRepository
+	productRepo repository.ProductRepository
+}
+
+// NewReviewService creates a new ReviewService instance.
+func NewReviewService(repo repository.ReviewRepository, productRepo repository.ProductRepository) *ReviewService {
+	return &ReviewService{repo: repo, productRepo: productRepo}
+}
+
+// CreateReview posts a review for a product the user bought. Only users
+// with a completed order for the product can review it, once. New reviews
+// wait for moderation before they are shown.
+func (s *ReviewService) CreateReview(review *models.Review) error {
+	if err := validateReview(review); err != nil {
+		return err
+	}
+	if _, err := s.productRepo.GetProductByID(review.ProductID); err != nil {
+		return err
+	}
+
+	orderID, err := s.repo.GetCompletedOrderID(review.UserID, review.ProductID)
+	if err != nil {
+		return err
+	}
+	if orderID == 0 {
+		return errors.New("only customers who bought the product can review it")
+	}
+
+	review.ID = 0
+	review.OrderID = orderID
+	review.Status = models.ReviewStatusPending
+	review.HelpfulCount = 0
+	review.ModeratorID = nil
+	review.ModerationNote = ""
+	review.ModeratedAt = nil
+	return s.repo.CreateReview(review)
+}
+
+// UpdateReview changes the rating, title and body of one of the user's
+// reviews. The edited review goes back to moderation.
+func (s *ReviewService) UpdateReview(id, userID uint, changes models.Review) (*models.Review, error) {
+	if err := validateReview(&changes); err != nil {
+		return nil, err
+	}
+	review, err := s.repo.GetReviewByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if review.UserID != userID {
+		return nil, errors.New("review not found")
+	}
+
+	review.Rating = changes.Rating
+	review.Title = changes.Title
+	review.Body = changes.Body
+	review.Status = models.ReviewStatusPending
+	review.ModeratorID = nil
+	review.ModerationNote = ""
+	review.ModeratedAt = nil
+	if err := s.repo.UpdateReview(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// DeleteReview removes a review. Users can only delete their own reviews;
+// admins can delete any review.
+func (s *ReviewService) DeleteReview(id, userID uint, isAdmin bool) error {
+	review, err := s.repo.GetReviewByID(id)
+	if err != nil {
+		return err
+	}
+	if !isAdmin && review.UserID != userID {
+		return errors.New("review not found")
+	}
+	return s.repo.DeleteReview(id)
+}
+
+// GetProductReviews retrieves a page of a product's approved reviews along
+// with its rating summary. The page and page size are brought into range on
+// the filter itself, as for ListReviews.
+func (s *ReviewService) GetProductReviews(filter *repository.ReviewFilter) ([]models.Review, int64, *models.ProductRating, error) {
+	if _, err := s.productRepo.GetProductByID(filter.ProductID); err != nil {
+		return nil, 0, nil, err
+	}
+	filter.UserID = 0
+	filter.Status = models.ReviewStatusApproved
+
+	reviews, total, err := s.ListReviews(filter)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	rating, err := s.repo.GetProductRating(filter.ProductID)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return reviews, total, rating, nil
+}
+
+// ListReviews retrieves a filtered, sorted page of reviews in any
+// moderation state (admin privilege). The page and page size are brought
+// into range on the filter itself, so the caller can report the ones used.
+func (s *ReviewService) ListReviews(filter *repository.ReviewFilter) ([]models.Review, int64, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultPageSize
+	}
+	if filter.PageSize > maxPageSize {
+		filter.PageSize = maxPageSize
+	}
+	return s.repo.ListReviews(*filter)
+}
+
+// ApproveReview publishes a review and counts it in the product's rating.
+func (s *ReviewService) ApproveReview(id, moderatorID uint, note string) (*models.Review, error) {
+	return s.repo.SetReviewStatus(id, models.ReviewStatusApproved, moderatorID, strings.TrimSpace(note))
+}
+
+// RejectReview hides a review. A note explaining why is required.
+func (s *ReviewService) RejectReview(id, moderatorID uint, note string) (*models.Review, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, errors.New("a note is required to reject a review")
+	}
+	return s.repo.SetReviewStatus(id, models.ReviewStatusRejected, moderatorID, note)
+}
+
+// VoteHelpful records that the user found an approved review helpful. Users
+// cannot vote for their own reviews.
+func (s *ReviewService) VoteHelpful(id, userID uint) error {
+	review, err := s.repo.GetReviewByID(id)
+	if err != nil {
+		return err
+	}
+	if review.Status != models.ReviewStatusApproved {
+		return errors.New("review not found")
+	}
+	if review.UserID == userID {
+		return errors.New("cannot vote for your own review")
+	}
+	return s.repo.AddHelpfulVote(id, userID)
+}
+
+// RemoveHelpfulVote takes back the user's helpful vote for a review.
+func (s *ReviewService) RemoveHelpfulVote(id, userID uint) error {
+	if _, err := s.repo.GetReviewByID(id); err != nil {
+		return err
+	}
+	return s.repo.RemoveHelpfulVote(id, userID)
+}
+
+// validateReview checks the rating and trims and bounds the title and body.
+func validateReview(review *models.Review) error {
+	if review.Rating < 1 || review.Rating > 5 {
+		return errors.New("rating must be between 1 and 5")
+	}
+	review.Title = strings.TrimSpace(review.Title)
+	review.Body = strings.TrimSpace(review.Body)
+	if review.Title == "" {
+		return errors.New("title is required")
+	}
+	if utf8.RuneCountInString(review.Title) > maxReviewTitleLength {
+		return errors.New("title is too long")
+	}
+	if utf8.RuneCountInString(review.Body) > maxReviewBodyLength {
+		return errors.New("body is too long")
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/repository"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ShipmentService handles shipments and keeps order fulfillment status in step with them.
+type ShipmentService struct {
+	repo      repository.ShipmentRepository
+	orderRepo repository.OrderRepositoryInterface
+}
+
+// NewShipmentService creates a new ShipmentService instance.
+func NewShipmentService(repo repository.ShipmentRepository, orderRepo repository.OrderRepositoryInterface) *ShipmentService {
+	return &ShipmentService{repo: repo, orderRepo: orderRepo}
+}
+
+// CreateShipment records a shipment for an order (admin privilege). Without
+// items the shipment contains everything not shipped yet. Without a shipping
+// time it is taken to have shipped now.
+func (s *ShipmentService) CreateShipment(shipment *models.Shipment) error {
+	shipment.Carrier = strings.TrimSpace(shipment.Carrier)
+	shipment.TrackingNumber = strings.TrimSpace(shipment.TrackingNumber)
+	if shipment.Carrier == "" {
+		return errors.New("carrier is required")
+	}
+
+	order, err := s.orderRepo.GetOrderByID(shipment.OrderID)
+	if err != nil {
+		return err
+	}
+
+	if len(shipment.Items) == 0 {
+		remaining := order.Quantity
+		for _, existing := range order.Shipments {
+			for _, item := range existing.Items {
+				remaining -= item.Quantity
+			}
+		}
+		if remaining <= 0 {
+			return errors.New("order has already been shipped in full")
+		}
+		shipment.Items = []models.ShipmentItem{{ProductID: order.ProductID, Quantity: remaining}}
+	}
+	for i := range shipment.Items {
+		shipment.Items[i].ID = 0
+		if shipment.Items[i].Quantity <= 0 {
+			return errors.New("quantity must be greater than zero")
+		}
+	}
+
+	if shipment.ShippedAt.IsZero() {
+		shipment.ShippedAt = time.Now()
+	}
+	shipment.DeliveredAt = nil
+	return s.repo.CreateShipment(shipment)
+}
+
+// MarkDelivered records a shipment as delivered (admin privilege). Without a
+// delivery time it is taken to have been delivered now.
+func (s *ShipmentService) MarkDelivered(id uint, deliveredAt *time.Time) (*models.Shipment, error) {
+	at := time.Now()
+	if deliveredAt != nil {
+		at = *deliveredAt
+	}
+	return s.repo.MarkDelivered(id, at)
+}
+
+// GetShipmentsByOrder retrieves the shipments of an order.
+func (s *ShipmentService) GetShipmentsByOrder(orderID uint) ([]models.Shipment, error) {
+	if _, err := s.orderRepo.GetOrderByID(orderID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetShipmentsByOrder(orderID)
+}
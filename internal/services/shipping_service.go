@@ -0,0 +1,237 @@
+package services
+
+import (
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/repository"
+	"ecommerce-api/internal/shipping"
+	"errors"
+	"strings"
+)
+
+// CartItem is a product and quantity to quote shipping for.
+type CartItem struct {
+	ProductID uint `json:"product_id"`
+	Quantity  int  `json:"quantity"`
+}
+
+// Destination is where a quote ships to: either one of the user's saved
+// addresses or a country and region.
+type Destination struct {
+	AddressID uint
+	Country   string
+	Region    string
+}
+
+// ShippingService handles shipping zones and methods and prices shipping.
+type ShippingService struct {
+	repo        repository.ShippingRepository
+	productRepo repository.ProductRepository
+	addressRepo repository.AddressRepository
+}
+
+// NewShippingService creates a new ShippingService instance.
+func NewShippingService(
+	repo repository.ShippingRepository,
+	productRepo repository.ProductRepository,
+	addressRepo repository.AddressRepository,
+) *ShippingService {
+	return &ShippingService{repo: repo, productRepo: productRepo, addressRepo: addressRepo}
+}
+
+// CreateZone validates and creates a new shipping zone.
+func (s *ShippingService) CreateZone(zone *models.ShippingZone) error {
+	if err := validateShippingZone(zone); err != nil {
+		return err
+	}
+	return s.repo.CreateZone(zone)
+}
+
+// GetZones retrieves all shipping zones with their methods.
+func (s *ShippingService) GetZones() ([]models.ShippingZone, error) {
+	return s.repo.GetAllZones()
+}
+
+// GetZoneByID retrieves a shipping zone with its methods.
+func (s *ShippingService) GetZoneByID(id uint) (*models.ShippingZone, error) {
+	return s.repo.GetZoneByID(id)
+}
+
+// UpdateZone validates and replaces the name and destinations of a zone.
+func (s *ShippingService) UpdateZone(zone *models.ShippingZone) error {
+	if err := validateShippingZone(zone); err != nil {
+		return err
+	}
+	return s.repo.UpdateZone(zone)
+}
+
+// DeleteZone removes a shipping zone and its methods.
+func (s *ShippingService) DeleteZone(id uint) error {
+	return s.repo.DeleteZone(id)
+}
+
+// CreateMethod validates and adds a shipping method to a zone.
+func (s *ShippingService) CreateMethod(method *models.ShippingMethod) error {
+	if _, err := s.repo.GetZoneByID(method.ZoneID); err != nil {
+		return err
+	}
+	if err := validateShippingMethod(method); err != nil {
+		return err
+	}
+	return s.repo.CreateMethod(method)
+}
+
+// GetMethodByID retrieves a shipping method by its ID.
+func (s *ShippingService) GetMethodByID(id uint) (*models.ShippingMethod, error) {
+	return s.repo.GetMethodByID(id)
+}
+
+// UpdateMethod validates and replaces a shipping method.
+func (s *ShippingService) UpdateMethod(method *models.ShippingMethod) error {
+	if err := validateShippingMethod(method); err != nil {
+		return err
+	}
+	return s.repo.UpdateMethod(method)
+}
+
+// DeleteMethod removes a shipping method by its ID.
+func (s *ShippingService) DeleteMethod(id uint) error {
+	return s.repo.DeleteMethod(id)
+}
+
+// QuoteRates prices the items with every shipping method available for the
+// destination, cheapest first.
+func (s *ShippingService) QuoteRates(userID uint, items []CartItem, destination Destination) ([]shipping.Quote, error) {
+	if len(items) == 0 {
+		return nil, errors.New("at least one item is required")
+	}
+
+	country, region := destination.Country, destination.Region
+	if destination.AddressID != 0 {
+		address, err := s.addressRepo.GetAddressByID(userID, destination.AddressID)
+		if err != nil {
+			return nil, err
+		}
+		country, region = address.Country, address.Region
+	}
+	if country == "" {
+		return nil, errors.New("country or address is required")
+	}
+
+	parcelItems := make([]shipping.Item, 0, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, errors.New("quantity must be greater than zero")
+		}
+		product, err := s.productRepo.GetProductByID(item.ProductID)
+		if err != nil {
+			return nil, errors.New("product not found")
+		}
+		parcelItems = append(parcelItems, shipping.Item{Product: product, Quantity: item.Quantity})
+	}
+
+	zones, err := s.repo.GetAllZones()
+	if err != nil {
+		return nil, err
+	}
+	zone := shipping.MatchZone(zones, country, region)
+	if zone == nil {
+		return []shipping.Quote{}, nil
+	}
+	return shipping.QuoteZone(zone, shipping.NewParcel(parcelItems)), nil
+}
+
+// ApplyShipping prices the chosen shipping method for a priced order and
+// records it on the order. The order must already have a shipping address.
+// Without a chosen method the cheapest one available for the address is
+// used; when none is available the order ships without a charge.
+func (s *ShippingService) ApplyShipping(order *models.Order, product *models.Product, methodID uint) error {
+	if order.ShippingCountry == "" {
+		return errors.New("shipping address is required")
+	}
+
+	zones, err := s.repo.GetAllZones()
+	if err != nil {
+		return err
+	}
+	zone := shipping.MatchZone(zones, order.ShippingCountry, order.ShippingRegion)
+	if zone == nil {
+		if methodID == 0 {
+			return nil
+		}
+		return errors.New("shipping method not available")
+	}
+
+	parcel := shipping.NewParcel([]shipping.Item{{Product: product, Quantity: order.Quantity}})
+	parcel.Subtotal = order.Subtotal
+	var chosen *models.ShippingMethod
+	var chosenCost float64
+	for i := range zone.Methods {
+		method := &zone.Methods[i]
+		if methodID != 0 && method.ID != methodID {
+			continue
+		}
+		cost, ok := shipping.Cost(method, parcel)
+		if !ok {
+			continue
+		}
+		if chosen == nil || cost < chosenCost {
+			chosen, chosenCost = method, cost
+		}
+	}
+	if chosen == nil {
+		if methodID == 0 {
+			return nil
+		}
+		return errors.New("shipping method not available")
+	}
+	order.ShippingMethodID = &chosen.ID
+	order.ShippingMethodName = chosen.Name
+	order.ShippingTotal = chosenCost
+	return nil
+}
+
+// validateShippingZone checks if the shipping zone fields are valid.
+func validateShippingZone(zone *models.ShippingZone) error {
+	zone.Name = strings.TrimSpace(zone.Name)
+	if zone.Name == "" {
+		return errors.New("zone name is required")
+	}
+	if len(zone.Regions) > 0 && len(zone.Countries) == 0 {
+		return errors.New("regions can only be set together with countries")
+	}
+	for i, country := range zone.Countries {
+		zone.Countries[i] = strings.ToUpper(strings.TrimSpace(country))
+	}
+	return nil
+}
+
+// validateShippingMethod checks if the shipping method fields are valid.
+func validateShippingMethod(method *models.ShippingMethod) error {
+	method.Name = strings.TrimSpace(method.Name)
+	if method.Name == "" {
+		return errors.New("method name is required")
+	}
+	switch method.Type {
+	case models.ShippingMethodFlat:
+	case models.ShippingMethodWeightBased:
+		if method.PerKg < 0 {
+			return errors.New("per kg rate cannot be negative")
+		}
+	case models.ShippingMethodFreeThreshold:
+		if method.FreeThreshold <= 0 {
+			return errors.New("free threshold must be greater than zero")
+		}
+	default:
+		return errors.New("invalid shipping method type. Valid values are: flat, weight_based, free_over_threshold")
+	}
+	if method.Rate < 0 {
+		return errors.New("rate cannot be negative")
+	}
+	if method.MinWeight < 0 || method.MaxWeight < 0 {
+		return errors.New("weight limits cannot be negative")
+	}
+	if method.MaxWeight > 0 && method.MaxWeight < method.MinWeight {
+		return errors.New("maximum weight must not be below the minimum weight")
+	}
+	return nil
+}
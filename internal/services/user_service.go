@@ -1,24 +1,52 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"ecommerce-api/internal/auth"
 	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/notify"
+	"ecommerce-api/internal/password"
 	"ecommerce-api/internal/repository"
+	"ecommerce-api/internal/utils"
+	"encoding/hex"
 	"errors"
 	"log"
 	"strconv"
-
-	"golang.org/x/crypto/bcrypt"
+	"time"
 )
 
 // UserService handles business logic related to users.
 type UserService struct {
-	userRepo *repository.UserRepository
+	userRepo       *repository.UserRepository
+	addressRepo    repository.AddressRepository
+	sessionRepo    repository.SessionRepository
+	passwordPolicy *password.Policy
+	hasher         password.PasswordHasher
+	notifier       notify.Notifier
 }
 
+// passwordResetTTL is how long a password reset token stays valid.
+const passwordResetTTL = time.Hour
+
 // NewUserService creates a new UserService instance.
-func NewUserService(userRepo *repository.UserRepository) *UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(
+	userRepo *repository.UserRepository,
+	addressRepo repository.AddressRepository,
+	sessionRepo repository.SessionRepository,
+	passwordPolicy *password.Policy,
+	hasher password.PasswordHasher,
+	notifier notify.Notifier,
+) *UserService {
+	return &UserService{
+		userRepo:       userRepo,
+		addressRepo:    addressRepo,
+		sessionRepo:    sessionRepo,
+		passwordPolicy: passwordPolicy,
+		hasher:         hasher,
+		notifier:       notifier,
+	}
 }
 
 // RegisterUser hashes the user's password and saves the user to the database.
@@ -27,26 +55,58 @@ func (s *UserService) RegisterUser(user *models.User) error {
 	if user.Email == "" || user.Password == "" {
 		return errors.New("email and password are required")
 	}
+	if !utils.ValidateEmail(user.Email) {
+		return errors.New("email address is not valid")
+	}
+	if err := s.passwordPolicy.Validate(user.Password); err != nil {
+		return err
+	}
 
-	// No need to hash the password here, because the BeforeSave hook will handle it
+	if err := user.SetPassword(s.hasher, user.Password); err != nil {
+		return err
+	}
 	return s.userRepo.CreateUser(user)
 }
 
-// AuthenticateUser authenticates a user and generates a JWT token
-func (s *UserService) AuthenticateUser(email, password string) (string, error) {
+// AuthenticateUser authenticates a user, records a login session for the
+// device and generates a JWT token bound to that session.
+func (s *UserService) AuthenticateUser(email, password, userAgent, ip string) (string, error) {
 	user, err := s.userRepo.GetUserByEmail(email)
 	if err != nil || user == nil {
 		return "", errors.New("invalid email or password")
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+	if !user.CheckPassword(s.hasher, password) {
 		log.Printf("Password comparison failed for user %s", user.Email)
 		return "", errors.New("invalid email or password")
 	}
 
+	// Upgrade hashes made with an older algorithm or weaker parameters while
+	// the plain-text password is at hand. A failure here must not block login.
+	if s.hasher.NeedsRehash(user.Password) {
+		if hashed, err := s.hasher.Hash(password); err != nil {
+			log.Printf("Could not rehash password for user %d: %v", user.ID, err)
+		} else if err := s.userRepo.UpdatePasswordHash(user.ID, hashed); err != nil {
+			log.Printf("Could not store rehashed password for user %d: %v", user.ID, err)
+		}
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		UserID:     user.ID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(auth.TokenTTL),
+	}
+	if err := s.sessionRepo.CreateSession(session); err != nil {
+		return "", err
+	}
+
 	userIDStr := strconv.Itoa(int(user.ID))
-	// Pass both userID and role to GenerateToken
-	token, err := auth.GenerateToken(userIDStr, user.Role)
+	sessionIDStr := strconv.Itoa(int(session.ID))
+	// Pass userID, role and session to GenerateToken
+	token, err := auth.GenerateToken(userIDStr, user.Role, sessionIDStr)
 	if err != nil {
 		return "", err
 	}
@@ -61,7 +121,7 @@ func (s *UserService) LoginUser(email, password string) (*models.User, error) {
 		return nil, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+	if !user.CheckPassword(s.hasher, password) {
 		return nil, errors.New("invalid credentials")
 	}
 
@@ -78,10 +138,211 @@ func (s *UserService) UpdateUser(user *models.User) error {
 	if user.Email == "" {
 		return errors.New("email is required")
 	}
+	// Only a new plain-text password needs hashing; a stored hash is kept as is
+	if user.Password != "" && !password.IsHash(user.Password) {
+		if err := s.passwordPolicy.Validate(user.Password); err != nil {
+			return err
+		}
+		if err := user.SetPassword(s.hasher, user.Password); err != nil {
+			return err
+		}
+	}
 	return s.userRepo.UpdateUser(user)
 }
 
-// DeleteUser removes a user from the database.
+// DeleteUser soft-deletes a user and logs out all their sessions (admin
+// privilege). The user's orders are kept.
 func (s *UserService) DeleteUser(id uint) error {
-	return s.userRepo.DeleteUser(id)
+	if err := s.userRepo.DeleteUser(id); err != nil {
+		return err
+	}
+	_, err := s.sessionRepo.RevokeOtherSessions(id, 0)
+	return err
+}
+
+// GetDeletedUsers lists the soft-deleted users (admin privilege).
+func (s *UserService) GetDeletedUsers() ([]models.User, error) {
+	return s.userRepo.GetDeletedUsers()
+}
+
+// RestoreUser brings back a soft-deleted user (admin privilege). They log in
+// again with their old password.
+func (s *UserService) RestoreUser(id uint) error {
+	return s.userRepo.RestoreUser(id)
+}
+
+// SetTaxExempt sets whether a user is exempt from tax on new orders (admin
+// privilege). Orders already placed keep their tax.
+func (s *UserService) SetTaxExempt(id uint, exempt bool) error {
+	return s.userRepo.SetTaxExempt(id, exempt)
+}
+
+// ChangePassword replaces the user's password after checking the current one.
+// All other sessions are logged out.
+func (s *UserService) ChangePassword(userID, currentSessionID uint, currentPassword, newPassword string) error {
+	user, err := s.userRepo.GetUserWithPasswordByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil || !user.CheckPassword(s.hasher, currentPassword) {
+		return errors.New("current password is incorrect")
+	}
+	if err := s.passwordPolicy.Validate(newPassword); err != nil {
+		return err
+	}
+
+	if err := user.SetPassword(s.hasher, newPassword); err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return err
+	}
+
+	_, err = s.sessionRepo.RevokeOtherSessions(userID, currentSessionID)
+	return err
+}
+
+// RequestPasswordReset sends a single-use reset token to the user. Unknown
+// emails are ignored so the endpoint cannot be used to discover accounts.
+func (s *UserService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(raw)
+
+	resetToken := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashResetToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}
+	if err := s.userRepo.CreatePasswordResetToken(resetToken); err != nil {
+		return err
+	}
+
+	return s.notifier.Notify(ctx, notify.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    "Use this token to choose a new password within the next hour: " + token,
+	})
+}
+
+// ResetPassword sets a new password using a reset token and logs out every session.
+func (s *UserService) ResetPassword(token, newPassword string) error {
+	if err := s.passwordPolicy.Validate(newPassword); err != nil {
+		return err
+	}
+
+	resetToken, err := s.userRepo.ConsumePasswordResetToken(hashResetToken(token), time.Now())
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetUserWithPasswordByID(resetToken.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("invalid or expired reset token")
+	}
+
+	if err := user.SetPassword(s.hasher, newPassword); err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return err
+	}
+
+	_, err = s.sessionRepo.RevokeOtherSessions(user.ID, 0)
+	return err
+}
+
+// hashResetToken returns the form of a reset token that is stored in the database.
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetSessions retrieves the active login sessions of a user.
+func (s *UserService) GetSessions(userID uint) ([]models.Session, error) {
+	return s.sessionRepo.GetActiveSessionsByUser(userID)
+}
+
+// RevokeSession logs out one of the user's sessions.
+func (s *UserService) RevokeSession(userID, sessionID uint) error {
+	return s.sessionRepo.RevokeSession(userID, sessionID)
+}
+
+// RevokeOtherSessions logs the user out everywhere except the current session.
+func (s *UserService) RevokeOtherSessions(userID, currentSessionID uint) (int64, error) {
+	return s.sessionRepo.RevokeOtherSessions(userID, currentSessionID)
+}
+
+// LogoutToken revokes the session behind an access token. Invalid tokens are ignored
+// because the caller is logging out anyway.
+func (s *UserService) LogoutToken(token string) error {
+	claims, err := auth.ParseToken(token)
+	if err != nil || claims.SessionID == "" {
+		return nil
+	}
+
+	userID, err := strconv.ParseUint(claims.UserID, 10, 32)
+	if err != nil {
+		return nil
+	}
+	sessionID, err := strconv.ParseUint(claims.SessionID, 10, 32)
+	if err != nil {
+		return nil
+	}
+
+	if err := s.sessionRepo.RevokeSession(uint(userID), uint(sessionID)); err != nil && err.Error() != "session not found" {
+		return err
+	}
+	return nil
+}
+
+// AddAddress validates and saves a new address for a user.
+func (s *UserService) AddAddress(address *models.Address) error {
+	if err := validateAddress(address); err != nil {
+		return err
+	}
+	return s.addressRepo.CreateAddress(address)
+}
+
+// GetAddresses retrieves all addresses saved by a user.
+func (s *UserService) GetAddresses(userID uint) ([]models.Address, error) {
+	return s.addressRepo.GetAddressesByUser(userID)
+}
+
+// DeleteAddress removes one of the user's saved addresses.
+func (s *UserService) DeleteAddress(userID, addressID uint) error {
+	return s.addressRepo.DeleteAddress(userID, addressID)
+}
+
+// validateAddress checks if the address fields are valid.
+func validateAddress(address *models.Address) error {
+	if address.UserID == 0 {
+		return errors.New("user ID is required")
+	}
+	if address.FullName == "" {
+		return errors.New("full name is required")
+	}
+	if address.Line1 == "" {
+		return errors.New("address line 1 is required")
+	}
+	if address.City == "" {
+		return errors.New("city is required")
+	}
+	if address.Country == "" {
+		return errors.New("country is required")
+	}
+	return nil
 }
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"ecommerce-api/internal/logger"
+	"ecommerce-api/internal/models"
+	"ecommerce-api/internal/notify"
+	"ecommerce-api/internal/repository"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// wishlistDigestBatchSize is how many users are sent a digest per run.
+const wishlistDigestBatchSize = 200
+
+// WishlistSettings configures wishlist notifications.
+type WishlistSettings struct {
+	// Notifier delivers the digests of wishlist notifications.
+	Notifier notify.Notifier
+	// DigestInterval is the least time between two digests to the same user.
+	// Changes in between wait for the next digest.
+	DigestInterval time.Duration
+	// UnsubscribeURL is the address of the unsubscribe endpoint; the
+	// product's unsubscribe token is appended to it.
+	UnsubscribeURL string
+}
+
+// WishlistService manages users' wishlists and tells subscribers when a
+// wishlisted product comes back in stock or gets cheaper.
+type WishlistService struct {
+	repo        repository.WishlistRepository
+	productRepo repository.ProductRepository
+	userRepo    *repository.UserRepository
+	settings    WishlistSettings
+}
+
+// NewWishlistService creates a new WishlistService instance.
+func NewWishlistService(
+	repo repository.WishlistRepository,
+	productRepo repository.ProductRepository,
+	userRepo *repository.UserRepository,
+	settings WishlistSettings,
+) *WishlistService {
+	return &WishlistService{repo: repo, productRepo: productRepo, userRepo: userRepo, settings: settings}
+}
+
+// GetWishlist retrieves the user's wishlist with the products.
+func (s *WishlistService) GetWishlist(userID uint) ([]models.WishlistItem, error) {
+	return s.repo.GetWishlist(userID)
+}
+
+// AddToWishlist saves a product to the user's wishlist. With notify set the
+// user is told when it comes back in stock or its price drops.
+func (s *WishlistService) AddToWishlist(userID, productID uint, notify bool) (*models.WishlistItem, error) {
+	product, err := s.productRepo.GetProductByID(productID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	item := &models.WishlistItem{
+		UserID:           userID,
+		ProductID:        productID,
+		Notify:           notify,
+		UnsubscribeToken: hex.EncodeToString(raw),
+	}
+	if err := s.repo.AddItem(item); err != nil {
+		return nil, err
+	}
+	item.Product = product
+	return item, nil
+}
+
+// SetNotify turns notifications about a wishlisted product on or off.
+func (s *WishlistService) SetNotify(userID, productID uint, notify bool) (*models.WishlistItem, error) {
+	return s.repo.SetNotify(userID, productID, notify)
+}
+
+// RemoveFromWishlist removes a product from the user's wishlist.
+func (s *WishlistService) RemoveFromWishlist(userID, productID uint) error {
+	return s.repo.RemoveItem(userID, productID)
+}
+
+// GetUnsubscribeItem looks up the wishlisted product an unsubscribe link was
+// made for without changing it.
+func (s *WishlistService) GetUnsubscribeItem(token string) (*models.WishlistItem, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, errors.New("invalid unsubscribe link")
+	}
+	return s.repo.GetItemByToken(token)
+}
+
+// Unsubscribe turns off notifications for the wishlisted product an
+// unsubscribe link was made for. The product stays in the wishlist.
+func (s *WishlistService) Unsubscribe(token string) (*models.WishlistItem, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, errors.New("invalid unsubscribe link")
+	}
+	return s.repo.UnsubscribeByToken(token)
+}
+
+// ProductChanged queues notifications for the subscribers of a product when
+// an update brought its stock from 0 back above 0 or lowered its price. They
+// are sent in the next digest.
+func (s *WishlistService) ProductChanged(before, after *models.Product) error {
+	if before.Stock <= 0 && after.Stock > 0 {
+		if _, err := s.repo.QueueNotifications(after.ID, models.WishlistNotificationBackInStock, before.Price, after.Price); err != nil {
+			return err
+		}
+	}
+	if after.Price < before.Price {
+		if _, err := s.repo.QueueNotifications(after.ID, models.WishlistNotificationPriceDrop, before.Price, after.Price); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendWishlistDigests sends each user with pending notifications, who has
+// not had a digest within the digest interval, one message covering all of
+// them. Notifications that no longer hold, because the product sold out
+// again, its price went back up, it was deleted or the user unsubscribed,
+// are dropped. It returns how many digests were sent. Sent notifications
+// are kept for the digest interval to rate-limit the next digest.
+func (s *WishlistService) SendWishlistDigests(ctx context.Context) (int, error) {
+	now := time.Now()
+	userIDs, err := s.repo.GetUsersWithPendingNotifications(now.Add(-s.settings.DigestInterval), wishlistDigestBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+		ok, err := s.sendDigest(ctx, userID, now)
+		if err != nil {
+			// Left pending so the next run tries again
+			logger.Error(fmt.Sprintf("could not send wishlist digest to user %d: %s", userID, err.Error()))
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	if _, err := s.repo.DeleteSentNotifications(now.Add(-s.settings.DigestInterval)); err != nil {
+		return sent, err
+	}
+	return sent, nil
+}
+
+// sendDigest sends a user's pending notifications that still hold in one
+// message and drops the others. It reports whether a message was sent.
+func (s *WishlistService) sendDigest(ctx context.Context, userID uint, now time.Time) (bool, error) {
+	notifications, err := s.repo.GetPendingNotifications(userID)
+	if err != nil {
+		return false, err
+	}
+	user, err := s.userRepo.GetUserByID(strconv.Itoa(int(userID)))
+	if err != nil {
+		return false, err
+	}
+	if user == nil || user.ErasedAt != nil {
+		return false, s.repo.DeleteNotifications(notificationIDs(notifications))
+	}
+
+	items, err := s.repo.GetWishlist(userID)
+	if err != nil {
+		return false, err
+	}
+	subscribed := make(map[uint]models.WishlistItem, len(items))
+	for _, item := range items {
+		if item.Notify && item.Product != nil {
+			subscribed[item.ProductID] = item
+		}
+	}
+
+	var lines []string
+	var due, stale []uint
+	for _, notification := range notifications {
+		item, ok := subscribed[notification.ProductID]
+		if !ok {
+			stale = append(stale, notification.ID)
+			continue
+		}
+		product := item.Product
+		var line string
+		switch notification.Kind {
+		case models.WishlistNotificationBackInStock:
+			if product.Stock <= 0 {
+				stale = append(stale, notification.ID)
+				continue
+			}
+			line = fmt.Sprintf("%s is back in stock at %.2f.", product.Name, product.Price)
+		case models.WishlistNotificationPriceDrop:
+			if product.Price >= notification.OldPrice {
+				stale = append(stale, notification.ID)
+				continue
+			}
+			line = fmt.Sprintf("%s dropped in price from %.2f to %.2f.", product.Name, notification.OldPrice, product.Price)
+		default:
+			stale = append(stale, notification.ID)
+			continue
+		}
+		lines = append(lines, line+"\nStop alerts for this product: "+s.settings.UnsubscribeURL+item.UnsubscribeToken)
+		due = append(due, notification.ID)
+	}
+
+	if err := s.repo.DeleteNotifications(stale); err != nil {
+		return false, err
+	}
+	if len(due) == 0 {
+		return false, nil
+	}
+
+	err = s.settings.Notifier.Notify(ctx, notify.Message{
+		To:      user.Email,
+		Subject: "Updates to products on your wishlist",
+		Body:    strings.Join(lines, "\n\n"),
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, s.repo.MarkNotificationsSent(due, now)
+}
+
+// notificationIDs returns the IDs of the notifications.
+func notificationIDs(notifications []models.WishlistNotification) []uint {
+	ids := make([]uint, len(notifications))
+	for i, notification := range notifications {
+		ids[i] = notification.ID
+	}
+	return ids
+}
@@ -0,0 +1,145 @@
+package shipping
+
+import (
+	"ecommerce-api/internal/models"
+	"math"
+	"sort"
+	"strings"
+)
+
+// VolumetricDivisor converts a parcel volume in cubic centimetres into a
+// volumetric weight in kilograms, as carriers do for bulky, light parcels.
+const VolumetricDivisor = 5000
+
+// Item is a quantity of one product to be shipped.
+type Item struct {
+	Product  *models.Product
+	Quantity int
+}
+
+// Parcel is what shipping is priced on.
+type Parcel struct {
+	// Weight is the chargeable weight in kilograms.
+	Weight float64
+	// Subtotal is the value of the goods, used by free-over-threshold methods.
+	Subtotal float64
+}
+
+// Quote is the cost of shipping a parcel with one method.
+type Quote struct {
+	MethodID uint    `json:"method_id"`
+	ZoneID   uint    `json:"zone_id"`
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Cost     float64 `json:"cost"`
+}
+
+// NewParcel builds a parcel from items at their current catalog price.
+func NewParcel(items []Item) Parcel {
+	var parcel Parcel
+	for _, item := range items {
+		parcel.Weight += ChargeableWeight(item.Product) * float64(item.Quantity)
+		parcel.Subtotal += item.Product.Price * float64(item.Quantity)
+	}
+	parcel.Weight = math.Round(parcel.Weight*1000) / 1000
+	parcel.Subtotal = round(parcel.Subtotal)
+	return parcel
+}
+
+// ChargeableWeight returns the greater of a product's actual and volumetric weight.
+func ChargeableWeight(product *models.Product) float64 {
+	volumetric := product.Length * product.Width * product.Height / VolumetricDivisor
+	return math.Max(product.Weight, volumetric)
+}
+
+// MatchZone returns the zone that ships to a destination, or nil if none does.
+// A zone naming the region outranks one covering the whole country, and both
+// outrank a catch-all zone without countries.
+func MatchZone(zones []models.ShippingZone, country, region string) *models.ShippingZone {
+	var best *models.ShippingZone
+	bestScore := -1
+	for i := range zones {
+		zone := &zones[i]
+		score := 0
+		if len(zone.Countries) > 0 {
+			if !containsFold(zone.Countries, country) {
+				continue
+			}
+			score = 1
+			if len(zone.Regions) > 0 {
+				if !containsFold(zone.Regions, region) {
+					continue
+				}
+				score = 2
+			}
+		}
+		if score > bestScore {
+			best, bestScore = zone, score
+		}
+	}
+	return best
+}
+
+// Cost works out what a method charges for a parcel. The second result is
+// false if the method cannot ship the parcel.
+func Cost(method *models.ShippingMethod, parcel Parcel) (float64, bool) {
+	if !method.Active {
+		return 0, false
+	}
+	if method.MinWeight > 0 && parcel.Weight < method.MinWeight {
+		return 0, false
+	}
+	if method.MaxWeight > 0 && parcel.Weight > method.MaxWeight {
+		return 0, false
+	}
+
+	switch method.Type {
+	case models.ShippingMethodFlat:
+		return round(method.Rate), true
+	case models.ShippingMethodWeightBased:
+		return round(method.Rate + method.PerKg*parcel.Weight), true
+	case models.ShippingMethodFreeThreshold:
+		if parcel.Subtotal >= method.FreeThreshold {
+			return 0, true
+		}
+		return round(method.Rate), true
+	}
+	return 0, false
+}
+
+// QuoteZone prices the parcel with every method of the zone that can ship it,
+// cheapest first.
+func QuoteZone(zone *models.ShippingZone, parcel Parcel) []Quote {
+	quotes := []Quote{}
+	for i := range zone.Methods {
+		method := &zone.Methods[i]
+		cost, ok := Cost(method, parcel)
+		if !ok {
+			continue
+		}
+		quotes = append(quotes, Quote{
+			MethodID: method.ID,
+			ZoneID:   zone.ID,
+			Name:     method.Name,
+			Type:     method.Type,
+			Cost:     cost,
+		})
+	}
+	sort.SliceStable(quotes, func(i, j int) bool { return quotes[i].Cost < quotes[j].Cost })
+	return quotes
+}
+
+// containsFold reports whether list contains value, ignoring case.
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// round rounds an amount to cents.
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,110 @@
+package shipping
+
+import (
+	"ecommerce-api/internal/models"
+	"testing"
+)
+
+func TestMatchZone(t *testing.T) {
+	zones := []models.ShippingZone{
+		{ID: 1, Name: "rest of world"},
+		{ID: 2, Name: "united states", Countries: []string{"US"}},
+		{ID: 3, Name: "alaska and hawaii", Countries: []string{"US"}, Regions: []string{"AK", "HI"}},
+		{ID: 4, Name: "germany", Countries: []string{" de "}},
+	}
+
+	tests := []struct {
+		country, region string
+		want            uint
+	}{
+		{"US", "CA", 2},
+		{"US", "hi", 3},
+		{"us", "", 2},
+		{"DE", "BY", 4},
+		{"FR", "", 1},
+	}
+	for _, tc := range tests {
+		var got uint
+		if zone := MatchZone(zones, tc.country, tc.region); zone != nil {
+			got = zone.ID
+		}
+		if got != tc.want {
+			t.Errorf("%s %s: got zone %d, want %d", tc.country, tc.region, got, tc.want)
+		}
+	}
+
+	if zone := MatchZone(zones[1:], "FR", ""); zone != nil {
+		t.Errorf("got zone %d for a destination no zone covers, want none", zone.ID)
+	}
+}
+
+func TestCost(t *testing.T) {
+	tests := map[string]struct {
+		method models.ShippingMethod
+		parcel Parcel
+		cost   float64
+		ok     bool
+	}{
+		"flat": {
+			models.ShippingMethod{Type: models.ShippingMethodFlat, Rate: 4.99, Active: true},
+			Parcel{Weight: 2, Subtotal: 20}, 4.99, true,
+		},
+		"weight based": {
+			models.ShippingMethod{Type: models.ShippingMethodWeightBased, Rate: 3, PerKg: 1.5, Active: true},
+			Parcel{Weight: 2.5, Subtotal: 20}, 6.75, true,
+		},
+		"below free threshold": {
+			models.ShippingMethod{Type: models.ShippingMethodFreeThreshold, Rate: 5, FreeThreshold: 50, Active: true},
+			Parcel{Weight: 1, Subtotal: 49.99}, 5, true,
+		},
+		"at free threshold": {
+			models.ShippingMethod{Type: models.ShippingMethodFreeThreshold, Rate: 5, FreeThreshold: 50, Active: true},
+			Parcel{Weight: 1, Subtotal: 50}, 0, true,
+		},
+		"inactive": {
+			models.ShippingMethod{Type: models.ShippingMethodFlat, Rate: 4.99},
+			Parcel{Weight: 1, Subtotal: 20}, 0, false,
+		},
+		"too light": {
+			models.ShippingMethod{Type: models.ShippingMethodFlat, Rate: 9, MinWeight: 5, Active: true},
+			Parcel{Weight: 4.9, Subtotal: 20}, 0, false,
+		},
+		"too heavy": {
+			models.ShippingMethod{Type: models.ShippingMethodFlat, Rate: 4.99, MaxWeight: 2, Active: true},
+			Parcel{Weight: 2.1, Subtotal: 20}, 0, false,
+		},
+		"unknown type": {
+			models.ShippingMethod{Type: "pigeon", Rate: 1, Active: true},
+			Parcel{Weight: 1, Subtotal: 20}, 0, false,
+		},
+	}
+	for name, tc := range tests {
+		cost, ok := Cost(&tc.method, tc.parcel)
+		if cost != tc.cost || ok != tc.ok {
+			t.Errorf("%s: got %.2f, %v, want %.2f, %v", name, cost, ok, tc.cost, tc.ok)
+		}
+	}
+}
+
+func TestQuoteZoneSkipsMethodsThatCannotShipAndSortsByCost(t *testing.T) {
+	zone := &models.ShippingZone{ID: 7, Methods: []models.ShippingMethod{
+		{ID: 1, Name: "Express", Type: models.ShippingMethodFlat, Rate: 12, Active: true},
+		{ID: 2, Name: "Freight", Type: models.ShippingMethodFlat, Rate: 30, MinWeight: 20, Active: true},
+		{ID: 3, Name: "Standard", Type: models.ShippingMethodWeightBased, Rate: 2, PerKg: 1, Active: true},
+		{ID: 4, Name: "Retired", Type: models.ShippingMethodFlat, Rate: 1},
+	}}
+
+	quotes := QuoteZone(zone, Parcel{Weight: 3, Subtotal: 40})
+	want := []Quote{
+		{MethodID: 3, ZoneID: 7, Name: "Standard", Type: models.ShippingMethodWeightBased, Cost: 5},
+		{MethodID: 1, ZoneID: 7, Name: "Express", Type: models.ShippingMethodFlat, Cost: 12},
+	}
+	if len(quotes) != len(want) {
+		t.Fatalf("got %d quotes, want %d: %+v", len(quotes), len(want), quotes)
+	}
+	for i := range want {
+		if quotes[i] != want[i] {
+			t.Errorf("quote %d: got %+v, want %+v", i, quotes[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package tax
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// csvColumns are the columns expected in a rate table, in order.
+var csvColumns = []string{"country", "region", "tax_class", "rate", "name"}
+
+// LoadRatesCSV reads a rate table with a header row of
+// country,region,tax_class,rate,name. Region and tax_class may be empty to
+// match any value; rate is a percentage such as 20 or 7.25.
+func LoadRatesCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read tax rate header: %w", err)
+	}
+	if len(header) != len(csvColumns) {
+		return nil, fmt.Errorf("tax rate header must be %s", strings.Join(csvColumns, ","))
+	}
+	for i, column := range csvColumns {
+		if strings.ToLower(strings.TrimSpace(header[i])) != column {
+			return nil, fmt.Errorf("tax rate header must be %s", strings.Join(csvColumns, ","))
+		}
+	}
+
+	var rates []Rate
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read tax rates: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		percent, err := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rate %q", line, record[3])
+		}
+		rates = append(rates, Rate{
+			Country:  strings.ToUpper(strings.TrimSpace(record[0])),
+			Region:   strings.ToUpper(strings.TrimSpace(record[1])),
+			TaxClass: strings.ToLower(strings.TrimSpace(record[2])),
+			Rate:     percent / 100,
+			Name:     strings.TrimSpace(record[4]),
+		})
+	}
+	return rates, nil
+}
+
+// LoadRatesFile reads a rate table from a CSV file.
+func LoadRatesFile(path string) ([]Rate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadRatesCSV(file)
+}
@@ -0,0 +1,152 @@
+package tax
+
+import (
+	"ecommerce-api/internal/utils"
+	"errors"
+	"strings"
+)
+
+// DefaultTaxClass is the tax class of products that do not set one.
+const DefaultTaxClass = "standard"
+
+// Line is a single taxable amount, such as an order line.
+type Line struct {
+	Reference string
+	TaxClass  string
+	Amount    float64
+}
+
+// Request describes what to calculate tax for.
+type Request struct {
+	Country string
+	Region  string
+	Lines   []Line
+	// PricesIncludeTax reports whether line amounts already contain tax.
+	PricesIncludeTax bool
+	// Exempt customers pay no tax; lines are still returned with a zero rate.
+	// Tax contained in their tax-inclusive amounts is deducted.
+	Exempt bool
+}
+
+// LineResult is the tax worked out for one Line.
+type LineResult struct {
+	Reference string
+	TaxClass  string
+	RateName  string
+	Rate      float64
+	// NetAmount is the amount before tax and TaxAmount the tax on it,
+	// whichever way the line amount was given.
+	NetAmount float64
+	TaxAmount float64
+	// DeductedRate is the rate of the tax deducted from an exempt customer's
+	// tax-inclusive amount to give NetAmount.
+	DeductedRate float64
+}
+
+// Result is the outcome of a tax calculation.
+type Result struct {
+	Lines    []LineResult
+	TotalTax float64
+}
+
+// TaxCalculator works out the tax due on a set of lines for a destination.
+type TaxCalculator interface {
+	Calculate(req Request) (*Result, error)
+}
+
+// Rate is the tax rate for a country, optionally narrowed to a region and a
+// product tax class. Rate is a fraction, so 0.2 means 20%.
+type Rate struct {
+	Country  string
+	Region   string
+	TaxClass string
+	Rate     float64
+	Name     string
+}
+
+// RuleBasedCalculator picks the most specific matching rate for every line.
+type RuleBasedCalculator struct {
+	rates []Rate
+}
+
+// NewRuleBasedCalculator creates a calculator from a rate table.
+func NewRuleBasedCalculator(rates []Rate) (*RuleBasedCalculator, error) {
+	for _, r := range rates {
+		if r.Country == "" {
+			return nil, errors.New("tax rate country is required")
+		}
+		if r.Rate < 0 || r.Rate >= 1 {
+			return nil, errors.New("tax rate must be a fraction between 0 and 1")
+		}
+	}
+	return &RuleBasedCalculator{rates: rates}, nil
+}
+
+// Calculate works out the tax on every line of the request.
+func (c *RuleBasedCalculator) Calculate(req Request) (*Result, error) {
+	result := &Result{}
+	for _, line := range req.Lines {
+		class := line.TaxClass
+		if class == "" {
+			class = DefaultTaxClass
+		}
+
+		rate := c.match(req.Country, req.Region, class)
+		lineResult := LineResult{
+			Reference: line.Reference,
+			TaxClass:  class,
+			RateName:  rate.Name,
+			Rate:      rate.Rate,
+		}
+		switch {
+		case req.Exempt && req.PricesIncludeTax:
+			lineResult.RateName, lineResult.Rate = "exempt", 0
+			lineResult.NetAmount = utils.RoundMoney(line.Amount / (1 + rate.Rate))
+			lineResult.DeductedRate = rate.Rate
+		case req.Exempt:
+			lineResult.RateName, lineResult.Rate = "exempt", 0
+			lineResult.NetAmount = utils.RoundMoney(line.Amount)
+		case req.PricesIncludeTax:
+			lineResult.NetAmount = utils.RoundMoney(line.Amount / (1 + rate.Rate))
+			lineResult.TaxAmount = utils.RoundMoney(line.Amount - lineResult.NetAmount)
+		default:
+			lineResult.NetAmount = utils.RoundMoney(line.Amount)
+			lineResult.TaxAmount = utils.RoundMoney(line.Amount * rate.Rate)
+		}
+
+		result.Lines = append(result.Lines, lineResult)
+		result.TotalTax += lineResult.TaxAmount
+	}
+	result.TotalTax = utils.RoundMoney(result.TotalTax)
+	return result, nil
+}
+
+// match returns the most specific rate for the destination and tax class.
+// A tax class match outranks a region match, and both outrank the country
+// default, so a national rate for the class beats a regional rate for all
+// classes. No match means no tax.
+func (c *RuleBasedCalculator) match(country, region, class string) Rate {
+	best, bestScore := Rate{Name: "none"}, -1
+	for _, r := range c.rates {
+		if !strings.EqualFold(r.Country, country) {
+			continue
+		}
+		score := 0
+		if r.Region != "" {
+			if !strings.EqualFold(r.Region, region) {
+				continue
+			}
+			score++
+		}
+		if r.TaxClass != "" {
+			if !strings.EqualFold(r.TaxClass, class) {
+				continue
+			}
+			score += 2
+		}
+		if score > bestScore {
+			best, bestScore = r, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,58 @@
+package tax
+
+import "testing"
+
+func TestMatchRanksTaxClassAboveRegion(t *testing.T) {
+	calculator, err := NewRuleBasedCalculator([]Rate{
+		{Country: "US", Rate: 0.05, Name: "national"},
+		{Country: "US", Region: "CA", Rate: 0.0725, Name: "california"},
+		{Country: "US", TaxClass: "food", Rate: 0.01, Name: "national food"},
+		{Country: "US", Region: "NY", TaxClass: "food", Rate: 0, Name: "new york food"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		region, class, want string
+	}{
+		{"CA", "food", "national food"},
+		{"CA", "standard", "california"},
+		{"NY", "food", "new york food"},
+		{"TX", "standard", "national"},
+	}
+	for _, tc := range tests {
+		if got := calculator.match("US", tc.region, tc.class); got.Name != tc.want {
+			t.Errorf("%s %s: got rate %q, want %q", tc.region, tc.class, got.Name, tc.want)
+		}
+	}
+}
+
+func TestCalculateDeductsIncludedTaxForExemptCustomers(t *testing.T) {
+	calculator, err := NewRuleBasedCalculator([]Rate{{Country: "GB", Rate: 0.2, Name: "VAT"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []Line{{Reference: "product", Amount: 120}}
+
+	tests := map[string]struct {
+		req           Request
+		net, tax, cut float64
+	}{
+		"inclusive":        {Request{Country: "GB", Lines: lines, PricesIncludeTax: true}, 100, 20, 0},
+		"exclusive":        {Request{Country: "GB", Lines: lines}, 120, 24, 0},
+		"exempt inclusive": {Request{Country: "GB", Lines: lines, PricesIncludeTax: true, Exempt: true}, 100, 0, 0.2},
+		"exempt exclusive": {Request{Country: "GB", Lines: lines, Exempt: true}, 120, 0, 0},
+	}
+	for name, tc := range tests {
+		result, err := calculator.Calculate(tc.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		line := result.Lines[0]
+		if line.NetAmount != tc.net || line.TaxAmount != tc.tax || line.DeductedRate != tc.cut || result.TotalTax != tc.tax {
+			t.Errorf("%s: got net %.2f, tax %.2f, deducted rate %.2f; want %.2f, %.2f, %.2f",
+				name, line.NetAmount, line.TaxAmount, line.DeductedRate, tc.net, tc.tax, tc.cut)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package utils
 
 import (
-	"net/http"
+	"ecommerce-api/internal/password"
 	"encoding/json"
+	"math"
+	"net/http"
+	"net/mail"
 )
 
 // RespondWithJSON is a utility function to send a JSON response with a specific status code.
@@ -17,20 +20,23 @@ func RespondWithError(w http.ResponseWriter, statusCode int, message string) {
 	RespondWithJSON(w, statusCode, map[string]string{"error": message})
 }
 
-// ValidateEmail checks if the provided email is valid.
+// ValidateEmail checks if the provided email is a bare, well-formed address.
 func ValidateEmail(email string) bool {
-	// Simple email validation logic (can be improved)
-	if len(email) == 0 {
+	address, err := mail.ParseAddress(email)
+	if err != nil {
 		return false
 	}
-	return true
+	// Reject display-name forms such as "Jane <jane@example.com>"
+	return address.Address == email
 }
 
-// ValidatePassword checks if the provided password meets certain criteria.
-func ValidatePassword(password string) bool {
-	// Simple password validation logic (can be improved)
-	if len(password) < 6 {
-		return false
-	}
-	return true
-}
\ No newline at end of file
+// ValidatePassword checks if the provided password meets the default password policy.
+// Use password.Policy directly to find out which rule failed.
+func ValidatePassword(pw string) bool {
+	return password.DefaultPolicy().Validate(pw) == nil
+}
+
+// RoundMoney rounds an amount to whole cents.
+func RoundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}